@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"text/template"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+// Agent is a voice agent persona. Instructions and Greeting are Go
+// text/templates rendered once per call with CallVars.
+type Agent struct {
+	Name         string `json:"name"`
+	Instructions string `json:"instructions"`
+	Greeting     string `json:"greeting"`
+
+	// ExtractionSchema is the JSON schema of the structured data pulled
+	// from the transcript after the call. Empty disables extraction.
+	ExtractionSchema map[string]interface{} `json:"extraction_schema,omitempty"`
+
+	// Inactivity sets the silent-caller timers; nil uses DefaultInactivity.
+	Inactivity *Inactivity `json:"inactivity,omitempty"`
+
+	// Limits caps call length and cost; nil uses DefaultLimits.
+	Limits *Limits `json:"limits,omitempty"`
+
+	// Filler plays while a tool call runs long; nil plays nothing.
+	Filler *Filler `json:"filler,omitempty"`
+
+	// Session picks the model, voice and tuning per provider; unset
+	// fields use the catalogue defaults.
+	Session catalog.Selection `json:"session,omitempty"`
+
+	// Languages the agent speaks, the first being the default; the
+	// caller's language is detected when there are several. Nil is
+	// English only.
+	Languages []Language `json:"languages,omitempty"`
+
+	// RecordKeypadDigits keeps the keys the caller types in transcripts.
+	// They are masked by default, as they may be OTPs or account numbers;
+	// the model always sees them.
+	RecordKeypadDigits bool `json:"record_keypad_digits,omitempty"`
+
+	instructionsTmpl *template.Template
+	greetingTmpl     *template.Template
+}
+
+// Config is the on-disk agent configuration (see AGENT_CONFIG).
+type Config struct {
+	DefaultAgent string   `json:"default_agent"`
+	Agents       []*Agent `json:"agents"`
+}
+
+var logger = logging.For("agent")
+
+var (
+	mu           sync.RWMutex
+	agents       = map[string]*Agent{}
+	defaultAgent string
+)
+
+func init() {
+	// Built-in agents are always valid, so a missing config file still
+	// leaves the process with a usable default.
+	if err := apply(&Config{DefaultAgent: DefaultAgent.Name, Agents: []*Agent{DefaultAgent}}); err != nil {
+		panic(err)
+	}
+}
+
+// Load reads the agent config from path and validates every template.
+// An empty path keeps the built-in default agent.
+func Load(path string) error {
+	if path == "" {
+		logger.Info("no AGENT_CONFIG set, using built-in agent", "agent", DefaultAgent.Name)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read agent config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse agent config %s: %w", path, err)
+	}
+
+	if err := apply(&cfg); err != nil {
+		return err
+	}
+	for _, a := range cfg.Agents {
+		if a.ExtractionSchema == nil {
+			logger.Warn("agent has no extraction_schema, post-call extraction is off", "agent", a.Name)
+		}
+	}
+	logger.Info("loaded agents", "count", len(cfg.Agents), "path", path, "default", defaultAgent)
+	return nil
+}
+
+func apply(cfg *Config) error {
+	if len(cfg.Agents) == 0 {
+		return fmt.Errorf("agent config has no agents")
+	}
+
+	loaded := make(map[string]*Agent, len(cfg.Agents))
+	for _, a := range cfg.Agents {
+		if a.Name == "" {
+			return fmt.Errorf("agent config: agent without a name")
+		}
+		if _, dup := loaded[a.Name]; dup {
+			return fmt.Errorf("agent config: duplicate agent %q", a.Name)
+		}
+		if err := a.compile(); err != nil {
+			return err
+		}
+		loaded[a.Name] = a
+	}
+
+	def := cfg.DefaultAgent
+	if def == "" {
+		def = cfg.Agents[0].Name
+	}
+	if _, ok := loaded[def]; !ok {
+		return fmt.Errorf("agent config: default agent %q is not defined", def)
+	}
+
+	mu.Lock()
+	agents = loaded
+	defaultAgent = def
+	mu.Unlock()
+	return nil
+}
+
+// compile parses both templates and renders them once against sample
+// variables, so unknown fields or bad syntax fail at load time. Missing map
+// keys are errors too; templates use index for optional customer or
+// campaign fields.
+func (a *Agent) compile() error {
+	var err error
+	if a.instructionsTmpl, err = template.New(a.Name + ".instructions").Option("missingkey=error").Parse(a.Instructions); err != nil {
+		return fmt.Errorf("agent %q: instructions template: %w", a.Name, err)
+	}
+	if a.greetingTmpl, err = template.New(a.Name + ".greeting").Option("missingkey=error").Parse(a.Greeting); err != nil {
+		return fmt.Errorf("agent %q: greeting template: %w", a.Name, err)
+	}
+
+	if a.ExtractionSchema != nil && a.ExtractionSchema["type"] != "object" {
+		return fmt.Errorf("agent %q: extraction_schema must be a JSON schema of type object", a.Name)
+	}
+	if err := a.Inactivity.validate(); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if err := a.Limits.validate(); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if err := a.Filler.load(); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if err := a.Session.Validate(); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if err := a.compileLanguages(); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+
+	sample := SampleVars()
+	if _, err := a.RenderInstructions(sample); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	if _, err := a.RenderGreeting(sample); err != nil {
+		return fmt.Errorf("agent %q: %w", a.Name, err)
+	}
+	return nil
+}
+
+// Get returns the named agent, or the default agent when name is empty or unknown.
+func Get(name string) *Agent {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if a, ok := agents[name]; ok {
+		return a
+	}
+	if name != "" {
+		logger.Warn("unknown agent, falling back to default", "agent", name, "default", defaultAgent)
+	}
+	return agents[defaultAgent]
+}
+
+// RenderInstructions renders the system prompt for a call.
+func (a *Agent) RenderInstructions(vars CallVars) (string, error) {
+	return render(a.instructionsTmpl, vars)
+}
+
+// RenderGreeting renders the opening line the agent speaks on connect.
+func (a *Agent) RenderGreeting(vars CallVars) (string, error) {
+	return render(a.greetingTmpl, vars)
+}
+
+func render(t *template.Template, vars CallVars) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("render %s: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/AVVKavvk/openai-vobiz/claims"
+)
+
+func TestExampleConfigExtractsFNOL(t *testing.T) {
+	if err := Load("../agents.example.json"); err != nil {
+		t.Fatalf("load example: %v", err)
+	}
+	t.Cleanup(func() {
+		apply(&Config{DefaultAgent: DefaultAgent.Name, Agents: []*Agent{DefaultAgent}})
+	})
+
+	// Compare as decoded JSON, the form the config file takes
+	var want map[string]interface{}
+	data, _ := json.Marshal(claims.FNOLSchema)
+	json.Unmarshal(data, &want)
+
+	if got := Get("anika").ExtractionSchema; !reflect.DeepEqual(got, want) {
+		t.Errorf("example extraction_schema differs from claims.FNOLSchema")
+	}
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+// Filler is audio played to the caller while a tool call runs long, such
+// as a "let me check that" clip or hold music.
+type Filler struct {
+	// Audio is a WAV file, or raw 8kHz μ-law for any other extension
+	Audio string `json:"audio"`
+	// AfterMs is how long a tool call runs before the filler starts
+	AfterMs int `json:"after_ms,omitempty"`
+	// Loop repeats the audio until the tool returns, for hold music
+	Loop bool `json:"loop,omitempty"`
+
+	clip []byte // 8kHz μ-law
+}
+
+const defaultFillerAfter = 1200 * time.Millisecond
+
+// FillerAudio returns the agent's filler as 8kHz μ-law, with its delay and
+// whether it loops. clip is nil when the agent has no filler.
+func (a *Agent) FillerAudio() (clip []byte, after time.Duration, loop bool) {
+	f := a.Filler
+	if f == nil || len(f.clip) == 0 {
+		return nil, 0, false
+	}
+	after = defaultFillerAfter
+	if f.AfterMs > 0 {
+		after = time.Duration(f.AfterMs) * time.Millisecond
+	}
+	return f.clip, after, f.Loop
+}
+
+// load reads the audio file, so a missing asset fails at startup.
+func (f *Filler) load() error {
+	if f == nil || f.Audio == "" {
+		return nil
+	}
+	if f.AfterMs < 0 {
+		return fmt.Errorf("filler after_ms must not be negative")
+	}
+
+	if strings.EqualFold(filepath.Ext(f.Audio), ".wav") {
+		w, err := audio.ReadWAVFile(f.Audio)
+		if err != nil {
+			return fmt.Errorf("filler audio: %w", err)
+		}
+		if f.clip, err = w.MuLaw8k(); err != nil {
+			return fmt.Errorf("filler audio %s: %w", f.Audio, err)
+		}
+	} else {
+		data, err := os.ReadFile(f.Audio)
+		if err != nil {
+			return fmt.Errorf("filler audio: %w", err)
+		}
+		f.clip = data
+	}
+	if len(f.clip) == 0 {
+		return fmt.Errorf("filler audio %s is empty", f.Audio)
+	}
+	return nil
+}
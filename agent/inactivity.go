@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+)
+
+// Inactivity configures what happens when the caller goes quiet: after
+// RepromptAfterSec without caller speech the model is asked to check in,
+// and after HangupAfterSec more it says Closing and the call is ended.
+type Inactivity struct {
+	Disabled         bool    `json:"disabled,omitempty"`
+	RepromptAfterSec float64 `json:"reprompt_after_sec,omitempty"`
+	HangupAfterSec   float64 `json:"hangup_after_sec,omitempty"`
+	// Reprompt is the instruction given to the model, not spoken verbatim
+	Reprompt string `json:"reprompt,omitempty"`
+	// Closing is spoken verbatim before hanging up
+	Closing string `json:"closing,omitempty"`
+}
+
+var DefaultInactivity = Inactivity{
+	RepromptAfterSec: 10,
+	HangupAfterSec:   10,
+	Reprompt:         `The caller has gone quiet. Briefly check that they are still there, for example "Are you still there?", then wait.`,
+	Closing:          "I haven't heard from you, so I'll end the call now. Please call us back any time. Goodbye.",
+}
+
+// Timers returns the reprompt and hangup delays, both zero when disabled.
+func (i Inactivity) Timers() (reprompt, hangup time.Duration) {
+	if i.Disabled {
+		return 0, 0
+	}
+	return time.Duration(i.RepromptAfterSec * float64(time.Second)), time.Duration(i.HangupAfterSec * float64(time.Second))
+}
+
+// InactivityConfig returns the agent's inactivity settings with unset
+// fields taken from DefaultInactivity.
+func (a *Agent) InactivityConfig() Inactivity {
+	c := DefaultInactivity
+	if a.Inactivity == nil {
+		return c
+	}
+	i := *a.Inactivity
+	c.Disabled = i.Disabled
+	if i.RepromptAfterSec > 0 {
+		c.RepromptAfterSec = i.RepromptAfterSec
+	}
+	if i.HangupAfterSec > 0 {
+		c.HangupAfterSec = i.HangupAfterSec
+	}
+	if i.Reprompt != "" {
+		c.Reprompt = i.Reprompt
+	}
+	if i.Closing != "" {
+		c.Closing = i.Closing
+	}
+	return c
+}
+
+func (i *Inactivity) validate() error {
+	if i == nil {
+		return nil
+	}
+	if i.RepromptAfterSec < 0 || i.HangupAfterSec < 0 {
+		return fmt.Errorf("inactivity timers must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+)
+
+// Language is one language an agent speaks. The call starts in the first
+// of the agent's languages (or the campaign's "language" variable) and
+// switches once the caller's language is detected.
+type Language struct {
+	// Code is the ISO 639-1 code, e.g. "hi"
+	Code string `json:"code"`
+	// Name is how the model is told the language; known codes default it
+	Name string `json:"name,omitempty"`
+	// Locale is the BCP-47 tag for speech settings; defaults to Code-IN
+	Locale string `json:"locale,omitempty"`
+	// Instructions are added to the prompt while the call is in this
+	// language, e.g. how formal to be
+	Instructions string `json:"instructions,omitempty"`
+	// Voices maps a provider ("openai", "gemini") to the voice to use
+	Voices map[string]string `json:"voices,omitempty"`
+}
+
+var languageNames = map[string]string{
+	"en": "English",
+	"hi": "Hindi",
+	"mr": "Marathi",
+	"kn": "Kannada",
+	"ta": "Tamil",
+	"te": "Telugu",
+	"gu": "Gujarati",
+	"bn": "Bengali",
+}
+
+// English is the language of agents that configure none.
+var English = Language{Code: "en", Name: "English", Locale: "en-IN"}
+
+// LanguageCodes returns the codes of the agent's languages.
+func (a *Agent) LanguageCodes() []string {
+	codes := make([]string, len(a.Languages))
+	for i, l := range a.Languages {
+		codes[i] = l.Code
+	}
+	return codes
+}
+
+// Language returns the agent's language with code, or its first one
+// (English without any) when the agent does not speak it.
+func (a *Agent) Language(code string) Language {
+	for _, l := range a.Languages {
+		if l.Code == code {
+			return l
+		}
+	}
+	if len(a.Languages) > 0 {
+		return a.Languages[0]
+	}
+	return English
+}
+
+// StartLanguage is the language a call starts in: the campaign's
+// "language" variable when the agent speaks it, else its first language.
+func (a *Agent) StartLanguage(vars CallVars) Language {
+	code, _ := vars.Campaign["language"].(string)
+	return a.Language(strings.ToLower(code))
+}
+
+// Session is the language's voices as a session settings layer.
+func (l Language) Session() catalog.Selection {
+	sel := catalog.Selection{}
+	for provider, voice := range l.Voices {
+		sel[provider] = catalog.Settings{Voice: voice}
+	}
+	return sel
+}
+
+// Instruction tells the model to speak the language, for the prompt or
+// mid-call when the language changes.
+func (l Language) Instruction() string {
+	s := fmt.Sprintf("Speak %s with the caller. If they switch language, follow them.", l.Name)
+	if l.Instructions != "" {
+		s += " " + l.Instructions
+	}
+	return s
+}
+
+func (a *Agent) compileLanguages() error {
+	seen := map[string]bool{}
+	for i := range a.Languages {
+		l := &a.Languages[i]
+		l.Code = strings.ToLower(strings.TrimSpace(l.Code))
+		if len(l.Code) != 2 {
+			return fmt.Errorf("language code %q is not an ISO 639-1 code", l.Code)
+		}
+		if seen[l.Code] {
+			return fmt.Errorf("duplicate language %q", l.Code)
+		}
+		seen[l.Code] = true
+		if l.Name == "" {
+			l.Name = languageNames[l.Code]
+		}
+		if l.Name == "" {
+			return fmt.Errorf("language %q needs a name", l.Code)
+		}
+		if l.Locale == "" {
+			l.Locale = l.Code + "-IN"
+		}
+		if err := l.Session().Validate(); err != nil {
+			return fmt.Errorf("language %q: %w", l.Code, err)
+		}
+	}
+	return nil
+}
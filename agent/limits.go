@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bound a call's length and provider cost. Shortly before a limit
+// the model is told to wrap up; at the limit it says Closing and the call
+// is ended.
+type Limits struct {
+	MaxDurationSec float64 `json:"max_duration_sec,omitempty"`
+	// MaxCostUSD is the estimated provider cost cap; 0 means no cap
+	MaxCostUSD float64 `json:"max_cost_usd,omitempty"`
+	// WrapUpSec is how long before MaxDurationSec the wrap-up starts; cost
+	// wrap-up starts at 90% of MaxCostUSD
+	WrapUpSec float64 `json:"wrap_up_sec,omitempty"`
+	// WrapUp is the instruction given to the model, not spoken verbatim
+	WrapUp string `json:"wrap_up,omitempty"`
+	// Closing is spoken verbatim before hanging up
+	Closing string `json:"closing,omitempty"`
+}
+
+var DefaultLimits = Limits{
+	MaxDurationSec: 900,
+	WrapUpSec:      60,
+	WrapUp:         "The call is nearly out of time. Start wrapping up: finish the current step, tell the caller what happens next and do not start anything new.",
+	Closing:        "We've reached the time limit for this call, so I'll need to end it here. Thank you for calling, goodbye.",
+}
+
+// LimitsConfig returns the agent's limits with unset fields taken from
+// DefaultLimits.
+func (a *Agent) LimitsConfig() Limits {
+	c := DefaultLimits
+	if a.Limits == nil {
+		return c
+	}
+	l := *a.Limits
+	if l.MaxDurationSec > 0 {
+		c.MaxDurationSec = l.MaxDurationSec
+	}
+	if l.MaxCostUSD > 0 {
+		c.MaxCostUSD = l.MaxCostUSD
+	}
+	if l.WrapUpSec > 0 {
+		c.WrapUpSec = l.WrapUpSec
+	}
+	if l.WrapUp != "" {
+		c.WrapUp = l.WrapUp
+	}
+	if l.Closing != "" {
+		c.Closing = l.Closing
+	}
+	return c
+}
+
+// MaxDuration is the hard call length limit.
+func (l Limits) MaxDuration() time.Duration {
+	return time.Duration(l.MaxDurationSec * float64(time.Second))
+}
+
+// WrapUpAt is the call length at which the model is told to wrap up.
+func (l Limits) WrapUpAt() time.Duration {
+	return time.Duration(max(l.MaxDurationSec-l.WrapUpSec, 0) * float64(time.Second))
+}
+
+// WrapUpCostUSD is the estimated cost at which the model is told to wrap
+// up, 0 without a cost cap.
+func (l Limits) WrapUpCostUSD() float64 {
+	return l.MaxCostUSD * 0.9
+}
+
+func (l *Limits) validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.MaxDurationSec < 0 || l.MaxCostUSD < 0 || l.WrapUpSec < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+)
+
+// SessionSettings resolves a call's settings on provider, lowest
+// precedence first: the catalogue defaults, the agent's session, the
+// language's voice, the campaign's "session" variable and request (the
+// outbound request's session). An invalid campaign or request layer is
+// skipped and reported in the error.
+func (a *Agent) SessionSettings(provider string, lang Language, vars CallVars, request catalog.Selection) (catalog.Settings, error) {
+	layers := []catalog.Selection{a.Session, lang.Session()}
+	var errs []error
+
+	campaign, err := catalog.SelectionFrom(vars.Campaign["session"])
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		layers = append(layers, campaign)
+	}
+	if err := request.Validate(); err != nil {
+		errs = append(errs, err)
+	} else {
+		layers = append(layers, request)
+	}
+
+	s, err := catalog.Resolve(provider, layers...)
+	if err != nil {
+		// The agent's own layers were checked at load, so this is an
+		// unknown provider or a catalogue reloaded since
+		fallback, _ := catalog.Resolve(provider)
+		return fallback, err
+	}
+	return s, errors.Join(errs...)
+}
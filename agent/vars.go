@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	_ "time/tzdata" // caller time zones must resolve in slim containers
+
+	"github.com/AVVKavvk/openai-vobiz/claims"
+)
+
+// CallVars are the variables available to agent templates.
+type CallVars struct {
+	CallId       string
+	CallerNumber string
+	DialedNumber string
+
+	// LocalTime is the current time in the caller's zone, TimeOfDay is
+	// one of "morning", "afternoon", "evening" or "night".
+	LocalTime time.Time
+	TimeOfDay string
+
+	Customer map[string]interface{} // customer record fields
+	Campaign map[string]interface{} // outbound campaign variables (body_data)
+
+	ClaimRef string // claim reference reserved for this call
+}
+
+// NewCallVars builds the template variables for a call. bodyData is the
+// JSON campaign payload forwarded from /outbound-call, if any.
+func NewCallVars(callId, from, to, bodyData string, customer map[string]interface{}) CallVars {
+	loc := callerLocation(from)
+	now := time.Now().In(loc)
+
+	campaign := map[string]interface{}{}
+	if bodyData != "" {
+		if err := json.Unmarshal([]byte(bodyData), &campaign); err != nil {
+			logger.Warn("ignoring invalid body_data", "call_id", callId, "error", err)
+		}
+	}
+	if customer == nil {
+		customer = map[string]interface{}{}
+	}
+
+	return CallVars{
+		CallId:       callId,
+		CallerNumber: from,
+		DialedNumber: to,
+		LocalTime:    now,
+		TimeOfDay:    timeOfDay(now),
+		Customer:     customer,
+		Campaign:     campaign,
+		ClaimRef:     claims.NewReference(callId),
+	}
+}
+
+// SampleVars returns representative values used to validate templates.
+func SampleVars() CallVars {
+	now := time.Date(2026, 1, 15, 11, 30, 0, 0, callerLocation("918504074217"))
+	return CallVars{
+		CallId:       "00000000-0000-0000-0000-000000000000",
+		CallerNumber: "918504074217",
+		DialedNumber: "08071387304",
+		LocalTime:    now,
+		TimeOfDay:    timeOfDay(now),
+		Customer: map[string]interface{}{
+			"name":    "Sample Customer",
+			"age":     30,
+			"address": "Sample Address",
+		},
+		Campaign: map[string]interface{}{},
+		ClaimRef: "KW-2A4C6D",
+	}
+}
+
+// callerPrefixes maps international dialling prefixes to a time zone.
+// Longer prefixes must come first.
+var callerPrefixes = []struct {
+	prefix string
+	zone   string
+}{
+	{"971", "Asia/Dubai"},
+	{"91", "Asia/Kolkata"},
+	{"65", "Asia/Singapore"},
+	{"44", "Europe/London"},
+	{"1", "America/New_York"},
+}
+
+const defaultZone = "Asia/Kolkata"
+
+func callerLocation(number string) *time.Location {
+	n := strings.TrimPrefix(strings.TrimSpace(number), "+")
+
+	zone := defaultZone
+	// A leading 0 is a domestic number, which for Vobiz means India.
+	if !strings.HasPrefix(n, "0") {
+		for _, p := range callerPrefixes {
+			if strings.HasPrefix(n, p.prefix) {
+				zone = p.zone
+				break
+			}
+		}
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func timeOfDay(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 5 && h < 12:
+		return "morning"
+	case h >= 12 && h < 17:
+		return "afternoon"
+	case h >= 17 && h < 21:
+		return "evening"
+	default:
+		return "night"
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/AVVKavvk/openai-vobiz/amd"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleMachineDetection receives Vobiz's answering machine verdict for an
+// outbound call and hands it to the stream screening that call.
+func HandleMachineDetection(c echo.Context) error {
+	ctx := c.Request().Context()
+	callUUID := c.FormValue("CallUUID")
+	machine := c.FormValue("MachineDetection") == "true"
+
+	if !amd.Report(callUUID, machine) {
+		// The stream is screening on another instance, or already done
+		logger.DebugContext(ctx, "machine detection result for unknown call", "call_id", callUUID, "machine", machine)
+		return c.NoContent(http.StatusOK)
+	}
+	logger.InfoContext(ctx, "machine detection result", "call_id", callUUID, "machine", machine)
+	return c.NoContent(http.StatusOK)
+}
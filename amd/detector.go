@@ -0,0 +1,325 @@
+package amd
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Who answered
+const (
+	Human   = "human"
+	Machine = "machine"
+	Unknown = "unknown" // undecided in time; handled as a person
+)
+
+// Event is a step in screening a call.
+type Event int
+
+const (
+	None Event = iota
+	// Answered: Result is decided; on Human or Unknown the agent takes over
+	Answered
+	// MessageReady: the machine's greeting is over, leave the message now
+	MessageReady
+)
+
+// Config tunes the heuristic. Zero fields take the defaults.
+type Config struct {
+	// MaxGreeting is the longest a person's opening utterance runs;
+	// voicemail greetings run longer
+	MaxGreeting time.Duration
+	// HumanSilence after a short greeting means a person waiting for a reply
+	HumanSilence time.Duration
+	// Window is how long to listen before giving up as Unknown
+	Window time.Duration
+	// MessageWait is the most to wait for a machine's beep
+	MessageWait time.Duration
+	// GreetingEnd of silence after a machine's greeting, without a beep,
+	// counts as the end of it
+	GreetingEnd time.Duration
+}
+
+var DefaultConfig = Config{
+	MaxGreeting:  2500 * time.Millisecond,
+	HumanSilence: 300 * time.Millisecond,
+	Window:       5 * time.Second,
+	MessageWait:  20 * time.Second,
+	GreetingEnd:  1500 * time.Millisecond,
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig
+	if c.MaxGreeting > 0 {
+		d.MaxGreeting = c.MaxGreeting
+	}
+	if c.HumanSilence > 0 {
+		d.HumanSilence = c.HumanSilence
+	}
+	if c.Window > 0 {
+		d.Window = c.Window
+	}
+	if c.MessageWait > 0 {
+		d.MessageWait = c.MessageWait
+	}
+	if c.GreetingEnd > 0 {
+		d.GreetingEnd = c.GreetingEnd
+	}
+	return d
+}
+
+const frameDuration = 20 * time.Millisecond
+
+// Detector screens one call, fed its caller frames in order. Vobiz's own
+// verdict may arrive concurrently through Report.
+type Detector struct {
+	callID string
+	cfg    Config
+
+	mu          sync.Mutex
+	elapsed     time.Duration // audio seen
+	heard       bool          // any utterance started
+	utterance   time.Duration // length of the current utterance
+	quiet       time.Duration // since the last utterance ended
+	result      string
+	reason      string // what decided the result
+	answered    bool   // Answered was returned
+	decidedAt   time.Duration
+	ready       bool
+	beep        beepDetector
+	beeped      bool // the machine's beep was heard
+	disposition string
+}
+
+// NewDetector starts screening callID; Vobiz verdicts for it reach the
+// detector until Close.
+func NewDetector(callID string, cfg Config) *Detector {
+	d := &Detector{callID: callID, cfg: cfg.withDefaults()}
+	registryMu.Lock()
+	registry[callID] = d
+	registryMu.Unlock()
+	return d
+}
+
+// Close stops taking Vobiz verdicts.
+func (d *Detector) Close() {
+	registryMu.Lock()
+	if registry[d.callID] == d {
+		delete(registry, d.callID)
+	}
+	registryMu.Unlock()
+}
+
+// Frame adds one 20ms caller frame of 8kHz PCM; speaking is whether the
+// caller is mid-utterance by the VAD.
+func (d *Detector) Frame(pcm []int16, speaking bool) Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.elapsed += frameDuration
+	if speaking {
+		d.heard = true
+		d.utterance += frameDuration
+		d.quiet = 0
+	} else {
+		d.utterance = 0
+		d.quiet += frameDuration
+	}
+	beep := d.beep.frame(pcm)
+	if beep {
+		d.beeped = true
+	}
+
+	if d.result == "" {
+		switch {
+		case beep:
+			d.decideLocked(Machine, "beep")
+		case d.utterance > d.cfg.MaxGreeting:
+			d.decideLocked(Machine, "long_greeting")
+		case d.heard && !speaking && d.quiet >= d.cfg.HumanSilence:
+			d.decideLocked(Human, "short_greeting")
+		case d.elapsed >= d.cfg.Window:
+			d.decideLocked(Unknown, "timeout")
+		}
+	}
+	return d.nextLocked()
+}
+
+// Report applies Vobiz's machine detection verdict if the call is still
+// undecided.
+func (d *Detector) Report(machine bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.result != "" {
+		return
+	}
+	if machine {
+		d.decideLocked(Machine, "vobiz")
+	} else {
+		d.decideLocked(Human, "vobiz")
+	}
+}
+
+func (d *Detector) decideLocked(result, reason string) {
+	d.result, d.reason, d.decidedAt = result, reason, d.elapsed
+}
+
+// nextLocked returns the event the state has reached, each once.
+func (d *Detector) nextLocked() Event {
+	if d.result == "" {
+		return None
+	}
+	if !d.answered {
+		d.answered = true
+		return Answered
+	}
+	if d.result != Machine || d.ready {
+		return None
+	}
+	// The recording starts once the beep stops; without one, once the
+	// greeting has gone quiet
+	waited := d.elapsed - d.decidedAt
+	beepOver := d.beeped && !d.beep.active()
+	if beepOver || (!d.beeped && d.quiet >= d.cfg.GreetingEnd) || waited >= d.cfg.MessageWait {
+		d.ready = true
+		return MessageReady
+	}
+	return None
+}
+
+// Result returns who answered, "" while undecided.
+func (d *Detector) Result() string {
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.result
+}
+
+// Reason returns what decided the result: "beep", "long_greeting",
+// "short_greeting", "timeout" or "vobiz".
+func (d *Detector) Reason() string {
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reason
+}
+
+// Done reports whether a person (or nobody decided in time) answered and
+// the caller's audio is the agent's. It stays false for a machine.
+func (d *Detector) Done() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.answered && d.result != Machine
+}
+
+// SetDisposition records how the bridge handled a machine.
+func (d *Detector) SetDisposition(disposition string) {
+	d.mu.Lock()
+	d.disposition = disposition
+	d.mu.Unlock()
+}
+
+// Disposition returns what SetDisposition recorded.
+func (d *Detector) Disposition() string {
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.disposition
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Detector{}
+)
+
+// Report passes Vobiz's verdict for callID to its detector and reports
+// whether one was screening the call here.
+func Report(callID string, machine bool) bool {
+	registryMu.Lock()
+	d := registry[callID]
+	registryMu.Unlock()
+	if d == nil {
+		return false
+	}
+	d.Report(machine)
+	return true
+}
+
+// Beeps are single tones in this range held for beepFrames
+const (
+	beepLow    = 400
+	beepHigh   = 2000
+	beepStep   = 25
+	beepShare  = 0.7
+	beepMinRMS = 300
+	beepFrames = 8 // 160ms
+)
+
+// beepDetector finds the tone a voicemail plays before recording.
+type beepDetector struct {
+	freq float64 // tone of the current run
+	run  int     // frames in a row with it
+}
+
+// frame reports true when a beep has just been held long enough.
+func (b *beepDetector) frame(pcm []int16) bool {
+	f := dominantTone(pcm)
+	if f == 0 || math.Abs(f-b.freq) > beepStep {
+		b.freq, b.run = f, 0
+		if f == 0 {
+			return false
+		}
+	}
+	b.run++
+	return b.run == beepFrames
+}
+
+func (b *beepDetector) active() bool {
+	return b.run > 0
+}
+
+// dominantTone returns the frequency of a single tone carrying most of the
+// frame's energy, or 0.
+func dominantTone(pcm []int16) float64 {
+	n := len(pcm)
+	if n == 0 {
+		return 0
+	}
+	var energy float64
+	for _, s := range pcm {
+		energy += float64(s) * float64(s)
+	}
+	if math.Sqrt(energy/float64(n)) < beepMinRMS {
+		return 0
+	}
+	var best, bestPower float64
+	for f := float64(beepLow); f <= beepHigh; f += beepStep {
+		if p := goertzel(pcm, f); p > bestPower {
+			best, bestPower = f, p
+		}
+	}
+	// A pure tone of the frame's energy has power energy*n/2
+	if bestPower/(energy*float64(n)/2) < beepShare {
+		return 0
+	}
+	return best
+}
+
+func goertzel(pcm []int16, freq float64) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*freq/8000)
+	var s1, s2 float64
+	for _, x := range pcm {
+		s0 := float64(x) + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
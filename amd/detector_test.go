@@ -0,0 +1,204 @@
+package amd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const frameSamples = 160 // 20ms at 8kHz
+
+// frame is one 20ms step of the caller's audio: the samples and whether
+// the VAD calls it speech.
+type frame struct {
+	pcm      []int16
+	speaking bool
+}
+
+// speech is d of a voice: harmonics of 150Hz, none of them dominant.
+func speech(d time.Duration) []frame {
+	return frames(d, true, func(t float64) float64 {
+		var v float64
+		for h := 1; h <= 12; h++ {
+			v += 2000 / math.Sqrt(float64(h)) * math.Sin(2*math.Pi*150*float64(h)*t+float64(h))
+		}
+		return v
+	})
+}
+
+// beep is d of the single tone a voicemail plays before recording.
+func beep(hz float64, d time.Duration) []frame {
+	return frames(d, false, func(t float64) float64 { return 8000 * math.Sin(2*math.Pi*hz*t) })
+}
+
+func quiet(d time.Duration) []frame {
+	return frames(d, false, func(float64) float64 { return 0 })
+}
+
+func frames(d time.Duration, speaking bool, wave func(t float64) float64) []frame {
+	out := make([]frame, d/frameDuration)
+	for n := range out {
+		pcm := make([]int16, frameSamples)
+		for i := range pcm {
+			pcm[i] = int16(wave(float64(n*frameSamples+i) / 8000))
+		}
+		out[n] = frame{pcm, speaking}
+	}
+	return out
+}
+
+// event is what the detector returned and when.
+type event struct {
+	ev Event
+	at time.Duration
+}
+
+func screen(d *Detector, parts ...[]frame) []event {
+	var events []event
+	var at time.Duration
+	for _, part := range parts {
+		for _, f := range part {
+			at += frameDuration
+			if ev := d.Frame(f.pcm, f.speaking); ev != None {
+				events = append(events, event{ev, at})
+			}
+		}
+	}
+	return events
+}
+
+func newDetector(t *testing.T) *Detector {
+	d := NewDetector(t.Name(), Config{})
+	t.Cleanup(d.Close)
+	return d
+}
+
+func TestShortHelloIsHuman(t *testing.T) {
+	d := newDetector(t)
+	events := screen(d, quiet(400*time.Millisecond), speech(600*time.Millisecond), quiet(3*time.Second))
+
+	if len(events) != 1 || events[0].ev != Answered {
+		t.Fatalf("events %v, want one Answered", events)
+	}
+	// Decided once the caller has waited HumanSilence for a reply
+	if want := 1300 * time.Millisecond; events[0].at != want {
+		t.Errorf("answered at %v, want %v", events[0].at, want)
+	}
+	if d.Result() != Human || d.Reason() != "short_greeting" || !d.Done() {
+		t.Errorf("result %s (%s), done %v; want human", d.Result(), d.Reason(), d.Done())
+	}
+}
+
+func TestLongGreetingIsMachine(t *testing.T) {
+	d := newDetector(t)
+	// "Hi, you've reached Priya, I can't take your call right now..."
+	events := screen(d, quiet(300*time.Millisecond), speech(6*time.Second), quiet(2*time.Second))
+
+	if len(events) != 2 || events[0].ev != Answered || events[1].ev != MessageReady {
+		t.Fatalf("events %v, want Answered then MessageReady", events)
+	}
+	if d.Result() != Machine || d.Reason() != "long_greeting" || d.Done() {
+		t.Errorf("result %s (%s), done %v; want machine", d.Result(), d.Reason(), d.Done())
+	}
+	// Past MaxGreeting of speech, while the greeting goes on
+	if at := events[0].at; at <= 2800*time.Millisecond || at > 2900*time.Millisecond {
+		t.Errorf("answered at %v, want just past 2.8s", at)
+	}
+	// Without a beep the message starts once the greeting has gone quiet
+	if want := 6300*time.Millisecond + DefaultConfig.GreetingEnd; events[1].at != want {
+		t.Errorf("message ready at %v, want %v", events[1].at, want)
+	}
+}
+
+func TestBeepAfterGreetingStartsMessage(t *testing.T) {
+	d := newDetector(t)
+	events := screen(d, speech(4*time.Second), quiet(200*time.Millisecond), beep(1000, 400*time.Millisecond), quiet(time.Second))
+
+	if len(events) != 2 || events[1].ev != MessageReady {
+		t.Fatalf("events %v, want Answered then MessageReady", events)
+	}
+	if d.Reason() != "long_greeting" {
+		t.Errorf("decided by %s, want the greeting", d.Reason())
+	}
+	// The message starts as the beep ends, not GreetingEnd later
+	if want := 4620 * time.Millisecond; events[1].at != want {
+		t.Errorf("message ready at %v, want %v", events[1].at, want)
+	}
+}
+
+func TestBeepAloneIsMachine(t *testing.T) {
+	for _, hz := range []float64{440, 1000, 1400} {
+		d := NewDetector(t.Name(), Config{})
+		events := screen(d, quiet(500*time.Millisecond), beep(hz, 500*time.Millisecond), quiet(100*time.Millisecond))
+		d.Close()
+
+		if len(events) != 2 || events[0].ev != Answered || events[1].ev != MessageReady {
+			t.Errorf("%vHz: events %v, want Answered then MessageReady", hz, events)
+			continue
+		}
+		if d.Result() != Machine || d.Reason() != "beep" {
+			t.Errorf("%vHz: result %s (%s), want machine by beep", hz, d.Result(), d.Reason())
+		}
+		// Heard after beepFrames, ready once it stops
+		if want := 500*time.Millisecond + beepFrames*frameDuration; events[0].at != want {
+			t.Errorf("%vHz: answered at %v, want %v", hz, events[0].at, want)
+		}
+		if want := 1020 * time.Millisecond; events[1].at != want {
+			t.Errorf("%vHz: message ready at %v, want %v", hz, events[1].at, want)
+		}
+	}
+}
+
+func TestShortToneIsNotABeep(t *testing.T) {
+	d := newDetector(t)
+	// A keypress or click, too short for a beep
+	screen(d, beep(1000, 100*time.Millisecond), quiet(200*time.Millisecond), speech(500*time.Millisecond), quiet(time.Second))
+	if d.Result() != Human {
+		t.Errorf("result %s (%s), want human", d.Result(), d.Reason())
+	}
+}
+
+func TestSilenceIsUnknown(t *testing.T) {
+	d := newDetector(t)
+	events := screen(d, quiet(8*time.Second))
+	if len(events) != 1 || events[0].at != DefaultConfig.Window {
+		t.Fatalf("events %v, want Answered at %v", events, DefaultConfig.Window)
+	}
+	if d.Result() != Unknown || d.Reason() != "timeout" || !d.Done() {
+		t.Errorf("result %s (%s), done %v; want unknown, handled as a person", d.Result(), d.Reason(), d.Done())
+	}
+}
+
+func TestVobizVerdict(t *testing.T) {
+	d := newDetector(t)
+	if !Report(t.Name(), true) {
+		t.Fatal("no detector found for the call")
+	}
+	// Audio that sounds like a person does not overturn it
+	events := screen(d, speech(500*time.Millisecond), quiet(2*time.Second))
+	if len(events) != 2 || events[0].ev != Answered || events[1].ev != MessageReady {
+		t.Fatalf("events %v, want Answered then MessageReady", events)
+	}
+	if d.Result() != Machine || d.Reason() != "vobiz" {
+		t.Errorf("result %s (%s), want machine by vobiz", d.Result(), d.Reason())
+	}
+
+	// Nor does a late verdict overturn the audio
+	h := NewDetector(t.Name()+"/human", Config{})
+	screen(h, speech(500*time.Millisecond), quiet(500*time.Millisecond))
+	h.Report(true)
+	if h.Result() != Human {
+		t.Errorf("late verdict changed the result to %s", h.Result())
+	}
+	h.Close()
+	if Report(t.Name()+"/human", true) {
+		t.Error("verdict reached a closed detector")
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	var d *Detector
+	if d.Result() != "" || d.Reason() != "" || d.Disposition() != "" || !d.Done() {
+		t.Error("a nil detector should be an unscreened call")
+	}
+}
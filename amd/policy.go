@@ -0,0 +1,88 @@
+// Package amd screens outbound calls for answering machines: it decides
+// from Vobiz's machine detection or the first seconds of caller audio
+// whether a person picked up, and when a voicemail greeting has finished.
+package amd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// What to do when a machine answers
+const (
+	ActionVoicemail = "voicemail" // leave Message, then hang up
+	ActionHangup    = "hangup"    // hang up without a word
+)
+
+// Policy is an outbound campaign's answering machine handling, sent as
+// machine_detection on /outbound-call.
+type Policy struct {
+	Action string `json:"action"`
+	// Message is a text/template rendered with the call's agent variables
+	Message string `json:"message,omitempty"`
+
+	tmpl *template.Template
+}
+
+// ParsePolicy decodes a policy from JSON and checks its message renders
+// with vars; empty input is no policy.
+func ParsePolicy(s string, vars interface{}) (*Policy, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var p Policy
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		return nil, fmt.Errorf("machine detection policy: %w", err)
+	}
+	if err := p.Validate(vars); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the action, parses the message template and renders it
+// with sample, so a field the call will not have fails now rather than
+// when the machine has answered.
+func (p *Policy) Validate(sample interface{}) error {
+	switch p.Action {
+	case ActionHangup:
+		return nil
+	case ActionVoicemail:
+		if err := p.parse(); err != nil {
+			return err
+		}
+		if _, err := p.RenderMessage(sample); err != nil {
+			return fmt.Errorf("machine detection: message template: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("machine detection: unknown action %q, want %q or %q", p.Action, ActionVoicemail, ActionHangup)
+}
+
+func (p *Policy) parse() error {
+	if p.Message == "" {
+		return fmt.Errorf("machine detection: voicemail needs a message")
+	}
+	t, err := template.New("voicemail").Option("missingkey=error").Parse(p.Message)
+	if err != nil {
+		return fmt.Errorf("machine detection: message template: %w", err)
+	}
+	p.tmpl = t
+	return nil
+}
+
+// RenderMessage renders the voicemail message with the call's variables.
+func (p *Policy) RenderMessage(vars interface{}) (string, error) {
+	if p.tmpl == nil {
+		if err := p.parse(); err != nil {
+			return "", err
+		}
+	}
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("render voicemail message: %w", err)
+	}
+	return buf.String(), nil
+}
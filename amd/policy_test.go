@@ -0,0 +1,55 @@
+package amd
+
+import (
+	"strings"
+	"testing"
+)
+
+type vars struct {
+	CallerNumber string
+	Campaign     map[string]interface{}
+}
+
+func TestParsePolicy(t *testing.T) {
+	sample := vars{"918504074217", map[string]interface{}{"first_name": "Asha"}}
+	tests := []struct {
+		json string
+		err  string // "" if valid
+	}{
+		{``, ""},
+		{`{"action":"hangup"}`, ""},
+		{`{"action":"voicemail","message":"Hi {{.Campaign.first_name}}, please call us back."}`, ""},
+		{`{"action":"voicemail","message":"Hi {{index .Campaign \"nickname\"}}"}`, ""},
+		{`{"action":"voicemail"}`, "needs a message"},
+		{`{"action":"voicemail","message":"Hi {{.Campaign.first_name"}`, "message template"},
+		// A campaign field the call does not have
+		{`{"action":"voicemail","message":"Hi {{.Campaign.nickname}}"}`, "nickname"},
+		// A field calls never have
+		{`{"action":"voicemail","message":"Hi {{.FirstName}}"}`, "FirstName"},
+		{`{"action":"transfer"}`, "unknown action"},
+		{`{"action":`, "machine detection policy"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePolicy(tt.json, sample)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("ParsePolicy(%s): %v", tt.json, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("ParsePolicy(%s) = %v, want an error about %q", tt.json, err, tt.err)
+		}
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	p, err := ParsePolicy(`{"action":"voicemail","message":"Hi {{.Campaign.first_name}}, please call {{.CallerNumber}}."}`, vars{Campaign: map[string]interface{}{"first_name": ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.RenderMessage(vars{"918504074217", map[string]interface{}{"first_name": "Asha"}})
+	if want := "Hi Asha, please call 918504074217."; err != nil || got != want {
+		t.Errorf("RenderMessage = %q, %v; want %q", got, err, want)
+	}
+	if _, err := p.RenderMessage(vars{Campaign: map[string]interface{}{}}); err == nil {
+		t.Error("rendered without the campaign's first_name")
+	}
+}
@@ -0,0 +1,83 @@
+package audio
+
+// G.711 μ-law, the only encoding Vobiz streams use (8kHz, 8-bit, mono).
+// PCM is 16-bit signed little-endian throughout this package.
+
+const (
+	muLawBias = 0x84
+	muLawClip = 32635
+)
+
+// MuLawSilence is the μ-law byte for a zero sample.
+const MuLawSilence = 0xFF
+
+var muLawTable = buildMuLawTable()
+
+func buildMuLawTable() [256]int16 {
+	var table [256]int16
+	for i := 0; i < 256; i++ {
+		// G.711 transmits μ-law bytes complemented, which EncodeMuLaw
+		// does too. Decoding without undoing it plays silence (0xFF) as
+		// the loudest negative sample.
+		mulaw := ^byte(i)
+		sign := mulaw & 0x80
+		exponent := (mulaw >> 4) & 0x07
+		mantissa := mulaw & 0x0F
+
+		sample := (int16(mantissa)<<3 + muLawBias) << exponent
+		sample -= muLawBias
+
+		if sign != 0 {
+			sample = -sample
+		}
+		table[i] = sample
+	}
+	return table
+}
+
+// DecodeMuLaw returns the linear sample for one μ-law byte.
+func DecodeMuLaw(b byte) int16 {
+	return muLawTable[b]
+}
+
+// EncodeMuLaw returns the μ-law byte for one linear sample.
+func EncodeMuLaw(sample int16) byte {
+	s := int32(sample)
+	sign := byte(0)
+	if s < 0 {
+		s = -s
+		sign = 0x80
+	}
+	if s > muLawClip {
+		s = muLawClip
+	}
+	s += muLawBias
+
+	exponent := byte(7)
+	for mask := int32(0x4000); s&mask == 0 && exponent > 0; exponent-- {
+		mask >>= 1
+	}
+
+	mantissa := byte((s >> (exponent + 3)) & 0x0F)
+	return ^(sign | exponent<<4 | mantissa)
+}
+
+// MuLawToPCM decodes μ-law bytes to PCM.
+func MuLawToPCM(mulaw []byte) []byte {
+	pcm := make([]byte, len(mulaw)*2)
+	for i, mu := range mulaw {
+		sample := muLawTable[mu]
+		pcm[i*2] = byte(sample)
+		pcm[i*2+1] = byte(sample >> 8)
+	}
+	return pcm
+}
+
+// PCMToMuLaw encodes PCM to μ-law bytes.
+func PCMToMuLaw(pcm []byte) []byte {
+	mulaw := make([]byte, len(pcm)/2)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		mulaw[i/2] = EncodeMuLaw(int16(pcm[i]) | int16(pcm[i+1])<<8)
+	}
+	return mulaw
+}
@@ -0,0 +1,49 @@
+package audio
+
+import "testing"
+
+func TestMuLawRoundTripsEveryCode(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		code := byte(i)
+		pcm := MuLawToPCM([]byte{code})
+		sample := int16(pcm[0]) | int16(pcm[1])<<8
+
+		want := code
+		// 0x7F is negative zero; it decodes to 0, which encodes as 0xFF
+		if code == 0x7F {
+			want = MuLawSilence
+		}
+		if got := EncodeMuLaw(sample); got != want {
+			t.Errorf("code %#02x decodes to %d, which encodes to %#02x", code, sample, got)
+		}
+		if got := PCMToMuLaw(pcm); len(got) != 1 || got[0] != want {
+			t.Errorf("PCMToMuLaw(MuLawToPCM(%#02x)) = %#v", code, got)
+		}
+	}
+}
+
+func TestMuLawKnownValues(t *testing.T) {
+	for _, tc := range []struct {
+		code   byte
+		sample int16
+	}{
+		{MuLawSilence, 0},
+		{0x80, 32124},  // loudest positive
+		{0x00, -32124}, // loudest negative
+		{0xFE, 8},
+		{0x7E, -8},
+	} {
+		if got := DecodeMuLaw(tc.code); got != tc.sample {
+			t.Errorf("DecodeMuLaw(%#02x) = %d, want %d", tc.code, got, tc.sample)
+		}
+	}
+}
+
+func TestEncodeMuLawClips(t *testing.T) {
+	if got := EncodeMuLaw(32767); got != 0x80 {
+		t.Errorf("EncodeMuLaw(32767) = %#02x, want 0x80", got)
+	}
+	if got := EncodeMuLaw(-32768); got != 0x00 {
+		t.Errorf("EncodeMuLaw(-32768) = %#02x, want 0x00", got)
+	}
+}
@@ -0,0 +1,96 @@
+package audio
+
+import "math"
+
+// BytesToInt16 converts PCM bytes to samples.
+func BytesToInt16(data []byte) []int16 {
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(data[i*2]) | int16(data[i*2+1])<<8
+	}
+	return samples
+}
+
+// Int16ToBytes converts samples to PCM bytes.
+func Int16ToBytes(samples []int16) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		data[i*2] = byte(sample)
+		data[i*2+1] = byte(sample >> 8)
+	}
+	return data
+}
+
+// Upsample8to24 triples 8kHz PCM to 24kHz by repeating samples.
+func Upsample8to24(pcm8k []byte) []byte {
+	samples8k := len(pcm8k) / 2
+	pcm24k := make([]byte, samples8k*3*2)
+
+	for i := 0; i < samples8k; i++ {
+		lo, hi := pcm8k[i*2], pcm8k[i*2+1]
+		for j := 0; j < 3; j++ {
+			offset := (i*3 + j) * 2
+			pcm24k[offset] = lo
+			pcm24k[offset+1] = hi
+		}
+	}
+	return pcm24k
+}
+
+// Downsample24to8 keeps every third sample of 24kHz PCM.
+func Downsample24to8(pcm24k []byte) []byte {
+	samples := BytesToInt16(pcm24k)
+	out := make([]int16, len(samples)/3)
+	for i := range out {
+		out[i] = samples[i*3]
+	}
+	return Int16ToBytes(out)
+}
+
+// Resample converts samples between arbitrary rates with linear
+// interpolation. It is meant for test fixtures, not the live path.
+func Resample(samples []int16, from, to int) []int16 {
+	if from == to || len(samples) == 0 {
+		return samples
+	}
+
+	n := int(int64(len(samples)) * int64(to) / int64(from))
+	out := make([]int16, n)
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = int16(float64(samples[j])*(1-frac) + float64(samples[j+1])*frac)
+	}
+	return out
+}
+
+// RMS returns the root mean square level of samples (0 to 32768).
+func RMS(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// MuLawRMS returns the RMS level of μ-law audio.
+func MuLawRMS(mulaw []byte) float64 {
+	if len(mulaw) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, b := range mulaw {
+		s := float64(DecodeMuLaw(b))
+		sum += s * s
+	}
+	return math.Sqrt(sum / float64(len(mulaw)))
+}
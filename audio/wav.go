@@ -0,0 +1,154 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WAV format codes
+const (
+	WAVFormatPCM   = 1
+	WAVFormatMuLaw = 7
+)
+
+// WAV is a decoded RIFF/WAVE file.
+type WAV struct {
+	Format        uint16
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+	Data          []byte
+}
+
+// ReadWAVFile reads a WAV file from disk.
+func ReadWAVFile(path string) (*WAV, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w, err := ReadWAV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// ReadWAV parses a RIFF/WAVE stream with a fmt and data chunk. Other
+// chunks are skipped.
+func ReadWAV(r io.Reader) (*WAV, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("read wav header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE file")
+	}
+
+	var w WAV
+	var haveFmt bool
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("read wav chunk: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("read fmt chunk: %w", err)
+			}
+			if len(body) < 16 {
+				return nil, errors.New("fmt chunk too short")
+			}
+			w.Format = binary.LittleEndian.Uint16(body[0:2])
+			w.Channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			w.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			w.BitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			haveFmt = true
+
+		case "data":
+			if !haveFmt {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			w.Data = make([]byte, size)
+			if _, err := io.ReadFull(r, w.Data); err != nil {
+				return nil, fmt.Errorf("read data chunk: %w", err)
+			}
+			return &w, nil
+
+		default:
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return nil, fmt.Errorf("skip %q chunk: %w", id, err)
+			}
+		}
+
+		// Chunks are word aligned
+		if size%2 == 1 {
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// MuLaw8k returns the audio as 8kHz mono μ-law, the format Vobiz streams.
+// 16-bit PCM at any rate and 8kHz μ-law are supported.
+func (w *WAV) MuLaw8k() ([]byte, error) {
+	switch {
+	case w.Format == WAVFormatMuLaw && w.SampleRate == 8000 && w.Channels == 1:
+		return w.Data, nil
+	case w.Format == WAVFormatPCM && w.BitsPerSample == 16:
+		samples := mono(BytesToInt16(w.Data), w.Channels)
+		return PCMToMuLaw(Int16ToBytes(Resample(samples, w.SampleRate, 8000))), nil
+	}
+	return nil, fmt.Errorf("unsupported wav: format %d, %d-bit, %dHz, %d channel(s)",
+		w.Format, w.BitsPerSample, w.SampleRate, w.Channels)
+}
+
+func mono(samples []int16, channels int) []int16 {
+	if channels <= 1 {
+		return samples
+	}
+	out := make([]int16, len(samples)/channels)
+	for i := range out {
+		var sum int
+		for c := 0; c < channels; c++ {
+			sum += int(samples[i*channels+c])
+		}
+		out[i] = int16(sum / channels)
+	}
+	return out
+}
+
+// WriteWAV writes 8kHz mono μ-law as a WAV file, e.g. to listen to what
+// the bridge played.
+func WriteWAV(w io.Writer, mulaw []byte) error {
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+len(mulaw)))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], WAVFormatMuLaw)
+	binary.LittleEndian.PutUint16(header[22:24], 1)
+	binary.LittleEndian.PutUint32(header[24:28], 8000)
+	binary.LittleEndian.PutUint32(header[28:32], 8000)
+	binary.LittleEndian.PutUint16(header[32:34], 1)
+	binary.LittleEndian.PutUint16(header[34:36], 8)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(len(mulaw)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(mulaw)
+	return err
+}
@@ -0,0 +1,93 @@
+// Package bargein tells a caller talking over the model apart from the
+// model's own audio echoing back down the phone line, so caller audio can
+// stay open while the model speaks without the model interrupting itself.
+package bargein
+
+import (
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/vad"
+)
+
+// Reference reports how loud the audio played to the caller was; a
+// *playout.Player satisfies it.
+type Reference interface {
+	PlayedLevel(from, to time.Time) float64
+}
+
+// Config tunes the guard. Zero fields take the defaults.
+type Config struct {
+	// EchoRatio is the loudest echo expected relative to what we played;
+	// 0.5 assumes at least 6dB of echo return loss
+	EchoRatio float64
+	// EchoWindow is how far back played audio can still echo
+	EchoWindow time.Duration
+	// StartFrames of speech in a row start an utterance
+	StartFrames int
+	// EndFrames without speech end it
+	EndFrames int
+}
+
+var DefaultConfig = Config{
+	EchoRatio:   0.5,
+	EchoWindow:  600 * time.Millisecond,
+	StartFrames: 3,
+	EndFrames:   25,
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig
+	if c.EchoRatio > 0 {
+		d.EchoRatio = c.EchoRatio
+	}
+	if c.EchoWindow > 0 {
+		d.EchoWindow = c.EchoWindow
+	}
+	if c.StartFrames > 0 {
+		d.StartFrames = c.StartFrames
+	}
+	if c.EndFrames > 0 {
+		d.EndFrames = c.EndFrames
+	}
+	return d
+}
+
+// Guard classifies the caller's frames of one call. It is not safe for
+// concurrent use.
+type Guard struct {
+	cfg     Config
+	ref     Reference
+	vad     *vad.Detector
+	tracker *vad.Tracker
+}
+
+// New returns a guard using the process-wide VAD mode. ref may be nil
+// when nothing is played to the caller.
+func New(ref Reference, cfg Config) *Guard {
+	cfg = cfg.withDefaults()
+	return &Guard{
+		cfg:     cfg,
+		ref:     ref,
+		vad:     vad.New(vad.DefaultMode),
+		tracker: &vad.Tracker{StartFrames: cfg.StartFrames, EndFrames: cfg.EndFrames},
+	}
+}
+
+// Frame classifies one caller frame of 8kHz PCM received at at. speech
+// reports whether the frame is the caller rather than silence, noise or
+// echo.
+func (g *Guard) Frame(pcm []int16, at time.Time) (speech bool, ev vad.Event) {
+	r := g.vad.Process(pcm)
+	speech = r.Speech
+	if speech && g.ref != nil {
+		// Echo has the shape of speech; only its level gives it away
+		echo := g.ref.PlayedLevel(at.Add(-g.cfg.EchoWindow), at) * g.cfg.EchoRatio
+		speech = r.Level > echo
+	}
+	return speech, g.tracker.Update(speech)
+}
+
+// Speaking reports whether the caller is mid-utterance.
+func (g *Guard) Speaking() bool {
+	return g.tracker.Speaking()
+}
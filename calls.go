@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultCallsWindow = 7 * 24 * time.Hour
+	defaultCallsLimit  = 100
+)
+
+// HandleListCalls lists call records, newest first.
+//
+// Query filters: since, until (RFC3339, default last 7 days), agent,
+// provider, channel ("voice" or "chat"), disposition, sentiment (caller
+// sentiment at the end of the call), trajectory and limit.
+func HandleListCalls(c echo.Context) error {
+	until := time.Now()
+	since := until.Add(-defaultCallsWindow)
+	var err error
+
+	if v := c.QueryParam("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'since', expected RFC3339")
+		}
+	}
+	if v := c.QueryParam("until"); v != "" {
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'until', expected RFC3339")
+		}
+	}
+
+	limit := defaultCallsLimit
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'limit'")
+		}
+	}
+
+	calls, err := redisClient.ListCalls(since, until)
+	if err != nil {
+		logger.ErrorContext(c.Request().Context(), "failed to list calls", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list calls")
+	}
+
+	filtered := []models.CallModel{}
+	for _, call := range calls {
+		if !matchesCallFilters(c, call) {
+			continue
+		}
+		filtered = append(filtered, call)
+		if len(filtered) == limit {
+			break
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count": len(filtered),
+		"calls": filtered,
+	})
+}
+
+func matchesCallFilters(c echo.Context, call models.CallModel) bool {
+	matches := func(param, value string) bool {
+		want := c.QueryParam(param)
+		return want == "" || want == value
+	}
+
+	var sentiment, trajectory string
+	if call.Sentiment != nil {
+		sentiment = call.Sentiment.End
+		trajectory = call.Sentiment.Trajectory
+	}
+
+	return matches("agent", call.Agent) &&
+		matches("provider", call.Provider) &&
+		matches("channel", call.Channel) &&
+		matches("disposition", call.Disposition) &&
+		matches("sentiment", sentiment) &&
+		matches("trajectory", trajectory)
+}
+
+// HandleGetCall returns a single call record.
+func HandleGetCall(c echo.Context) error {
+	id := c.Param("id")
+
+	call, err := redisClient.GetCall(id)
+	if err != nil {
+		logger.ErrorContext(c.Request().Context(), "failed to load call", "call_id", id, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load call")
+	}
+	if call == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Call not found")
+	}
+
+	return c.JSON(http.StatusOK, call)
+}
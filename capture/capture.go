@@ -0,0 +1,207 @@
+// Package capture records the raw websocket traffic of a call so it can be
+// replayed against the fake provider later. A capture file is JSON lines:
+// a Header, then one Frame per message in arrival order.
+//
+// Captures hold caller audio and transcripts, so recording is opt-in via
+// CAPTURE_DIR and files are written owner-readable only.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+// Frame sources
+const (
+	Vobiz    = "vobiz"    // received from Vobiz on the media stream
+	Provider = "provider" // received from the model provider
+)
+
+var logger = logging.For("capture")
+
+// Header is the first line of a capture file.
+type Header struct {
+	CallUUID  string    `json:"callUuid"`
+	Provider  string    `json:"provider"`
+	Query     string    `json:"query"` // raw query of the /stream request
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Frame is one received websocket message.
+type Frame struct {
+	// AtMs is the time since the capture started
+	AtMs   int64           `json:"atMs"`
+	Source string          `json:"source"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Capture is a loaded capture file.
+type Capture struct {
+	Header Header
+	Frames []Frame
+}
+
+// From returns the frames from source, in order.
+func (c *Capture) From(source string) []Frame {
+	var out []Frame
+	for _, f := range c.Frames {
+		if f.Source == source {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Recorder appends frames to a capture file. A nil Recorder records
+// nothing, so callers need not check whether capture is enabled.
+type Recorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	w     *bufio.Writer
+	start time.Time
+	err   error
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Start opens a capture for the call in CAPTURE_DIR. It returns nil when
+// capture is off or the file cannot be created.
+func Start(h Header) *Recorder {
+	dir := os.Getenv("CAPTURE_DIR")
+	if dir == "" {
+		return nil
+	}
+	r, err := Create(dir, h)
+	if err != nil {
+		logger.Warn("capture disabled for call", "call_id", h.CallUUID, "error", err)
+		return nil
+	}
+	return r
+}
+
+// Create writes h to a new capture file in dir named after the call.
+func Create(dir string, h Header) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if h.StartedAt.IsZero() {
+		h.StartedAt = time.Now().UTC()
+	}
+
+	name := unsafeName.ReplaceAllString(h.CallUUID, "_")
+	if name == "" {
+		name = "call"
+	}
+	name = fmt.Sprintf("%s-%s.jsonl", h.StartedAt.Format("20060102T150405"), name)
+
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{f: f, w: bufio.NewWriter(f), start: time.Now()}
+	r.writeLine(h)
+	if r.err != nil {
+		f.Close()
+		return nil, r.err
+	}
+	logger.Info("capturing call", "call_id", h.CallUUID, "file", f.Name())
+	return r, nil
+}
+
+// Vobiz records a message received from Vobiz.
+func (r *Recorder) Vobiz(data []byte) {
+	r.record(Vobiz, data)
+}
+
+// Provider records a message received from the model provider.
+func (r *Recorder) Provider(data []byte) {
+	r.record(Provider, data)
+}
+
+func (r *Recorder) record(source string, data []byte) {
+	if r == nil {
+		return
+	}
+	raw := json.RawMessage(data)
+	if !json.Valid(data) {
+		// Keep non-JSON frames readable rather than dropping them
+		raw, _ = json.Marshal(string(data))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeLine(Frame{AtMs: time.Since(r.start).Milliseconds(), Source: source, Data: raw})
+}
+
+// writeLine appends v; r.mu must be held (or r not yet shared).
+func (r *Recorder) writeLine(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	line, err := json.Marshal(v)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = r.w.Write(line)
+	}
+	if err != nil {
+		r.err = err
+		logger.Warn("capture write failed, dropping further frames", "file", r.f.Name(), "error", err)
+	}
+}
+
+// Close flushes and closes the capture file.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.w.Flush()
+	return errors.Join(err, r.f.Close())
+}
+
+// Load reads a capture file.
+func Load(path string) (*Capture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	// Provider frames carry base64 audio and can be large
+	sc.Buffer(make([]byte, 0, 1<<20), 16<<20)
+
+	var c Capture
+	line := 0
+	for sc.Scan() {
+		line++
+		if line == 1 {
+			if err := json.Unmarshal(sc.Bytes(), &c.Header); err != nil {
+				return nil, fmt.Errorf("%s: header: %w", path, err)
+			}
+			continue
+		}
+		var fr Frame
+		if err := json.Unmarshal(sc.Bytes(), &fr); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		c.Frames = append(c.Frames, fr)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if line == 0 {
+		return nil, fmt.Errorf("%s: empty capture", path)
+	}
+	return &c, nil
+}
@@ -0,0 +1,122 @@
+// Package catalog lists the models and voices each provider offers and
+// resolves the session settings of a call from the agent, campaign and
+// request that asked for them. Live calls register here so a supervisor
+// or a tool can change their settings mid-call.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+// Providers
+const (
+	OpenAI = "openai"
+	Gemini = "gemini"
+)
+
+// Provider is what one provider offers and its defaults.
+type Provider struct {
+	Models       []string `json:"models"`
+	Voices       []string `json:"voices"`
+	DefaultModel string   `json:"default_model"`
+	DefaultVoice string   `json:"default_voice"`
+	// TextModel answers text chat; native audio models cannot
+	TextModel string `json:"text_model"`
+
+	MinTemperature     float64 `json:"min_temperature"`
+	MaxTemperature     float64 `json:"max_temperature"`
+	DefaultTemperature float64 `json:"default_temperature"`
+}
+
+// Config is the on-disk catalogue (see CATALOG_CONFIG), keyed by provider.
+type Config struct {
+	Providers map[string]Provider `json:"providers"`
+}
+
+// DefaultCatalog holds the models and voices known at the time of writing;
+// add new ones with CATALOG_CONFIG rather than editing code.
+var DefaultCatalog = Config{Providers: map[string]Provider{
+	OpenAI: {
+		Models:       []string{"gpt-realtime-mini", "gpt-realtime", "gpt-4o-realtime-preview", "gpt-4o-mini-realtime-preview"},
+		Voices:       []string{"alloy", "ash", "ballad", "cedar", "coral", "echo", "marin", "sage", "shimmer", "verse"},
+		DefaultModel: "gpt-realtime-mini",
+		DefaultVoice: "alloy",
+		TextModel:    "gpt-realtime-mini",
+		// The Realtime API rejects temperatures outside 0.6-1.2
+		MinTemperature:     0.6,
+		MaxTemperature:     1.2,
+		DefaultTemperature: 0.8,
+	},
+	Gemini: {
+		Models:             []string{"gemini-2.5-flash-native-audio-preview-12-2025", "gemini-2.5-flash-native-audio-preview-09-2025", "gemini-live-2.5-flash-preview"},
+		Voices:             []string{"Puck", "Charon", "Kore", "Fenrir", "Aoede", "Leda", "Orus", "Zephyr"},
+		DefaultModel:       "gemini-2.5-flash-native-audio-preview-12-2025",
+		DefaultVoice:       "Puck",
+		TextModel:          "gemini-live-2.5-flash-preview",
+		MinTemperature:     0,
+		MaxTemperature:     2,
+		DefaultTemperature: 0.8,
+	},
+}}
+
+var logger = logging.For("catalog")
+
+var (
+	mu        sync.RWMutex
+	providers = DefaultCatalog.Providers
+)
+
+// Load reads the catalogue from path. Its providers replace the built-in
+// ones with the same name; an empty path keeps the defaults.
+func Load(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read catalog config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse catalog config %s: %w", path, err)
+	}
+
+	merged := make(map[string]Provider, len(DefaultCatalog.Providers)+len(cfg.Providers))
+	for name, p := range DefaultCatalog.Providers {
+		merged[name] = p
+	}
+	for name, p := range cfg.Providers {
+		if !slices.Contains(p.Models, p.DefaultModel) || !slices.Contains(p.Voices, p.DefaultVoice) {
+			return fmt.Errorf("catalog config: %s default model and voice must be listed", name)
+		}
+		if !slices.Contains(p.Models, p.TextModel) {
+			return fmt.Errorf("catalog config: %s text model must be listed", name)
+		}
+		if p.MinTemperature > p.MaxTemperature || p.DefaultTemperature < p.MinTemperature || p.DefaultTemperature > p.MaxTemperature {
+			return fmt.Errorf("catalog config: %s temperature range is invalid", name)
+		}
+		merged[name] = p
+	}
+
+	mu.Lock()
+	providers = merged
+	mu.Unlock()
+	logger.Info("loaded catalog", "providers", len(cfg.Providers), "path", path)
+	return nil
+}
+
+// Get returns what provider offers.
+func Get(provider string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[provider]
+	return p, ok
+}
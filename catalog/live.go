@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotLive is returned for a call that is not bridged by this instance.
+var ErrNotLive = errors.New("call is not live on this instance")
+
+// ErrModelFixed is returned for a mid-call model change; both providers
+// fix the model when the session is opened.
+var ErrModelFixed = errors.New("the model cannot change during a call")
+
+// ErrInvalid marks settings the catalogue rejects.
+var ErrInvalid = errors.New("invalid session settings")
+
+// Apply pushes a call's new settings to its provider session.
+type Apply func(s Settings) error
+
+type liveCall struct {
+	provider string
+	apply    Apply
+
+	mu      sync.Mutex
+	current Settings
+}
+
+var (
+	liveMu sync.Mutex
+	live   = map[string]*liveCall{}
+)
+
+// Register makes callID's session changeable through Update until the
+// returned func is called. current is the settings the call started with.
+func Register(callID, provider string, current Settings, apply Apply) (unregister func()) {
+	c := &liveCall{provider: provider, apply: apply, current: current}
+	liveMu.Lock()
+	live[callID] = c
+	liveMu.Unlock()
+	return func() {
+		liveMu.Lock()
+		if live[callID] == c {
+			delete(live, callID)
+		}
+		liveMu.Unlock()
+	}
+}
+
+// Current returns a live call's settings.
+func Current(callID string) (Settings, error) {
+	c := lookup(callID)
+	if c == nil {
+		return Settings{}, ErrNotLive
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current, nil
+}
+
+// Update applies change over a live call's settings and returns the
+// result. Validation errors wrap ErrInvalid; a provider that cannot make
+// the change returns its own error and the settings are kept.
+func Update(callID string, change Settings) (Settings, error) {
+	c := lookup(callID)
+	if c == nil {
+		return Settings{}, ErrNotLive
+	}
+	if err := change.Validate(c.provider); err != nil {
+		return Settings{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if change.Model != "" && change.Model != c.current.Model {
+		return c.current, ErrModelFixed
+	}
+	next := change.Over(c.current)
+	if next.equal(c.current) {
+		return next, nil
+	}
+	if err := c.apply(next); err != nil {
+		return c.current, err
+	}
+	c.current = next
+	return next, nil
+}
+
+func lookup(callID string) *liveCall {
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	return live[callID]
+}
@@ -0,0 +1,120 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Settings are a call's model, voice and tuning. Unset fields leave the
+// value from a lower layer in place.
+type Settings struct {
+	Model       string   `json:"model,omitempty"`
+	Voice       string   `json:"voice,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	// VADThreshold is how clearly the caller must speak for the provider to
+	// take it as their turn, 0-1; raise it for callers in noisy places
+	VADThreshold *float64 `json:"vad_threshold,omitempty"`
+}
+
+// Over returns s with its unset fields taken from base.
+func (s Settings) Over(base Settings) Settings {
+	if s.Model == "" {
+		s.Model = base.Model
+	}
+	if s.Voice == "" {
+		s.Voice = base.Voice
+	}
+	if s.Temperature == nil {
+		s.Temperature = base.Temperature
+	}
+	if s.VADThreshold == nil {
+		s.VADThreshold = base.VADThreshold
+	}
+	return s
+}
+
+func (s Settings) equal(o Settings) bool {
+	same := func(a, b *float64) bool { return (a == nil) == (b == nil) && (a == nil || *a == *b) }
+	return s.Model == o.Model && s.Voice == o.Voice && same(s.Temperature, o.Temperature) && same(s.VADThreshold, o.VADThreshold)
+}
+
+// Validate checks s against what provider offers.
+func (s Settings) Validate(provider string) error {
+	p, ok := Get(provider)
+	if !ok {
+		return fmt.Errorf("unknown provider %q", provider)
+	}
+	if s.Model != "" && !slices.Contains(p.Models, s.Model) {
+		return fmt.Errorf("%s: unknown model %q", provider, s.Model)
+	}
+	if s.Voice != "" && !slices.Contains(p.Voices, s.Voice) {
+		return fmt.Errorf("%s: unknown voice %q", provider, s.Voice)
+	}
+	if t := s.Temperature; t != nil && (*t < p.MinTemperature || *t > p.MaxTemperature) {
+		return fmt.Errorf("%s: temperature must be between %g and %g", provider, p.MinTemperature, p.MaxTemperature)
+	}
+	if v := s.VADThreshold; v != nil && (*v < 0 || *v > 1) {
+		return fmt.Errorf("vad_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// Selection holds Settings per provider, so one agent or campaign can
+// pick a voice on each.
+type Selection map[string]Settings
+
+// ParseSelection decodes a selection from JSON; empty input is none.
+func ParseSelection(s string) (Selection, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sel Selection
+	if err := json.Unmarshal([]byte(s), &sel); err != nil {
+		return nil, fmt.Errorf("session settings: %w", err)
+	}
+	return sel, sel.Validate()
+}
+
+// SelectionFrom converts a decoded JSON value, such as a campaign variable,
+// to a selection; nil is none.
+func SelectionFrom(v interface{}) (Selection, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("session settings: %w", err)
+	}
+	return ParseSelection(string(data))
+}
+
+// Validate checks the settings of every provider.
+func (sel Selection) Validate() error {
+	for provider, s := range sel {
+		if err := s.Validate(provider); err != nil {
+			return fmt.Errorf("session settings: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resolve returns provider's settings for a call with the layers applied
+// over the catalogue defaults, lowest precedence first. Model, Voice and
+// Temperature are always set.
+func Resolve(provider string, layers ...Selection) (Settings, error) {
+	p, ok := Get(provider)
+	if !ok {
+		return Settings{}, fmt.Errorf("unknown provider %q", provider)
+	}
+	temperature := p.DefaultTemperature
+	s := Settings{Model: p.DefaultModel, Voice: p.DefaultVoice, Temperature: &temperature}
+	for _, sel := range layers {
+		layer := sel[provider]
+		if err := layer.Validate(provider); err != nil {
+			return Settings{}, fmt.Errorf("session settings: %w", err)
+		}
+		s = layer.Over(s)
+	}
+	return s, nil
+}
@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AVVKavvk/openai-vobiz/tools"
+)
+
+// ToolName is the tool the model uses to change its own session settings.
+//
+// Only OpenAI calls offer it. The Gemini Live API fixes voice, temperature
+// and activity detection in the setup message, and a new setup means a new
+// session that loses the conversation so far; Gemini calls are registered
+// so that Update refuses a change with that reason, but their model is
+// not given the tool.
+const ToolName = "adjust_session"
+
+func init() {
+	tools.Register(tools.Tool{
+		Name:        ToolName,
+		Description: "Adjusts how you sound and listen for the rest of the call. Use vad_threshold when background noise keeps cutting the caller off (raise it) or their quiet speech is missed (lower it). Change voice or temperature only when the caller asks. Returns the settings now in use, or why the change was not possible.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"voice": map[string]interface{}{
+					"type":        "string",
+					"description": "Voice name to switch to.",
+				},
+				"temperature": map[string]interface{}{
+					"type":        "number",
+					"description": "Higher is more varied, lower more predictable.",
+				},
+				"vad_threshold": map[string]interface{}{
+					"type":        "number",
+					"description": "0-1; how clearly the caller must speak to count as their turn.",
+				},
+			},
+		},
+		Handler:   handleAdjustSession,
+		PhoneOnly: true,
+	})
+}
+
+func handleAdjustSession(ctx context.Context, call tools.Call, args map[string]interface{}) map[string]interface{} {
+	var change Settings
+	change.Voice, _ = args["voice"].(string)
+	if t, ok := args["temperature"].(float64); ok {
+		change.Temperature = &t
+	}
+	if v, ok := args["vad_threshold"].(float64); ok {
+		change.VADThreshold = &v
+	}
+
+	s, err := Update(call.CallId, change)
+	if errors.Is(err, ErrNotLive) {
+		return map[string]interface{}{"error": "session settings cannot be changed on this call"}
+	}
+	if err != nil {
+		logger.InfoContext(ctx, "session change refused", "call_id", call.CallId, "error", err)
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"success": true, "settings": s}
+}
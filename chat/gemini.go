@@ -0,0 +1,144 @@
+package chat
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	gemini20 "github.com/AVVKavvk/openai-vobiz/gemini2.0"
+	"github.com/gorilla/websocket"
+)
+
+// geminiChat is a Live API session answering in TEXT.
+type geminiChat struct {
+	s  *session
+	ws *conn
+
+	reply strings.Builder // the model turn so far; only the read loop touches it
+}
+
+func dialGemini(s *session, instructions string, settings catalog.Settings) (model, error) {
+	// GEMINI_LIVE_URL points chat at another endpoint too
+	liveURL := gemini20.GeminiLiveURL
+	if u := os.Getenv("GEMINI_LIVE_URL"); u != "" {
+		liveURL = u
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(liveURL+"?key="+url.QueryEscape(os.Getenv("GEMINI_API_KEY")), nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &geminiChat{s: s, ws: &conn{Conn: ws}}
+
+	var decls []gemini20.GeminiFunctionDeclaration
+	for _, t := range chatTools() {
+		decls = append(decls, gemini20.GeminiFunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		})
+	}
+	err = m.ws.WriteJSON(gemini20.GeminiClientMessage{
+		Setup: &gemini20.GeminiSetup{
+			Model: "models/" + settings.Model,
+			GenerationConfig: &gemini20.GeminiGenerationConfig{
+				ResponseModalities: []string{"TEXT"},
+				Temperature:        *settings.Temperature,
+			},
+			SystemInstruction: &gemini20.GeminiContent{Parts: []gemini20.GeminiPart{{Text: instructions}}},
+			Tools:             []gemini20.GeminiTool{{FunctionDeclarations: decls}},
+		},
+	})
+	if err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("setup: %w", err)
+	}
+
+	// Messages are only accepted once setup is complete
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg gemini20.GeminiServerMessage
+		if err := ws.ReadJSON(&msg); err != nil {
+			ws.Close()
+			return nil, fmt.Errorf("waiting for setup complete: %w", err)
+		}
+		if msg.SetupComplete != nil {
+			break
+		}
+	}
+	ws.SetReadDeadline(time.Time{})
+
+	go m.read()
+	return m, nil
+}
+
+func (m *geminiChat) greet(greeting string) error {
+	return m.userTurn(fmt.Sprintf("[Chat started. Greet the user by writing: %q]", greeting))
+}
+
+func (m *geminiChat) send(text string) error {
+	return m.userTurn(text)
+}
+
+func (m *geminiChat) userTurn(text string) error {
+	return m.ws.WriteJSON(gemini20.GeminiClientMessage{
+		ClientContent: &gemini20.GeminiClientContent{
+			Turns:        []gemini20.GeminiContent{{Role: "user", Parts: []gemini20.GeminiPart{{Text: text}}}},
+			TurnComplete: true,
+		},
+	})
+}
+
+func (m *geminiChat) close() {
+	m.ws.Close()
+}
+
+func (m *geminiChat) read() {
+	s := m.s
+	for {
+		var msg gemini20.GeminiServerMessage
+		if err := m.ws.ReadJSON(&msg); err != nil {
+			s.providerFailed(err)
+			return
+		}
+
+		if sc := msg.ServerContent; sc != nil {
+			if sc.ModelTurn != nil {
+				for _, part := range sc.ModelTurn.Parts {
+					if part.Thought || part.Text == "" {
+						continue
+					}
+					m.reply.WriteString(part.Text)
+					s.delta(part.Text)
+				}
+			}
+			if sc.TurnComplete {
+				s.reply(m.reply.String())
+				m.reply.Reset()
+			}
+		}
+
+		if msg.ToolCall != nil {
+			for _, fc := range msg.ToolCall.FunctionCalls {
+				// Tools run off the read loop, as on calls
+				go func() {
+					out := s.runTool(s.ctx, fc.Name, fc.ID, fc.Args)
+					err := m.ws.WriteJSON(gemini20.GeminiClientMessage{
+						ToolResponse: &gemini20.GeminiToolResponse{
+							FunctionResponses: []gemini20.GeminiFunctionResponse{{Name: fc.Name, ID: fc.ID, Response: out}},
+						},
+					})
+					if err != nil {
+						s.log.WarnContext(s.ctx, "failed to send tool result", "tool", fc.Name, "error", err)
+					}
+				}()
+			}
+		}
+
+		if msg.UsageMetadata != nil {
+			s.meter.Add(msg.UsageMetadata.Tokens())
+		}
+	}
+}
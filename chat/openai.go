@@ -0,0 +1,150 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/openai"
+	"github.com/gorilla/websocket"
+)
+
+// openAIChat is a Realtime session with text as its only modality.
+type openAIChat struct {
+	s  *session
+	ws *conn
+}
+
+func dialOpenAI(s *session, instructions string, settings catalog.Settings) (model, error) {
+	header := http.Header{}
+	header.Add("Authorization", "Bearer "+os.Getenv("OPENAI_API_KEY"))
+	header.Add("OpenAI-Beta", "realtime=v1")
+
+	realtimeURL, err := openai.Endpoint(settings.Model)
+	if err != nil {
+		return nil, err
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(realtimeURL, header)
+	if err != nil {
+		return nil, err
+	}
+	m := &openAIChat{s: s, ws: &conn{Conn: ws}}
+
+	toolDefs := []map[string]interface{}{}
+	for _, t := range chatTools() {
+		toolDefs = append(toolDefs, map[string]interface{}{
+			"type":        "function",
+			"name":        t.Name,
+			"description": t.Description,
+			"parameters":  t.Parameters,
+		})
+	}
+	// No turn detection: a turn is each message the user sends
+	err = m.ws.WriteJSON(map[string]interface{}{
+		"type": "session.update",
+		"session": map[string]interface{}{
+			"modalities":     []string{"text"},
+			"instructions":   instructions,
+			"temperature":    *settings.Temperature,
+			"tools":          toolDefs,
+			"tool_choice":    "auto",
+			"turn_detection": nil,
+		},
+	})
+	if err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("session update: %w", err)
+	}
+
+	go m.read()
+	return m, nil
+}
+
+func (m *openAIChat) greet(greeting string) error {
+	return m.ws.WriteJSON(map[string]interface{}{
+		"type": "response.create",
+		"response": map[string]interface{}{
+			"instructions": fmt.Sprintf("Greet the user by writing: %q", greeting),
+		},
+	})
+}
+
+func (m *openAIChat) send(text string) error {
+	err := m.ws.WriteJSON(map[string]interface{}{
+		"type": "conversation.item.create",
+		"item": map[string]interface{}{
+			"type":    "message",
+			"role":    "user",
+			"content": []map[string]interface{}{{"type": "input_text", "text": text}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return m.ws.WriteJSON(map[string]interface{}{"type": "response.create"})
+}
+
+func (m *openAIChat) close() {
+	m.ws.Close()
+}
+
+func (m *openAIChat) read() {
+	s := m.s
+	for {
+		var msg map[string]interface{}
+		if err := m.ws.ReadJSON(&msg); err != nil {
+			s.providerFailed(err)
+			return
+		}
+
+		switch msg["type"] {
+		case "response.text.delta":
+			delta, _ := msg["delta"].(string)
+			s.delta(delta)
+
+		case "response.text.done":
+			text, _ := msg["text"].(string)
+			s.reply(text)
+
+		case "response.function_call_arguments.done":
+			name, _ := msg["name"].(string)
+			argsRaw, _ := msg["arguments"].(string)
+			toolCallID, _ := msg["call_id"].(string)
+
+			// Tools run off the read loop, as on calls
+			go func() {
+				var args map[string]interface{}
+				json.Unmarshal([]byte(argsRaw), &args)
+				out := s.runTool(s.ctx, name, toolCallID, args)
+
+				output, _ := json.Marshal(out)
+				err := m.ws.WriteJSON(map[string]interface{}{
+					"type": "conversation.item.create",
+					"item": map[string]interface{}{
+						"type":    "function_call_output",
+						"call_id": toolCallID,
+						"output":  string(output),
+					},
+				})
+				if err == nil {
+					err = m.ws.WriteJSON(map[string]interface{}{"type": "response.create"})
+				}
+				if err != nil {
+					s.log.WarnContext(s.ctx, "failed to send tool result", "tool", name, "error", err)
+				}
+			}()
+
+		case "response.done":
+			if resp, ok := msg["response"].(map[string]interface{}); ok {
+				if u, ok := resp["usage"].(map[string]interface{}); ok {
+					s.meter.Add(openai.Tokens(u))
+				}
+			}
+
+		case "error":
+			s.log.ErrorContext(s.ctx, "provider error", "error", msg["error"])
+		}
+	}
+}
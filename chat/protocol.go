@@ -0,0 +1,48 @@
+package chat
+
+// The /chat protocol is JSON text frames. The client sends Message
+// ("message" with Text, or "end"); the server answers with Event.
+//
+//	-> {"type":"message","text":"My car was hit"}
+//	<- {"type":"delta","text":"I'm sorry"}
+//	<- {"type":"message","text":"I'm sorry to hear that. Is everyone safe?"}
+//	-> {"type":"end"}
+//	<- {"type":"ended","reason":"user"}
+
+// Client message types
+const (
+	TypeMessage = "message"
+	TypeEnd     = "end"
+)
+
+// Server event types
+const (
+	TypeReady = "ready" // the session is open; SessionID names it
+	TypeDelta = "delta" // part of the reply being written
+	// TypeMessage is also sent for each complete reply
+	TypeEnded = "ended" // the session is over; Reason says why
+	TypeError = "error"
+)
+
+// Message is sent by the client.
+type Message struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// Event is sent to the client.
+type Event struct {
+	Type      string `json:"type"`
+	SessionID string `json:"session_id,omitempty"`
+	Agent     string `json:"agent,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Reasons a session ended
+const (
+	EndedByUser     = "user"     // the client sent "end" or disconnected
+	EndedByAgent    = "agent"    // the model called end_chat
+	EndedByProvider = "provider" // the provider connection failed
+)
@@ -0,0 +1,319 @@
+// Package chat runs an agent over a text WebSocket instead of a phone
+// call. It shares the agent's prompt, the tool registry, the transcript
+// queue and the call records with the voice bridges; the provider runs in
+// text mode.
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/metrics"
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/rabbitmq"
+	"github.com/AVVKavvk/openai-vobiz/tools"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/AVVKavvk/openai-vobiz/usage"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var logger = logging.For("chat")
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// chatNote adapts the voice prompt, which talks about calls, to chat
+const chatNote = "You are in a text chat, not a phone call. Keep messages short and plain. Ignore instructions about speaking, keypad input or hanging up; call end_chat instead of call_end when the conversation is over."
+
+// Built-in tools, declared before the shared registry's
+var builtins = []tools.Tool{
+	{
+		Name:        "get_customer_info",
+		Description: "Retrieves the user's name, age, and address from the database.",
+		Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	},
+	{
+		Name:        "end_chat",
+		Description: "Ends the chat once the conversation is finished or the user says goodbye. Write your closing message in the same turn.",
+		Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	},
+}
+
+func chatTools() []tools.Tool {
+	return append(append([]tools.Tool{}, builtins...), tools.ListChat()...)
+}
+
+// model is a provider session in text mode. Its read loop reports back
+// through the session's delta, reply and runTool.
+type model interface {
+	// greet has the model open the chat with greeting
+	greet(greeting string) error
+	// send adds a user message and asks for a reply
+	send(text string) error
+	close()
+}
+
+// Handler serves /chat. Query: agent, provider ("openai" or "gemini"),
+// from (the user's phone or account reference), body_data (campaign
+// JSON) and session_id (generated when empty). customerInfo stands in for
+// the customer lookup the voice bridges use.
+func Handler(customerInfo func() map[string]interface{}) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		provider := c.QueryParam("provider")
+		if provider == "" {
+			provider = catalog.OpenAI
+		}
+		if provider != catalog.OpenAI && provider != catalog.Gemini {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown provider")
+		}
+		id := c.QueryParam("session_id")
+		if id == "" {
+			id = uuid.NewString()
+		}
+		return serve(c, id, provider, customerInfo)
+	}
+}
+
+type session struct {
+	id           string
+	customerInfo func() map[string]interface{}
+	toolCall     tools.Call
+	meter        *usage.Meter
+
+	ctx context.Context
+	log *slog.Logger
+
+	client   *websocket.Conn
+	clientMu sync.Mutex
+	ending   atomic.Bool // end_chat was called; end after the reply
+	ended    atomic.Bool
+	endOnce  sync.Once
+	reason   string
+}
+
+func serve(c echo.Context, id, provider string, customerInfo func() map[string]interface{}) error {
+	from := c.QueryParam("from")
+	ctx, span := tracing.StartCall(c.Request().Context(), id, attribute.String("provider", provider), attribute.String("channel", models.ChannelChat))
+	defer span.End()
+	log := logger.With("session_id", id, "provider", provider)
+
+	persona := agent.Get(c.QueryParam("agent"))
+	span.SetAttributes(attribute.String("agent", persona.Name))
+	vars := agent.NewCallVars(id, from, "", c.QueryParam("body_data"), customerInfo())
+	instructions, err := persona.RenderInstructions(vars)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to render instructions", "agent", persona.Name, "error", err)
+		return err
+	}
+	greeting, err := persona.RenderGreeting(vars)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to render greeting", "agent", persona.Name, "error", err)
+		return err
+	}
+	instructions += "\n\n" + chatNote
+
+	settings, err := persona.SessionSettings(provider, persona.StartLanguage(vars), vars, nil)
+	if err != nil {
+		log.WarnContext(ctx, "ignoring invalid session settings", "error", err)
+	}
+	p, _ := catalog.Get(provider)
+	settings.Model = p.TextModel
+
+	client, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	s := &session{
+		id:           id,
+		customerInfo: customerInfo,
+		toolCall:     tools.Call{CallId: id, From: from, ClaimRef: vars.ClaimRef},
+		meter:        usage.NewMeter(provider, settings.Model),
+		ctx:          ctx,
+		log:          log,
+		client:       client,
+		reason:       EndedByUser,
+	}
+	log.InfoContext(ctx, "chat connected", "agent", persona.Name, "model", settings.Model)
+
+	// Chats are recorded like calls, so post-call analysis, extraction and
+	// claim lookups work the same
+	startedAt := time.Now().UTC()
+	defer func() {
+		callUsage := s.meter.Snapshot()
+		rabbitmq.PublishCallEnded(ctx, models.CallModel{
+			CallId:    id,
+			Agent:     persona.Name,
+			Provider:  provider,
+			Channel:   models.ChannelChat,
+			From:      from,
+			ClaimRef:  vars.ClaimRef,
+			StartedAt: startedAt,
+			EndedAt:   time.Now().UTC(),
+			Usage:     &callUsage,
+		})
+		log.InfoContext(ctx, "chat ended", "reason", s.reason, "estimated_cost_usd", callUsage.EstimatedCostUSD)
+	}()
+
+	var m model
+	switch provider {
+	case catalog.Gemini:
+		m, err = dialGemini(s, instructions, settings)
+	default:
+		m, err = dialOpenAI(s, instructions, settings)
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to connect to provider", "error", err)
+		s.write(Event{Type: TypeError, Error: "the assistant is unavailable, please try again later"})
+		s.end(EndedByProvider)
+		return nil
+	}
+	defer m.close()
+
+	s.write(Event{Type: TypeReady, SessionID: id, Agent: persona.Name})
+	if err := m.greet(greeting); err != nil {
+		log.WarnContext(ctx, "failed to trigger greeting", "error", err)
+	}
+
+	for {
+		var msg Message
+		if err := client.ReadJSON(&msg); err != nil {
+			if !s.ended.Load() {
+				log.InfoContext(ctx, "chat connection closed", "error", err)
+			}
+			s.end(EndedByUser)
+			return nil
+		}
+		switch msg.Type {
+		case TypeMessage:
+			if msg.Text == "" {
+				continue
+			}
+			s.publish("User", msg.Text)
+			if err := m.send(msg.Text); err != nil {
+				log.WarnContext(ctx, "failed to send message to provider", "error", err)
+				s.write(Event{Type: TypeError, Error: "the message could not be delivered"})
+			}
+		case TypeEnd:
+			s.end(EndedByUser)
+			return nil
+		default:
+			s.write(Event{Type: TypeError, Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+		}
+	}
+}
+
+// write sends an event to the client.
+func (s *session) write(ev Event) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	if err := s.client.WriteJSON(ev); err != nil {
+		s.log.DebugContext(s.ctx, "failed to write to client", "error", err)
+	}
+}
+
+// end tells the client the session is over and closes it, which ends
+// serve's read loop.
+func (s *session) end(reason string) {
+	s.endOnce.Do(func() {
+		s.ended.Store(true)
+		s.reason = reason
+		s.write(Event{Type: TypeEnded, Reason: reason})
+		s.clientMu.Lock()
+		s.client.Close()
+		s.clientMu.Unlock()
+	})
+}
+
+func (s *session) publish(role, content string) {
+	rabbitmq.RabbitMQProducerWithContext(s.ctx, models.TranscriptModel{Role: role, Content: content, CallId: s.id})
+}
+
+// delta forwards part of a reply as the model writes it.
+func (s *session) delta(text string) {
+	if text != "" {
+		s.write(Event{Type: TypeDelta, Text: text})
+	}
+}
+
+// reply delivers a complete reply, and ends the chat if end_chat was
+// called for it.
+func (s *session) reply(text string) {
+	if text != "" {
+		s.log.DebugContext(s.ctx, "transcript", "role", "AI", "chars", len(text))
+		s.publish("AI", text)
+		s.write(Event{Type: TypeMessage, Text: text})
+	}
+	if s.ending.Load() {
+		s.end(EndedByAgent)
+	}
+}
+
+// providerFailed ends the chat when the provider connection drops; after
+// the chat has ended that is just the connection being closed.
+func (s *session) providerFailed(err error) {
+	if s.ended.Load() {
+		return
+	}
+	s.log.WarnContext(s.ctx, "provider read failed", "error", err)
+	s.write(Event{Type: TypeError, Error: "the assistant disconnected"})
+	s.end(EndedByProvider)
+}
+
+// runTool runs a tool the model called and returns its result.
+func (s *session) runTool(ctx context.Context, name, toolCallID string, args map[string]interface{}) map[string]interface{} {
+	s.log.InfoContext(ctx, "tool call", "tool", name, "tool_call_id", toolCallID)
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "tool "+name, trace.WithAttributes(
+		attribute.String("tool.name", name),
+		attribute.String("tool.call_id", toolCallID),
+	))
+	defer span.End()
+
+	var out map[string]interface{}
+	switch name {
+	case "get_customer_info":
+		out = s.customerInfo()
+	case "end_chat":
+		s.ending.Store(true)
+		out = map[string]interface{}{"status": "chat_ended"}
+	default:
+		out = tools.Execute(ctx, s.toolCall, name, args)
+	}
+
+	_, failed := out["error"]
+	metrics.ObserveTool(name, start, failed)
+	if failed {
+		s.log.WarnContext(ctx, "tool failed", "tool", name, "result", out)
+		span.SetStatus(codes.Error, "tool returned an error")
+	}
+	return out
+}
+
+// conn serialises writes to a provider socket from the read loop and
+// tool goroutines.
+type conn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *conn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
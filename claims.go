@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/AVVKavvk/openai-vobiz/claims"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetClaim returns the FNOL record for a claim reference
+func HandleGetClaim(c echo.Context) error {
+	ref := c.Param("ref")
+
+	claim, err := claims.Get(ref)
+	if err != nil {
+		logger.ErrorContext(c.Request().Context(), "failed to load claim", "claim_ref", ref, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load claim")
+	}
+	if claim == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Claim not found")
+	}
+
+	return c.JSON(http.StatusOK, claim)
+}
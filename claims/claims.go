@@ -0,0 +1,44 @@
+package claims
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+)
+
+// Create stores the FNOL record. Calling it again for the same reference
+// from the same call updates the record, so the agent can correct details.
+func Create(claim models.ClaimModel) (*models.ClaimModel, error) {
+	if claim.Reference == "" {
+		return nil, fmt.Errorf("claim reference is required")
+	}
+	if claim.VehicleRegistration == "" {
+		return nil, fmt.Errorf("vehicle registration is required")
+	}
+
+	now := time.Now().UTC()
+	saved, err := redisClient.UpdateClaim(claim.Reference, func(existing *models.ClaimModel) (models.ClaimModel, error) {
+		claim.CreatedAt = now
+		if existing != nil {
+			if existing.CallId != claim.CallId {
+				return claim, fmt.Errorf("it belongs to another call")
+			}
+			claim.CreatedAt = existing.CreatedAt
+		}
+		claim.UpdatedAt = now
+		return claim, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save claim %s: %w", claim.Reference, err)
+	}
+
+	logger.Info("claim saved", "claim_ref", saved.Reference, "call_id", saved.CallId)
+	return saved, nil
+}
+
+// Get returns the claim for ref, or nil if it does not exist.
+func Get(ref string) (*models.ClaimModel, error) {
+	return redisClient.GetClaim(ref)
+}
@@ -0,0 +1,107 @@
+package claims
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/alicebob/miniredis/v2"
+)
+
+var mr *miniredis.Miniredis
+
+func TestMain(m *testing.M) {
+	var err error
+	if mr, err = miniredis.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "miniredis:", err)
+		os.Exit(1)
+	}
+	os.Setenv("REDIS_ADDR", mr.Addr())
+	os.Setenv("REDIS_PASSWORD", "")
+
+	code := m.Run()
+	mr.Close()
+	os.Exit(code)
+}
+
+func TestReservationExpiresUnlessClaimed(t *testing.T) {
+	unused := NewReference("call-unused")
+	if ttl := mr.TTL("claimref:" + unused); ttl != refReservation {
+		t.Errorf("unused reservation ttl = %v, want %v", ttl, refReservation)
+	}
+
+	ref := NewReference("call-claimed")
+	if _, err := Create(models.ClaimModel{Reference: ref, CallId: "call-claimed", VehicleRegistration: "MH12AB1234"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if ttl := mr.TTL("claimref:" + ref); ttl != 0 {
+		t.Errorf("claimed reservation ttl = %v, want none", ttl)
+	}
+}
+
+func TestCreateUpdatesOwnClaim(t *testing.T) {
+	ref := NewReference("call-a")
+	first, err := Create(models.ClaimModel{Reference: ref, CallId: "call-a", VehicleRegistration: "MH12AB1234"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	second, err := Create(models.ClaimModel{Reference: ref, CallId: "call-a", VehicleRegistration: "KA01MJ2022"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("created at moved from %v to %v", first.CreatedAt, second.CreatedAt)
+	}
+
+	got, err := Get(ref)
+	if err != nil || got == nil {
+		t.Fatalf("get: %v, %v", got, err)
+	}
+	if got.VehicleRegistration != "KA01MJ2022" {
+		t.Errorf("registration = %q, want the update", got.VehicleRegistration)
+	}
+}
+
+func TestCreateRejectsOtherCall(t *testing.T) {
+	ref := NewReference("call-owner")
+	if _, err := Create(models.ClaimModel{Reference: ref, CallId: "call-owner", VehicleRegistration: "MH12AB1234"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := Create(models.ClaimModel{Reference: ref, CallId: "call-other", VehicleRegistration: "DL3CAB0001"}); err == nil {
+		t.Fatal("another call overwrote the claim")
+	}
+	if got, _ := Get(ref); got.CallId != "call-owner" {
+		t.Errorf("claim owner = %q", got.CallId)
+	}
+}
+
+func TestConcurrentCreateHasOneOwner(t *testing.T) {
+	ref := NewReference("call-0")
+
+	const n = 10
+	var wg sync.WaitGroup
+	created := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(callId string) {
+			defer wg.Done()
+			if _, err := Create(models.ClaimModel{Reference: ref, CallId: callId, VehicleRegistration: "MH12AB1234"}); err == nil {
+				created <- callId
+			}
+		}(fmt.Sprintf("call-%d", i))
+	}
+	wg.Wait()
+	close(created)
+
+	got, err := Get(ref)
+	if err != nil || got == nil {
+		t.Fatalf("get: %v, %v", got, err)
+	}
+	for callId := range created {
+		if callId != got.CallId {
+			t.Errorf("%s was told its claim was saved, but %s owns it", callId, got.CallId)
+		}
+	}
+}
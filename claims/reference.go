@@ -0,0 +1,61 @@
+package claims
+
+import (
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+)
+
+var logger = logging.For("claims")
+
+// Reference alphabet without look- or sound-alike characters
+// (0/O, 1/I/L, 5/S, 8/B) so callers can note it down over the phone.
+const refAlphabet = "234679ACDEFGHJKMNPQRTUVWXYZ"
+
+const (
+	refPrefix   = "KW-"
+	refLength   = 6
+	refAttempts = 5
+
+	// refReservation outlives any call; Create makes the reservation of a
+	// reference that ends up on a claim permanent.
+	refReservation = 24 * time.Hour
+)
+
+// NewReference generates a human-readable claim reference such as
+// "KW-7HQ3MC" and reserves it for callId so no other call can use it.
+func NewReference(callId string) string {
+	var ref string
+	for i := 0; i < refAttempts; i++ {
+		ref = randomReference()
+
+		ok, err := redisClient.ReserveClaimRef(ref, callId, refReservation)
+		if err != nil {
+			// The space is large enough that an unreserved reference is
+			// still very unlikely to collide; don't fail the call over it.
+			logger.Warn("could not reserve claim reference", "call_id", callId, "claim_ref", ref, "error", err)
+			return ref
+		}
+		if ok {
+			return ref
+		}
+	}
+	logger.Warn("claim reference space congested, using unreserved reference", "call_id", callId, "claim_ref", ref)
+	return ref
+}
+
+func randomReference() string {
+	b := make([]byte, refLength)
+	max := big.NewInt(int64(len(refAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = refAlphabet[n.Int64()]
+	}
+	return refPrefix + string(b)
+}
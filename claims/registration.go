@@ -0,0 +1,204 @@
+package claims
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// stateCodes maps Indian registration state/UT codes to their names.
+var stateCodes = map[string]string{
+	"AN": "Andaman and Nicobar Islands",
+	"AP": "Andhra Pradesh",
+	"AR": "Arunachal Pradesh",
+	"AS": "Assam",
+	"BR": "Bihar",
+	"CG": "Chhattisgarh",
+	"CH": "Chandigarh",
+	"DD": "Dadra and Nagar Haveli and Daman and Diu",
+	"DL": "Delhi",
+	"DN": "Dadra and Nagar Haveli",
+	"GA": "Goa",
+	"GJ": "Gujarat",
+	"HP": "Himachal Pradesh",
+	"HR": "Haryana",
+	"JH": "Jharkhand",
+	"JK": "Jammu and Kashmir",
+	"KA": "Karnataka",
+	"KL": "Kerala",
+	"LA": "Ladakh",
+	"LD": "Lakshadweep",
+	"MH": "Maharashtra",
+	"ML": "Meghalaya",
+	"MN": "Manipur",
+	"MP": "Madhya Pradesh",
+	"MZ": "Mizoram",
+	"NL": "Nagaland",
+	"OD": "Odisha",
+	"OR": "Odisha",
+	"PB": "Punjab",
+	"PY": "Puducherry",
+	"RJ": "Rajasthan",
+	"SK": "Sikkim",
+	"TG": "Telangana",
+	"TN": "Tamil Nadu",
+	"TR": "Tripura",
+	"TS": "Telangana",
+	"UA": "Uttarakhand",
+	"UK": "Uttarakhand",
+	"UP": "Uttar Pradesh",
+	"WB": "West Bengal",
+}
+
+// Registration is a validated vehicle registration number.
+type Registration struct {
+	Canonical string // e.g. "MH12AB1234" or "22BH1234AA"
+	Formatted string // e.g. "MH 12 AB 1234", for reading back
+	State     string // state name, or "Bharat series"
+}
+
+// ValidateRegistration normalizes a registration number as heard (written
+// or spoken, e.g. "em etch twelve a b one two three four") and checks it
+// against the state/RTO format and the BH-series format. On failure it
+// returns a short reason that can be read to the caller.
+func ValidateRegistration(heard string) (*Registration, string, error) {
+	normalized, err := NormalizeRegistration(heard)
+	if err != nil {
+		return nil, normalized, err
+	}
+	if normalized == "" {
+		return nil, "", fmt.Errorf("no registration number was heard")
+	}
+
+	if reg, ok, err := parseBharatSeries(normalized); ok {
+		return reg, normalized, err
+	}
+	reg, err := parseStateSeries(normalized)
+	return reg, normalized, err
+}
+
+// delhiCategories are the vehicle-class letters Delhi appends to its RTO
+// number, e.g. the C in "DL 3C AB 1234".
+const delhiCategories = "CEPRSTVY"
+
+// parseStateSeries validates the standard format: state code, RTO number,
+// an optional series of up to three letters, and a number of up to four digits.
+// The RTO number is padded to two digits and the vehicle number to four, so
+// "MH 1 AB 12" and "MH01AB0012" are the same plate.
+func parseStateSeries(s string) (*Registration, error) {
+	state, rest := takeLetters(s)
+	if len(state) < 2 {
+		return nil, fmt.Errorf("it should start with a two-letter state code like MH, KA or DL")
+	}
+	if len(state) > 2 {
+		// No RTO number between the state code and the series
+		return nil, fmt.Errorf("the district number after the state code %s is missing", state[:2])
+	}
+	name, ok := stateCodes[state]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid state code", state)
+	}
+
+	rto, rest := takeDigits(rest)
+	if rto == "" {
+		return nil, fmt.Errorf("the district number after the state code %s is missing", state)
+	}
+	if len(rto) > 2 {
+		return nil, fmt.Errorf("the district number %s should be at most two digits", rto)
+	}
+
+	rto = fmt.Sprintf("%02s", rto)
+
+	series, rest := takeLetters(rest)
+	if state == "DL" && series != "" && strings.ContainsRune(delhiCategories, rune(series[0])) && len(series) <= 3 {
+		// Delhi: the first letter belongs to the RTO, the series follows
+		rto, series = rto+series[:1], series[1:]
+	}
+	if len(series) > 3 {
+		return nil, fmt.Errorf("the letter series %s is too long", series)
+	}
+	if strings.ContainsAny(series, "IO") {
+		return nil, fmt.Errorf("the letter series %s cannot contain I or O", series)
+	}
+
+	number, rest := takeDigits(rest)
+	if number == "" {
+		return nil, fmt.Errorf("the vehicle number at the end is missing")
+	}
+	if len(number) > 4 {
+		return nil, fmt.Errorf("the vehicle number %s should be at most four digits", number)
+	}
+	if strings.Trim(number, "0") == "" {
+		return nil, fmt.Errorf("the vehicle number cannot be all zeros")
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("there are extra characters %s at the end", rest)
+	}
+
+	number = fmt.Sprintf("%04s", number)
+	parts := []string{state, rto}
+	if series != "" {
+		parts = append(parts, series)
+	}
+	parts = append(parts, number)
+
+	return &Registration{
+		Canonical: strings.Join(parts, ""),
+		Formatted: strings.Join(parts, " "),
+		State:     name,
+	}, nil
+}
+
+// parseBharatSeries validates BH-series plates: two-digit year of
+// registration, "BH", four digits and one or two letters, e.g. 22BH1234AA.
+// ok is false when s does not look like a BH-series plate at all.
+func parseBharatSeries(s string) (*Registration, bool, error) {
+	year, rest := takeDigits(s)
+	if year == "" || !strings.HasPrefix(rest, "BH") {
+		return nil, false, nil
+	}
+	if len(year) != 2 {
+		return nil, true, fmt.Errorf("a BH-series number should start with the two-digit registration year")
+	}
+	if year < "21" {
+		return nil, true, fmt.Errorf("BH-series numbers start from 2021, not 20%s", year)
+	}
+
+	number, rest := takeDigits(rest[2:])
+	if len(number) != 4 {
+		return nil, true, fmt.Errorf("a BH-series number needs four digits after BH")
+	}
+
+	letters, rest := takeLetters(rest)
+	if len(letters) < 1 || len(letters) > 2 {
+		return nil, true, fmt.Errorf("a BH-series number should end with one or two letters")
+	}
+	if strings.ContainsAny(letters, "IO") {
+		return nil, true, fmt.Errorf("the letters %s cannot contain I or O", letters)
+	}
+	if rest != "" {
+		return nil, true, fmt.Errorf("there are extra characters %s at the end", rest)
+	}
+
+	return &Registration{
+		Canonical: year + "BH" + number + letters,
+		Formatted: strings.Join([]string{year, "BH", number, letters}, " "),
+		State:     "Bharat series",
+	}, true, nil
+}
+
+func takeLetters(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+func takeDigits(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,104 @@
+package claims
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRegistration(t *testing.T) {
+	tests := []struct {
+		heard string
+		want  string
+	}{
+		{"MH12AB1234", "MH12AB1234"},
+		{"mh-12 ab 1234", "MH12AB1234"},
+		{"em etch twelve a b one two three four", "MH12AB1234"},
+		{"em etch one two a b double four five six", "MH12AB4456"},
+		{"kay a oh one em jay twenty twenty two", "KA01MJ2022"},
+		{"my registration number is dee el three see a b twelve thirty four", "DL3CAB1234"},
+		{"twenty two bee aitch one two three four a a", "22BH1234AA"},
+		{"it's a MH12AB1234", "MH12AB1234"},
+		{"it's a em etch twelve a b one two three four", "MH12AB1234"},
+		{"a twenty two BH 1234 AA", "22BH1234AA"},
+		// Here the A is the state code, not an article
+		{"a p zero nine c d one two three four", "AP09CD1234"},
+		{"a s zero one double u x one", "AS01WX1"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeRegistration(tt.heard)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeRegistration(%q) = %q, %v; want %q", tt.heard, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRegistrationNamesUnknownWord(t *testing.T) {
+	_, err := NormalizeRegistration("em etch twelve banana")
+	if err == nil || !strings.Contains(err.Error(), "banana") {
+		t.Errorf("err = %v, want it to name the word", err)
+	}
+}
+
+func TestValidateRegistration(t *testing.T) {
+	tests := []struct {
+		heard     string
+		canonical string
+		formatted string
+		state     string
+	}{
+		{"MH12AB1234", "MH12AB1234", "MH 12 AB 1234", "Maharashtra"},
+		{"mh01ab1234", "MH01AB1234", "MH 01 AB 1234", "Maharashtra"},
+		{"MH 1 AB 12", "MH01AB0012", "MH 01 AB 0012", "Maharashtra"},
+		{"em etch one a b twelve", "MH01AB0012", "MH 01 AB 0012", "Maharashtra"},
+		{"it's a MH12AB1234", "MH12AB1234", "MH 12 AB 1234", "Maharashtra"},
+		{"DL 3C AB 1234", "DL03CAB1234", "DL 03C AB 1234", "Delhi"},
+		{"DL03CAB1234", "DL03CAB1234", "DL 03C AB 1234", "Delhi"},
+		{"dee el eight ess a b one", "DL08SAB0001", "DL 08S AB 0001", "Delhi"},
+		{"DL 1 AB 1234", "DL01AB1234", "DL 01 AB 1234", "Delhi"},
+		{"22 BH 1234 AA", "22BH1234AA", "22 BH 1234 AA", "Bharat series"},
+		{"twenty one bee aitch nine eight seven six see", "21BH9876C", "21 BH 9876 C", "Bharat series"},
+	}
+	for _, tt := range tests {
+		reg, _, err := ValidateRegistration(tt.heard)
+		if err != nil {
+			t.Errorf("ValidateRegistration(%q): %v", tt.heard, err)
+			continue
+		}
+		if reg.Canonical != tt.canonical || reg.Formatted != tt.formatted || reg.State != tt.state {
+			t.Errorf("ValidateRegistration(%q) = %+v, want %s / %q / %s", tt.heard, *reg, tt.canonical, tt.formatted, tt.state)
+		}
+	}
+}
+
+func TestValidateRegistrationRejects(t *testing.T) {
+	tests := []struct {
+		heard  string
+		reason string
+	}{
+		{"", "no registration number"},
+		{"XX12AB1234", "not a valid state code"},
+		{"MHAB1234", "district number after the state code MH is missing"},
+		{"MH123AB1234", "at most two digits"},
+		{"MH12ABCD1234", "too long"},
+		{"MH12IO1234", "cannot contain I or O"},
+		{"MH12AB", "vehicle number at the end is missing"},
+		{"MH12AB12345", "at most four digits"},
+		{"MH12AB0000", "all zeros"},
+		{"MH12AB1234X", "extra characters"},
+		{"2022BH1234AA", "two-digit registration year"},
+		{"20BH1234AA", "start from 2021"},
+		{"22BH123AA", "four digits after BH"},
+		{"22BH1234", "one or two letters"},
+		{"22BH1234IO", "cannot contain I or O"},
+	}
+	for _, tt := range tests {
+		reg, _, err := ValidateRegistration(tt.heard)
+		if err == nil {
+			t.Errorf("ValidateRegistration(%q) = %+v, want an error", tt.heard, *reg)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.reason) {
+			t.Errorf("ValidateRegistration(%q) = %q, want %q", tt.heard, err, tt.reason)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package claims
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// spokenLetters maps ASR spellings of letter names to the letter.
+var spokenLetters = map[string]string{
+	"a": "A", "ay": "A", "eh": "A",
+	"b": "B", "bee": "B", "be": "B",
+	"c": "C", "see": "C", "sea": "C", "cee": "C",
+	"d": "D", "dee": "D",
+	"e": "E", "ee": "E",
+	"f": "F", "ef": "F", "eff": "F",
+	"g": "G", "gee": "G", "jee": "G",
+	"h": "H", "aitch": "H", "etch": "H", "haitch": "H", "ech": "H",
+	"i": "I", "eye": "I",
+	"j": "J", "jay": "J",
+	"k": "K", "kay": "K", "kei": "K",
+	"l": "L", "el": "L", "ell": "L",
+	"m": "M", "em": "M",
+	"n": "N", "en": "N",
+	"p": "P", "pee": "P",
+	"q": "Q", "queue": "Q", "cue": "Q", "kyu": "Q",
+	"r": "R", "ar": "R", "are": "R",
+	"s": "S", "es": "S", "ess": "S",
+	"t": "T", "tee": "T", "tea": "T",
+	"u": "U", "you": "U", "yu": "U",
+	"v": "V", "vee": "V",
+	"w": "W",
+	"x": "X", "ex": "X",
+	"y": "Y", "why": "Y", "wai": "Y",
+	"z": "Z", "zed": "Z", "zee": "Z",
+}
+
+var spokenDigits = map[string]string{
+	"zero": "0", "one": "1", "two": "2", "to": "2", "too": "2",
+	"three": "3", "four": "4", "for": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9",
+	"ten": "10", "eleven": "11", "twelve": "12", "thirteen": "13",
+	"fourteen": "14", "fifteen": "15", "sixteen": "16", "seventeen": "17",
+	"eighteen": "18", "nineteen": "19",
+}
+
+var spokenTens = map[string]string{
+	"twenty": "2", "thirty": "3", "forty": "4", "fifty": "5",
+	"sixty": "6", "seventy": "7", "eighty": "8", "ninety": "9",
+}
+
+// fillerWords are ignored when normalizing a spoken registration.
+var fillerWords = map[string]bool{
+	"my": true, "number": true, "is": true, "its": true, "it's": true,
+	"the": true, "registration": true, "vehicle": true, "car": true,
+	"bike": true, "plate": true, "uh": true, "um": true, "and": true,
+	"space": true, "dash": true, "hyphen": true, "then": true,
+}
+
+// NormalizeRegistration turns a written or spoken registration into
+// uppercase letters and digits without separators, e.g.
+// "em etch twelve a b double four five six" becomes "MH12AB4456".
+// It returns an error naming the first word it could not interpret.
+func NormalizeRegistration(heard string) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(heard), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	var out strings.Builder
+	repeat := 1
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		var next string
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+
+		var token string
+		switch {
+		case fillerWords[w]:
+			continue
+
+		case out.Len() == 0 && (w == "a" || w == "an") && startsPlate(words[i+1:]):
+			// An article, as in "it's a MH12AB1234", not the A of AP or AS
+			continue
+
+		case w == "double" && (next == "u" || next == "you"):
+			token = "W"
+			i++
+
+		case w == "double" || w == "triple":
+			repeat = 2
+			if w == "triple" {
+				repeat = 3
+			}
+			continue
+
+		case w == "o" || w == "oh":
+			// O is never valid after the state code, so it is a zero there
+			if isLettersOnly(out.String()) && out.Len() < 2 {
+				token = "O"
+			} else {
+				token = "0"
+			}
+
+		case spokenTens[w] != "":
+			token = spokenTens[w] + "0"
+			if d, ok := spokenDigits[next]; ok && len(d) == 1 && d != "0" {
+				token = spokenTens[w] + d
+				i++
+			}
+
+		case spokenDigits[w] != "":
+			token = spokenDigits[w]
+
+		case spokenLetters[w] != "":
+			token = spokenLetters[w]
+
+		case isAlphanumeric(w) && (len(w) <= 4 || hasDigit(w)):
+			// Already written out, e.g. "mh12" or "1234"
+			token = strings.ToUpper(w)
+
+		default:
+			return strings.ToUpper(out.String()), fmt.Errorf("could not make out %q", w)
+		}
+
+		out.WriteString(strings.Repeat(token, repeat))
+		repeat = 1
+	}
+	return out.String(), nil
+}
+
+// startsPlate reports whether words, on their own, begin a registration:
+// the year of a BH-series plate, or a state code and its RTO number.
+func startsPlate(words []string) bool {
+	rest, _ := NormalizeRegistration(strings.Join(words, " "))
+	if rest == "" {
+		return false
+	}
+	if unicode.IsDigit(rune(rest[0])) {
+		return true
+	}
+	return len(rest) > 2 && stateCodes[rest[:2]] != "" && unicode.IsDigit(rune(rest[2]))
+}
+
+func isLettersOnly(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
@@ -0,0 +1,180 @@
+package claims
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/tools"
+)
+
+// FNOLSchema mirrors the FNOL steps in the agent prompt. It is both the
+// create_claim parameters and the default post-call extraction schema.
+var FNOLSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"caller_safe": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Step 1: the caller and everyone involved are safe.",
+		},
+		"vehicle_registration": map[string]interface{}{
+			"type":        "string",
+			"description": "Step 3: vehicle registration number, e.g. MH12AB1234.",
+		},
+		"relationship_to_policy": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"policyholder", "named_driver", "family_member", "other"},
+			"description": "Step 4: the caller's relationship to the policy.",
+		},
+		"incident": map[string]interface{}{
+			"type":        "object",
+			"description": "Step 5: the caller's narration of the incident.",
+			"properties": map[string]interface{}{
+				"what":                 map[string]interface{}{"type": "string", "description": "What happened."},
+				"where":                map[string]interface{}{"type": "string", "description": "Where it happened."},
+				"when":                 map[string]interface{}{"type": "string", "description": "When it happened."},
+				"damage":               map[string]interface{}{"type": "string", "description": "Damage to the vehicle."},
+				"third_party_involved": map[string]interface{}{"type": "boolean", "description": "Another vehicle or person was involved."},
+			},
+			"required": []string{"what", "where", "when"},
+		},
+		"injuries": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Step 7: anyone was injured.",
+		},
+		"injury_details": map[string]interface{}{
+			"type":        "string",
+			"description": "Step 7: who was injured and how, if anyone.",
+		},
+		"fir_filed": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Step 7: a police report (FIR) has been filed.",
+		},
+		"fir_number": map[string]interface{}{
+			"type":        "string",
+			"description": "Step 7: the FIR number, if filed.",
+		},
+		"police_station": map[string]interface{}{
+			"type":        "string",
+			"description": "Step 7: the police station the FIR was filed at.",
+		},
+		"current_location": map[string]interface{}{
+			"type":        "string",
+			"description": "Step 8: where the caller and vehicle are now.",
+		},
+	},
+	"required": []string{"vehicle_registration", "relationship_to_policy", "incident", "injuries"},
+}
+
+type createClaimArgs struct {
+	CallerSafe           bool   `json:"caller_safe"`
+	VehicleRegistration  string `json:"vehicle_registration"`
+	RelationshipToPolicy string `json:"relationship_to_policy"`
+	Incident             struct {
+		What               string `json:"what"`
+		Where              string `json:"where"`
+		When               string `json:"when"`
+		Damage             string `json:"damage"`
+		ThirdPartyInvolved bool   `json:"third_party_involved"`
+	} `json:"incident"`
+	Injuries        bool   `json:"injuries"`
+	InjuryDetails   string `json:"injury_details"`
+	FIRFiled        bool   `json:"fir_filed"`
+	FIRNumber       string `json:"fir_number"`
+	PoliceStation   string `json:"police_station"`
+	CurrentLocation string `json:"current_location"`
+}
+
+func init() {
+	tools.Register(tools.Tool{
+		Name:        "create_claim",
+		Description: "Creates the FNOL claim record once all details are collected (step 8). Returns the claim reference number to read out to the caller.",
+		Parameters:  FNOLSchema,
+		Handler:     handleCreateClaim,
+	})
+
+	tools.Register(tools.Tool{
+		Name:        "validate_vehicle_registration",
+		Description: "Validates an Indian vehicle registration number exactly as heard from the caller, including spoken forms like 'em etch twelve'. Returns the canonical number, or a reason to ask the caller to repeat it.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"registration": map[string]interface{}{
+					"type":        "string",
+					"description": "The registration number as the caller said it.",
+				},
+			},
+			"required": []string{"registration"},
+		},
+		Handler: handleValidateRegistration,
+	})
+}
+
+func handleValidateRegistration(ctx context.Context, call tools.Call, args map[string]interface{}) map[string]interface{} {
+	heard, _ := args["registration"].(string)
+
+	reg, normalized, err := ValidateRegistration(heard)
+	if err != nil {
+		return map[string]interface{}{
+			"valid":  false,
+			"heard":  normalized,
+			"reason": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"valid":        true,
+		"registration": reg.Canonical,
+		"formatted":    reg.Formatted,
+		"state":        reg.State,
+	}
+}
+
+func handleCreateClaim(ctx context.Context, call tools.Call, rawArgs map[string]interface{}) map[string]interface{} {
+	// Round-trip through JSON so both providers' argument maps decode the same way
+	var args createClaimArgs
+	data, _ := json.Marshal(rawArgs)
+	if err := json.Unmarshal(data, &args); err != nil {
+		return map[string]interface{}{"error": "invalid arguments: " + err.Error()}
+	}
+
+	// Store the canonical form when the model passes a spoken or spaced-out number
+	if reg, _, err := ValidateRegistration(args.VehicleRegistration); err == nil {
+		args.VehicleRegistration = reg.Canonical
+	}
+
+	ref := call.ClaimRef
+	if ref == "" {
+		ref = NewReference(call.CallId)
+	}
+
+	claim, err := Create(models.ClaimModel{
+		Reference:            ref,
+		CallId:               call.CallId,
+		Caller:               call.From,
+		CallerSafe:           args.CallerSafe,
+		VehicleRegistration:  args.VehicleRegistration,
+		RelationshipToPolicy: args.RelationshipToPolicy,
+		Incident: models.IncidentModel{
+			What:               args.Incident.What,
+			Where:              args.Incident.Where,
+			When:               args.Incident.When,
+			Damage:             args.Incident.Damage,
+			ThirdPartyInvolved: args.Incident.ThirdPartyInvolved,
+		},
+		Injuries:        args.Injuries,
+		InjuryDetails:   args.InjuryDetails,
+		FIRFiled:        args.FIRFiled,
+		FIRNumber:       args.FIRNumber,
+		PoliceStation:   args.PoliceStation,
+		CurrentLocation: args.CurrentLocation,
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"status":    "claim_created",
+		"reference": claim.Reference,
+	}
+}
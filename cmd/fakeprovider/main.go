@@ -0,0 +1,58 @@
+// Command fakeprovider serves a scripted OpenAI Realtime or Gemini Live
+// endpoint so the bridge can run without network access or API keys.
+//
+//	go run ./cmd/fakeprovider -provider gemini -scenario fakeprovider/scenarios/greeting.json -addr :9090
+//	GEMINI_LIVE_URL=ws://localhost:9090 go run .
+//	go run ./cmd/vobizsim -base http://localhost:8080
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/AVVKavvk/openai-vobiz/fakeprovider"
+)
+
+func main() {
+	provider := flag.String("provider", fakeprovider.Gemini, "protocol to speak: openai or gemini")
+	scenario := flag.String("scenario", "fakeprovider/scenarios/greeting.json", "scenario file")
+	addr := flag.String("addr", ":9090", "listen address")
+	once := flag.Bool("once", false, "exit after the first session, non-zero if the scenario failed")
+	flag.Parse()
+
+	sc, err := fakeprovider.LoadScenario(*scenario)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv, err := fakeprovider.NewServer(*provider, sc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		log.Printf("fake %s serving %q on %s", *provider, sc.Name, *addr)
+		log.Fatal(http.ListenAndServe(*addr, srv))
+	}()
+
+	<-srv.Done()
+	r := srv.Record()
+	fmt.Printf("configured:   %v\n", r.SessionConfigured)
+	fmt.Printf("tools:        %v\n", r.Tools)
+	fmt.Printf("responses:    %d\n", r.ResponseCreates)
+	fmt.Printf("caller audio: %v\n", r.CallerAudio)
+	for _, tr := range r.ToolResults {
+		fmt.Printf("tool result:  %s %v\n", tr.Tool, tr.Output)
+	}
+	if err := srv.Err(); err != nil {
+		fmt.Printf("scenario err: %v\n", err)
+		if *once {
+			os.Exit(1)
+		}
+	}
+	if !*once {
+		select {}
+	}
+}
@@ -0,0 +1,206 @@
+// Command loadtest runs stages of concurrent simulated calls against a
+// running bridge backed by the fake provider in echo mode, and reports
+// per-frame round-trip latency, dropped frames and goroutine and heap
+// growth per call. Start the bridge pointed at the fake first:
+//
+//	GEMINI_LIVE_URL=ws://localhost:9090 go run .
+//	go run ./cmd/loadtest -stages 10,50,100 -duration 30s
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+	"github.com/AVVKavvk/openai-vobiz/fakeprovider"
+	"github.com/AVVKavvk/openai-vobiz/vobizsim"
+)
+
+func main() {
+	base := flag.String("base", "http://localhost:8080", "bridge base URL")
+	stagesFlag := flag.String("stages", "1,10,50", "comma separated concurrency levels, run in order")
+	duration := flag.Duration("duration", 20*time.Second, "caller audio streamed per call")
+	ramp := flag.Duration("ramp", 5*time.Second, "spread the call starts of a stage over this long")
+	settle := flag.Duration("settle", 5*time.Second, "pause after each stage before measuring leftovers")
+	provider := flag.String("provider", fakeprovider.Gemini, "fake provider protocol: openai or gemini")
+	fakeAddr := flag.String("fake", ":9090", "listen address of the in-process fake provider; empty to use one already running")
+	flag.Parse()
+
+	stages, err := parseStages(*stagesFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *fakeAddr != "" {
+		srv, err := fakeprovider.NewServer(*provider, echoScenario(*duration))
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			log.Fatal(http.ListenAndServe(*fakeAddr, srv))
+		}()
+		log.Printf("fake %s provider echoing on %s", *provider, *fakeAddr)
+	}
+
+	metricsURL := strings.TrimRight(*base, "/") + "/metrics"
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "calls\tfailed\tframes\tdropped\tp50\tp90\tp99\tmax\tgoroutines/call\theap/call\tleftover goroutines")
+	for _, n := range stages {
+		log.Printf("stage: %d concurrent calls", n)
+		res := runStage(*base, metricsURL, n, *duration, *ramp, *settle)
+		fmt.Fprintln(w, res.row())
+	}
+	w.Flush()
+}
+
+func parseStages(s string) ([]int, error) {
+	var out []int
+	for _, f := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad stage %q", f)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// echoScenario plays every caller frame straight back for the length of
+// a call, then waits for the bridge to hang up.
+func echoScenario(d time.Duration) *fakeprovider.Scenario {
+	return &fakeprovider.Scenario{
+		Name: "loadtest echo",
+		Steps: []fakeprovider.Step{
+			{WaitFor: fakeprovider.WaitSetup, TimeoutMs: 30000},
+			{EchoMs: int((d + 10*time.Second).Milliseconds())},
+			{WaitFor: fakeprovider.WaitBridgeClose, TimeoutMs: 30000},
+		},
+	}
+}
+
+type stageResult struct {
+	calls, failed     int
+	sent, dropped     int
+	latencies         []time.Duration
+	base, peak, after runtimeStats
+}
+
+func runStage(base, metricsURL string, n int, d, ramp, settle time.Duration) stageResult {
+	res := stageResult{calls: n}
+	res.base, _ = scrapeRuntime(metricsURL)
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(i) * ramp / time.Duration(n))
+
+			cr, err := runCall(base, i, d)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("call %d: %v", i, err)
+				res.failed++
+				return
+			}
+			res.sent += cr.sent
+			res.dropped += cr.sent - len(cr.latencies)
+			res.latencies = append(res.latencies, cr.latencies...)
+		}(i)
+	}
+
+	// Every call is up once the ramp is over; sample halfway through
+	time.Sleep(time.Until(start.Add(ramp + d/2)))
+	res.peak, _ = scrapeRuntime(metricsURL)
+
+	wg.Wait()
+	time.Sleep(settle)
+	res.after, _ = scrapeRuntime(metricsURL)
+	return res
+}
+
+type callResult struct {
+	sent      int
+	latencies []time.Duration
+}
+
+// frame is 20ms of a 440Hz tone, so the bridge sees speech-like audio
+var frame = func() []byte {
+	pcm := make([]int16, vobizsim.FrameBytes)
+	for i := range pcm {
+		pcm[i] = int16(8000 * math.Sin(2*math.Pi*440*float64(i)/8000))
+	}
+	return audio.PCMToMuLaw(audio.Int16ToBytes(pcm))
+}()
+
+// runCall streams d of audio and matches the n-th echoed frame to the
+// n-th sent one.
+func runCall(base string, i int, d time.Duration) (callResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d+time.Minute)
+	defer cancel()
+
+	call, err := vobizsim.Start(ctx, vobizsim.Config{
+		BaseURL:  base,
+		CallUUID: fmt.Sprintf("load-%d-%d", time.Now().UnixNano(), i),
+		From:     fmt.Sprintf("91999%07d", i),
+	})
+	if err != nil {
+		return callResult{}, err
+	}
+	defer call.Close()
+
+	var sent []time.Duration
+	ticker := time.NewTicker(vobizsim.FrameDuration)
+	end := time.After(d)
+stream:
+	for {
+		select {
+		case <-ticker.C:
+			if err := call.SendFrame(frame); err != nil {
+				ticker.Stop()
+				return callResult{}, fmt.Errorf("send frame: %w", err)
+			}
+			sent = append(sent, call.Elapsed())
+		case <-end:
+			break stream
+		}
+	}
+	ticker.Stop()
+
+	// Give the tail a moment to come back; what doesn't is dropped
+	drain, cancelDrain := context.WithTimeout(ctx, 2*time.Second)
+	call.WaitForAudio(drain, time.Duration(len(sent))*vobizsim.FrameDuration)
+	cancelDrain()
+	call.Stop()
+
+	var echoed []time.Duration
+	for _, ev := range call.Events() {
+		if ev.Event != "playAudio" {
+			continue
+		}
+		for n := 0; n < len(ev.Audio)/vobizsim.FrameBytes; n++ {
+			echoed = append(echoed, ev.At)
+		}
+	}
+
+	res := callResult{sent: len(sent)}
+	for n := 0; n < len(sent) && n < len(echoed); n++ {
+		res.latencies = append(res.latencies, echoed[n]-sent[n])
+	}
+	return res, call.Err()
+}
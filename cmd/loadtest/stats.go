@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (r stageResult) row() string {
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	dropped := "-"
+	if r.sent > 0 {
+		dropped = fmt.Sprintf("%d (%.2f%%)", r.dropped, 100*float64(r.dropped)/float64(r.sent))
+	}
+	perCall := func(f func(runtimeStats) float64) float64 {
+		up := r.calls - r.failed
+		if up == 0 || !r.base.ok || !r.peak.ok {
+			return 0
+		}
+		return (f(r.peak) - f(r.base)) / float64(up)
+	}
+	leftover := "-"
+	if r.base.ok && r.after.ok {
+		leftover = fmt.Sprintf("%+.0f", r.after.goroutines-r.base.goroutines)
+	}
+
+	return fmt.Sprintf("%d\t%d\t%d\t%s\t%v\t%v\t%v\t%v\t%.1f\t%s\t%s",
+		r.calls, r.failed, r.sent, dropped,
+		percentile(r.latencies, 0.50), percentile(r.latencies, 0.90), percentile(r.latencies, 0.99), percentile(r.latencies, 1),
+		perCall(func(s runtimeStats) float64 { return s.goroutines }),
+		formatBytes(perCall(func(s runtimeStats) float64 { return s.heapInuse })),
+		leftover)
+}
+
+// percentile expects sorted input.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	i = max(0, min(i, len(sorted)-1))
+	return sorted[i].Round(100 * time.Microsecond)
+}
+
+func formatBytes(b float64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", b/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", b/(1<<10))
+	}
+	return fmt.Sprintf("%.0fB", b)
+}
+
+// runtimeStats is read from the Go collector series on the bridge's /metrics.
+type runtimeStats struct {
+	ok         bool
+	goroutines float64
+	heapInuse  float64
+}
+
+func scrapeRuntime(url string) (runtimeStats, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return runtimeStats{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return runtimeStats{}, fmt.Errorf("scrape %s: status %d", url, resp.StatusCode)
+	}
+
+	var s runtimeStats
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		name, value, found := strings.Cut(sc.Text(), " ")
+		if !found {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch name {
+		case "go_goroutines":
+			s.goroutines = v
+		case "go_memstats_heap_inuse_bytes":
+			s.heapInuse = v
+		}
+	}
+	s.ok = true
+	return s, sc.Err()
+}
@@ -0,0 +1,92 @@
+// Command replay feeds a call capture (see CAPTURE_DIR) back through a
+// running bridge: it serves the captured provider frames from a fake
+// provider and streams the captured Vobiz frames to /stream, both with
+// their original timing. Start the bridge pointed at the fake first:
+//
+//	GEMINI_LIVE_URL=ws://localhost:9090 go run .
+//	go run ./cmd/replay -capture captures/20260115T114951-0e516b32.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/capture"
+	"github.com/AVVKavvk/openai-vobiz/fakeprovider"
+	"github.com/AVVKavvk/openai-vobiz/vobizsim"
+)
+
+func main() {
+	path := flag.String("capture", "", "capture file to replay")
+	base := flag.String("base", "http://localhost:8080", "bridge base URL")
+	streamPath := flag.String("path", "/stream", "media stream path on the bridge")
+	fakeAddr := flag.String("fake", ":9090", "listen address of the fake provider")
+	timeout := flag.Duration("timeout", 5*time.Minute, "give up after this long")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("-capture is required")
+	}
+	c, err := capture.Load(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv, err := fakeprovider.NewServer(c.Header.Provider, fakeprovider.ReplayScenario(c))
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Fatal(http.ListenAndServe(*fakeAddr, srv))
+	}()
+	log.Printf("fake %s provider on %s, replaying call %s", c.Header.Provider, *fakeAddr, c.Header.CallUUID)
+
+	// A fresh CallUUID keeps the replay's transcripts and records apart
+	// from the captured call
+	cfg := vobizsim.Config{CallUUID: fmt.Sprintf("replay-%d", time.Now().UnixNano())}
+	query, _ := url.ParseQuery(c.Header.Query)
+	query.Set("calluuid", cfg.CallUUID)
+	streamURL := strings.Replace(strings.TrimRight(*base, "/"), "http", "ws", 1) + *streamPath + "?" + query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	call, err := vobizsim.Dial(ctx, cfg, vobizsim.StreamInstruction{URL: streamURL, Bidirectional: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer call.Close()
+
+	if err := call.Replay(ctx, c.From(capture.Vobiz)); err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	if err := call.WaitForClose(ctx); err != nil {
+		log.Printf("bridge did not hang up: %v", err)
+	}
+	select {
+	case <-srv.Done():
+	case <-ctx.Done():
+	}
+
+	r := srv.Record()
+	first, _ := call.FirstAudioAt()
+	fmt.Printf("call:         %s (replay of %s)\n", cfg.CallUUID, c.Header.CallUUID)
+	fmt.Printf("played:       %v\n", call.PlayedDuration())
+	fmt.Printf("first audio:  %v\n", first)
+	fmt.Printf("clearAudio:   %d\n", call.Count("clearAudio"))
+	fmt.Printf("caller audio: %v\n", r.CallerAudio)
+	for _, tr := range r.ToolResults {
+		fmt.Printf("tool result:  %s %v\n", tr.Tool, tr.Output)
+	}
+	if err := srv.Err(); err != nil {
+		fmt.Printf("provider err: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+// Command vobizsim places a simulated Vobiz call against a running bridge,
+// streams a WAV file as the caller and reports what the bridge played.
+//
+//	go run ./cmd/vobizsim -base http://localhost:8080 -wav caller.wav -out reply.wav
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+	"github.com/AVVKavvk/openai-vobiz/vobizsim"
+)
+
+func main() {
+	base := flag.String("base", "http://localhost:8080", "bridge base URL")
+	wav := flag.String("wav", "", "caller audio (WAV); silence when empty")
+	from := flag.String("from", "", "caller number")
+	to := flag.String("to", "", "dialled number")
+	bodyData := flag.String("body-data", "", "campaign JSON forwarded as body_data")
+	greet := flag.Duration("greet", 5*time.Second, "silence to stream before the caller audio, while the agent greets")
+	listen := flag.Duration("listen", 10*time.Second, "silence to stream after the caller audio")
+	out := flag.String("out", "", "write the audio the bridge played to this WAV file")
+	flag.Parse()
+
+	ctx := context.Background()
+	call, err := vobizsim.Start(ctx, vobizsim.Config{
+		BaseURL:  *base,
+		From:     *from,
+		To:       *to,
+		BodyData: *bodyData,
+	})
+	if err != nil {
+		log.Fatalf("start call: %v", err)
+	}
+	defer call.Close()
+	log.Printf("call %s streaming to %s", call.Config.CallUUID, call.Instruction.URL)
+
+	if err := call.StreamSilence(ctx, *greet); err != nil {
+		log.Fatalf("stream: %v", err)
+	}
+	if *wav != "" {
+		if err := call.StreamWAV(ctx, *wav); err != nil {
+			log.Fatalf("stream %s: %v", *wav, err)
+		}
+	}
+	if err := call.StreamSilence(ctx, *listen); err != nil {
+		log.Fatalf("stream: %v", err)
+	}
+	if err := call.Stop(); err != nil {
+		log.Printf("send stop: %v", err)
+	}
+
+	first, _ := call.FirstAudioAt()
+	fmt.Printf("played:      %v\n", call.PlayedDuration())
+	fmt.Printf("first audio: %v\n", first)
+	fmt.Printf("clearAudio:  %d\n", call.Count("clearAudio"))
+	if err := call.Err(); err != nil {
+		fmt.Printf("stream err:  %v\n", err)
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("create %s: %v", *out, err)
+		}
+		defer f.Close()
+		if err := audio.WriteWAV(f, call.PlayedAudio()); err != nil {
+			log.Fatalf("write %s: %v", *out, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package dtmf
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+const frameSamples = SampleRate / 50 // 20ms
+
+// keyFreqs returns the row and column tones of key.
+func keyFreqs(t *testing.T, key byte) (row, col float64) {
+	t.Helper()
+	for r := range keys {
+		for c := range keys[r] {
+			if keys[r][c] == key {
+				return rowFreqs[r], colFreqs[c]
+			}
+		}
+	}
+	t.Fatalf("no key %q", key)
+	return 0, 0
+}
+
+// tone returns d of the two tones at the given peak amplitudes, passed
+// through μ-law like the caller's audio from Vobiz.
+func tone(row, col, rowAmp, colAmp float64, d time.Duration) []int16 {
+	pcm := make([]int16, int(d.Seconds()*SampleRate))
+	for i := range pcm {
+		t := float64(i) / SampleRate
+		pcm[i] = int16(rowAmp*math.Sin(2*math.Pi*row*t) + colAmp*math.Sin(2*math.Pi*col*t))
+	}
+	return audio.BytesToInt16(audio.MuLawToPCM(audio.PCMToMuLaw(audio.Int16ToBytes(pcm))))
+}
+
+func silence(d time.Duration) []int16 {
+	return make([]int16, int(d.Seconds()*SampleRate))
+}
+
+// presses runs pcm through a detector in 20ms frames.
+func presses(d *Detector, pcm []int16) string {
+	var got []byte
+	for i := 0; i+frameSamples <= len(pcm); i += frameSamples {
+		if key, ok := d.Process(pcm[i : i+frameSamples]); ok {
+			got = append(got, key)
+		}
+	}
+	return string(got)
+}
+
+func TestDetectorFindsEveryKey(t *testing.T) {
+	for _, key := range []byte("123A456B789C*0#D") {
+		row, col := keyFreqs(t, key)
+		var d Detector
+		if got := presses(&d, tone(row, col, 6000, 6000, 80*time.Millisecond)); got != string(key) {
+			t.Errorf("key %c: detected %q", key, got)
+		}
+	}
+}
+
+func TestDetectorAllowsTwist(t *testing.T) {
+	row, col := keyFreqs(t, '5')
+	for _, tt := range []struct {
+		name           string
+		rowAmp, colAmp float64
+		want           string
+	}{
+		// Lines attenuate the higher column tone most: 6dB either way is normal
+		{"column 6dB weaker", 8000, 4000, "5"},
+		{"row 6dB weaker", 4000, 8000, "5"},
+		// Past maxTwist (9dB in power) it is not a key
+		{"column 12dB weaker", 8000, 2000, ""},
+		{"row 12dB weaker", 2000, 8000, ""},
+	} {
+		var d Detector
+		if got := presses(&d, tone(row, col, tt.rowAmp, tt.colAmp, 80*time.Millisecond)); got != tt.want {
+			t.Errorf("%s: detected %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectorIgnoresToneShorterThanAPress(t *testing.T) {
+	row, col := keyFreqs(t, '9')
+	var d Detector
+	pcm := append(silence(40*time.Millisecond), tone(row, col, 6000, 6000, 20*time.Millisecond)...)
+	pcm = append(pcm, silence(40*time.Millisecond)...)
+	if got := presses(&d, pcm); got != "" {
+		t.Errorf("a 20ms tone was detected as %q", got)
+	}
+}
+
+func TestDetectorReportsAHeldKeyOnce(t *testing.T) {
+	row, col := keyFreqs(t, '0')
+	var d Detector
+	var pcm []int16
+	for range 2 {
+		pcm = append(pcm, tone(row, col, 6000, 6000, 300*time.Millisecond)...)
+		pcm = append(pcm, silence(60*time.Millisecond)...)
+	}
+	if got := presses(&d, pcm); got != "00" {
+		t.Errorf("two held presses detected as %q", got)
+	}
+}
+
+func TestDetectorIgnoresOtherSounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]int16, SampleRate)
+	for i := range noise {
+		noise[i] = int16(rng.NormFloat64() * 4000)
+	}
+	// A chord of harmonics, like a voiced vowel, crossing the key bands
+	voice := make([]int16, SampleRate)
+	for i := range voice {
+		t := float64(i) / SampleRate
+		for h := 1; h <= 20; h++ {
+			voice[i] += int16(3000 / float64(h) * math.Sin(2*math.Pi*140*float64(h)*t))
+		}
+	}
+	row, _ := keyFreqs(t, '1')
+	for name, pcm := range map[string][]int16{
+		"white noise":   noise,
+		"voice":         voice,
+		"one row tone":  tone(row, 0, 8000, 0, time.Second),
+		"quiet key '1'": tone(697, 1209, 150, 150, time.Second),
+	} {
+		var d Detector
+		if got := presses(&d, pcm); got != "" {
+			t.Errorf("%s: detected %q", name, got)
+		}
+	}
+}
+
+// collect starts a collection and waits until it is accepting keys.
+func collect(t *testing.T, k *Keypad, rules Rules) <-chan Result {
+	t.Helper()
+	out := make(chan Result, 1)
+	go func() {
+		res, err := k.Collect(context.Background(), rules)
+		if err != nil {
+			t.Errorf("collect: %v", err)
+		}
+		out <- res
+	}()
+	deadline := time.Now().Add(time.Second)
+	for !k.Collecting() {
+		if time.Now().After(deadline) {
+			t.Fatal("collection did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return out
+}
+
+func press(k *Keypad, keys, source string) {
+	for i := range len(keys) {
+		k.Press(keys[i], source)
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitResult(t *testing.T, results <-chan Result) Result {
+	t.Helper()
+	select {
+	case res := <-results:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("collection did not finish")
+		return Result{}
+	}
+}
+
+func TestCollectRules(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		rules Rules
+		keys  string
+		want  Result
+	}{
+		{"max digits", Rules{MaxDigits: 4, Terminator: "#", Timeout: time.Second}, "123456", Result{"1234", ReasonMaxDigits}},
+		{"terminator", Rules{MaxDigits: 16, Terminator: "#", Timeout: time.Second}, "4821#", Result{"4821", ReasonTerminator}},
+		{"no terminator", Rules{MaxDigits: 3, Timeout: time.Second}, "1#2", Result{"1#2", ReasonMaxDigits}},
+		{"inter-digit timeout", Rules{MaxDigits: 16, Terminator: "#", Timeout: 5 * time.Second, InterDigitTimeout: 100 * time.Millisecond}, "42", Result{"42", ReasonInterDigit}},
+		{"timeout", Rules{MaxDigits: 16, Terminator: "#", Timeout: 100 * time.Millisecond}, "", Result{"", ReasonTimeout}},
+	} {
+		k := NewKeypad(t.Name()+tt.name, nil)
+		results := collect(t, k, tt.rules)
+		press(k, tt.keys, SourceVobiz)
+		if got := waitResult(t, results); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		k.Close()
+	}
+}
+
+func TestCollectCountsKeysPressedJustBefore(t *testing.T) {
+	k := NewKeypad(t.Name(), nil)
+	defer k.Close()
+
+	press(k, "12", SourceVobiz)
+	results := collect(t, k, Rules{MaxDigits: 4, Timeout: time.Second})
+	press(k, "34", SourceVobiz)
+	if got := waitResult(t, results); got != (Result{"1234", ReasonMaxDigits}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestCollectIsExclusive(t *testing.T) {
+	k := NewKeypad(t.Name(), nil)
+	defer k.Close()
+
+	results := collect(t, k, Rules{MaxDigits: 1, Timeout: time.Second})
+	if _, err := k.Collect(context.Background(), Rules{}); err != ErrBusy {
+		t.Errorf("second collection: %v, want ErrBusy", err)
+	}
+	press(k, "7", SourceVobiz)
+	waitResult(t, results)
+}
+
+func TestKeyFromBothSourcesCountsOnce(t *testing.T) {
+	k := NewKeypad(t.Name(), nil)
+	defer k.Close()
+
+	results := collect(t, k, Rules{MaxDigits: 16, Terminator: "#", Timeout: time.Second})
+	// Heard in the audio and then reported by Vobiz: one press
+	k.Press('5', SourceInBand)
+	k.Press('5', SourceVobiz)
+	// Once Vobiz reports presses, in-band detection is ignored
+	k.Press('6', SourceInBand)
+	// A repeat from the same source is a second press
+	k.Press('5', SourceVobiz)
+	k.Press('#', SourceVobiz)
+
+	if got := waitResult(t, results); got != (Result{"55", ReasonTerminator}) {
+		t.Errorf("got %+v, want 55", got)
+	}
+}
+
+func TestFreeInputIsBatched(t *testing.T) {
+	got := make(chan string, 2)
+	k := NewKeypad(t.Name(), func(digits string) { got <- digits })
+	defer k.Close()
+
+	press(k, "12#", SourceVobiz)
+	select {
+	case d := <-got:
+		if d != "12#" {
+			t.Errorf("batch %q, want 12#", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("# did not end the batch")
+	}
+
+	press(k, "3", SourceInBand)
+	select {
+	case d := <-got:
+		t.Errorf("in-band %q delivered after Vobiz reported presses", d)
+	case <-time.After(batchGap + 200*time.Millisecond):
+	}
+}
+
+func TestMask(t *testing.T) {
+	for in, want := range map[string]string{
+		"":           "",
+		"123456":     "XXXXXX",
+		"4821#":      "XXXX#",
+		"*99#":       "*XX#",
+		"1A2B":       "XXXX",
+		"0000000000": "XXXXXXXXXX",
+	} {
+		if got := Mask(in); got != want {
+			t.Errorf("Mask(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
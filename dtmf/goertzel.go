@@ -0,0 +1,116 @@
+// Package dtmf handles keypad input from the caller: key presses reported
+// by Vobiz, tones detected in the call audio when Vobiz does not report
+// them, and the collect_digits tool.
+package dtmf
+
+import "math"
+
+// SampleRate of the audio the detector expects.
+const SampleRate = 8000
+
+var (
+	rowFreqs = [4]float64{697, 770, 852, 941}
+	colFreqs = [4]float64{1209, 1336, 1477, 1633}
+	keys     = [4][4]byte{
+		{'1', '2', '3', 'A'},
+		{'4', '5', '6', 'B'},
+		{'7', '8', '9', 'C'},
+		{'*', '0', '#', 'D'},
+	}
+)
+
+const (
+	// minRMS is the quietest frame that can hold a key tone
+	minRMS = 200
+	// minShare is the least share of the frame's energy the two tones must
+	// carry; speech spreads its energy much wider
+	minShare = 0.6
+	// maxTwist bounds the power ratio between the row and column tones
+	maxTwist = 8
+	// minPeak is how much stronger than the rest of its group each tone
+	// must be
+	minPeak = 6
+	// holdFrames of the same key in a row make a press; 40ms at 20ms frames
+	holdFrames = 2
+)
+
+// Detector finds key tones in 8kHz PCM frames of one call. It is not safe
+// for concurrent use.
+type Detector struct {
+	candidate byte // key seen in the latest frames
+	count     int  // frames in a row with candidate
+	down      bool // candidate was reported and is still held
+}
+
+// Process examines one frame of 10-40ms and returns the key if a press
+// starts in it.
+func (d *Detector) Process(pcm []int16) (key byte, ok bool) {
+	k := detect(pcm)
+	if k != d.candidate {
+		d.candidate, d.count, d.down = k, 0, false
+	}
+	d.count++
+	if k == 0 || d.down || d.count < holdFrames {
+		return 0, false
+	}
+	d.down = true
+	return k, true
+}
+
+// detect returns the key whose tones dominate the frame, or 0.
+func detect(pcm []int16) byte {
+	n := len(pcm)
+	if n == 0 {
+		return 0
+	}
+	var energy float64
+	for _, s := range pcm {
+		energy += float64(s) * float64(s)
+	}
+	if math.Sqrt(energy/float64(n)) < minRMS {
+		return 0
+	}
+
+	row, rowPower, rowPeak := strongest(pcm, rowFreqs)
+	col, colPower, colPeak := strongest(pcm, colFreqs)
+	if !rowPeak || !colPeak {
+		return 0
+	}
+	if rowPower > colPower*maxTwist || colPower > rowPower*maxTwist {
+		return 0
+	}
+	// A pure tone of the frame's energy has power energy*n/2
+	if (rowPower+colPower)/(energy*float64(n)/2) < minShare {
+		return 0
+	}
+	return keys[row][col]
+}
+
+// strongest returns the loudest of freqs and whether it stands out from
+// the others.
+func strongest(pcm []int16, freqs [4]float64) (idx int, power float64, peak bool) {
+	var powers [4]float64
+	for i, f := range freqs {
+		powers[i] = goertzel(pcm, f)
+		if powers[i] > powers[idx] {
+			idx = i
+		}
+	}
+	for i, p := range powers {
+		if i != idx && p*minPeak > powers[idx] {
+			return idx, powers[idx], false
+		}
+	}
+	return idx, powers[idx], true
+}
+
+// goertzel returns the power of freq in pcm.
+func goertzel(pcm []int16, freq float64) float64 {
+	coeff := 2 * math.Cos(2*math.Pi*freq/SampleRate)
+	var s1, s2 float64
+	for _, x := range pcm {
+		s0 := float64(x) + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
@@ -0,0 +1,258 @@
+package dtmf
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+var logger = logging.For("dtmf")
+
+// Sources of a key press
+const (
+	SourceVobiz  = "vobiz"  // a dtmf event on the media stream
+	SourceInBand = "inband" // tones detected in the caller's audio
+)
+
+const (
+	// echoWindow is how long a press from one source hides the same key
+	// from the other
+	echoWindow = 300 * time.Millisecond
+	// batchGap of no presses ends a run of free keypad input
+	batchGap = 1500 * time.Millisecond
+)
+
+// Collection end reasons
+const (
+	ReasonTerminator = "terminator"
+	ReasonMaxDigits  = "max_digits"
+	ReasonTimeout    = "timeout"
+	ReasonInterDigit = "inter_digit_timeout"
+	ReasonCancelled  = "cancelled"
+)
+
+var ErrBusy = errors.New("already collecting digits")
+
+// Rules bound one digit collection.
+type Rules struct {
+	MinDigits int
+	MaxDigits int
+	// Terminator ends the input and is not part of it; "" for none
+	Terminator string
+	// Timeout is the most the caller gets to finish
+	Timeout time.Duration
+	// InterDigitTimeout is the longest pause between two keys
+	InterDigitTimeout time.Duration
+}
+
+// Result is a finished collection.
+type Result struct {
+	Digits string
+	Reason string
+}
+
+// Keypad routes the key presses of one call: to a running collection,
+// otherwise in runs to OnDigits.
+type Keypad struct {
+	callID   string
+	onDigits func(digits string)
+
+	mu        sync.Mutex
+	vobizSeen bool // Vobiz reports presses, in-band detection is off
+	lastKey   byte
+	lastFrom  string
+	lastAt    time.Time
+	collect   chan byte // set while collecting
+	pending   []byte
+	flush     *time.Timer
+	closed    bool
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Keypad{}
+)
+
+// NewKeypad returns the keypad of a call, found by the collect_digits tool
+// through callID. onDigits receives free input, e.g. a menu choice.
+func NewKeypad(callID string, onDigits func(digits string)) *Keypad {
+	k := &Keypad{callID: callID, onDigits: onDigits}
+	registryMu.Lock()
+	registry[callID] = k
+	registryMu.Unlock()
+	return k
+}
+
+func lookup(callID string) *Keypad {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registry[callID]
+}
+
+// Close unregisters the keypad and ends a running collection.
+func (k *Keypad) Close() {
+	registryMu.Lock()
+	if registry[k.callID] == k {
+		delete(registry, k.callID)
+	}
+	registryMu.Unlock()
+
+	k.mu.Lock()
+	k.closed = true
+	if k.flush != nil {
+		k.flush.Stop()
+	}
+	k.mu.Unlock()
+}
+
+// Press adds a key press and reports whether it counted; a press already
+// reported by the other source does not.
+func (k *Keypad) Press(key byte, source string) bool {
+	if !strings.ContainsRune("0123456789*#ABCD", rune(key)) {
+		return false
+	}
+	now := time.Now()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.closed {
+		return false
+	}
+	switch source {
+	case SourceVobiz:
+		k.vobizSeen = true
+	case SourceInBand:
+		if k.vobizSeen {
+			return false
+		}
+	}
+	if key == k.lastKey && source != k.lastFrom && now.Sub(k.lastAt) < echoWindow {
+		// The echo becomes the last press, so a real repeat from this
+		// source still counts
+		k.lastFrom, k.lastAt = source, now
+		return false
+	}
+	k.lastKey, k.lastFrom, k.lastAt = key, source, now
+
+	if k.collect != nil {
+		select {
+		case k.collect <- key:
+		default:
+		}
+		return true
+	}
+
+	k.pending = append(k.pending, key)
+	if k.flush != nil {
+		k.flush.Stop()
+	}
+	if key == '#' {
+		k.flushLocked()
+	} else {
+		k.flush = time.AfterFunc(batchGap, func() {
+			k.mu.Lock()
+			defer k.mu.Unlock()
+			k.flushLocked()
+		})
+	}
+	return true
+}
+
+// Mask hides the keys in digits for transcripts and logs, keeping * and #
+// so the shape of the input stays readable: "1234#" becomes "XXXX#".
+func Mask(digits string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '*' || r == '#' {
+			return r
+		}
+		return 'X'
+	}, digits)
+}
+
+func (k *Keypad) flushLocked() {
+	if len(k.pending) == 0 || k.closed || k.collect != nil {
+		return
+	}
+	digits := string(k.pending)
+	k.pending = nil
+	if k.onDigits != nil {
+		go k.onDigits(digits)
+	}
+}
+
+// Collecting reports whether a collection is waiting for the caller.
+func (k *Keypad) Collecting() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.collect != nil
+}
+
+// Collect waits for the caller to key in digits under rules. Keys pressed
+// just before it started count as the start of the input.
+func (k *Keypad) Collect(ctx context.Context, rules Rules) (Result, error) {
+	k.mu.Lock()
+	if k.collect != nil {
+		k.mu.Unlock()
+		return Result{}, ErrBusy
+	}
+	ch := make(chan byte, 64)
+	k.collect = ch
+	early := k.pending
+	k.pending = nil
+	if k.flush != nil {
+		k.flush.Stop()
+	}
+	k.mu.Unlock()
+
+	defer func() {
+		k.mu.Lock()
+		k.collect = nil
+		k.mu.Unlock()
+	}()
+
+	var digits []byte
+	// add reports the end reason once key completes the input
+	add := func(key byte) string {
+		if rules.Terminator != "" && string(key) == rules.Terminator {
+			return ReasonTerminator
+		}
+		digits = append(digits, key)
+		if rules.MaxDigits > 0 && len(digits) >= rules.MaxDigits {
+			return ReasonMaxDigits
+		}
+		return ""
+	}
+	for _, key := range early {
+		if reason := add(key); reason != "" {
+			return Result{Digits: string(digits), Reason: reason}, nil
+		}
+	}
+
+	overall := time.NewTimer(rules.Timeout)
+	defer overall.Stop()
+	var gap <-chan time.Time
+	if len(digits) > 0 && rules.InterDigitTimeout > 0 {
+		gap = time.After(rules.InterDigitTimeout)
+	}
+	for {
+		select {
+		case key := <-ch:
+			if reason := add(key); reason != "" {
+				return Result{Digits: string(digits), Reason: reason}, nil
+			}
+			if rules.InterDigitTimeout > 0 {
+				gap = time.After(rules.InterDigitTimeout)
+			}
+		case <-gap:
+			return Result{Digits: string(digits), Reason: ReasonInterDigit}, nil
+		case <-overall.C:
+			return Result{Digits: string(digits), Reason: ReasonTimeout}, nil
+		case <-ctx.Done():
+			return Result{Digits: string(digits), Reason: ReasonCancelled}, nil
+		}
+	}
+}
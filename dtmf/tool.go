@@ -0,0 +1,87 @@
+package dtmf
+
+import (
+	"context"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/tools"
+)
+
+// ToolName is the tool that collects keypad input. It blocks until the
+// caller finishes, so bridges run it off their read loop.
+const ToolName = "collect_digits"
+
+const (
+	defaultMaxDigits  = 16
+	defaultTimeout    = 20 * time.Second
+	maxTimeout        = 60 * time.Second
+	defaultInterDigit = 5 * time.Second
+)
+
+func init() {
+	tools.Register(tools.Tool{
+		Name:        ToolName,
+		Description: "Collects digits the caller types on their phone keypad, e.g. a policy number or OTP. Ask the caller to type them before calling this. Returns the digits and why input ended; if complete is false, ask the caller to try again.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"max_digits": map[string]interface{}{
+					"type":        "integer",
+					"description": "Stop after this many digits (default 16).",
+				},
+				"min_digits": map[string]interface{}{
+					"type":        "integer",
+					"description": "Fewer digits than this is incomplete input (default 1).",
+				},
+				"terminator": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"#", "*", ""},
+					"description": "Key that ends the input early; empty for none (default #).",
+				},
+				"timeout_sec": map[string]interface{}{
+					"type":        "integer",
+					"description": "Seconds the caller gets to finish (default 20, at most 60).",
+				},
+			},
+		},
+		Handler:   handleCollectDigits,
+		PhoneOnly: true,
+	})
+}
+
+func handleCollectDigits(ctx context.Context, call tools.Call, args map[string]interface{}) map[string]interface{} {
+	k := lookup(call.CallId)
+	if k == nil {
+		return map[string]interface{}{"error": "keypad input is not available on this call"}
+	}
+
+	rules := Rules{
+		MinDigits:         intArg(args, "min_digits", 1),
+		MaxDigits:         intArg(args, "max_digits", defaultMaxDigits),
+		Terminator:        "#",
+		Timeout:           time.Duration(intArg(args, "timeout_sec", int(defaultTimeout.Seconds()))) * time.Second,
+		InterDigitTimeout: defaultInterDigit,
+	}
+	if t, ok := args["terminator"].(string); ok {
+		rules.Terminator = t
+	}
+	rules.Timeout = min(max(rules.Timeout, time.Second), maxTimeout)
+
+	res, err := k.Collect(ctx, rules)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	logger.InfoContext(ctx, "digits collected", "call_id", call.CallId, "count", len(res.Digits), "reason", res.Reason)
+	return map[string]interface{}{
+		"digits":   res.Digits,
+		"reason":   res.Reason,
+		"complete": len(res.Digits) >= rules.MinDigits && res.Reason != ReasonCancelled,
+	}
+}
+
+func intArg(args map[string]interface{}, key string, def int) int {
+	if n, ok := args[key].(float64); ok && n > 0 {
+		return int(n)
+	}
+	return def
+}
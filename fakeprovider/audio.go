@@ -0,0 +1,46 @@
+package fakeprovider
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// chunk is the length of the audio messages the fakes send
+const chunk = 100 * time.Millisecond
+
+// tone returns ms of a 440Hz tone at rate, so the bridge forwards
+// something audible rather than digital silence.
+func tone(rate int, d time.Duration) []int16 {
+	n := int(int64(rate) * int64(d) / int64(time.Second))
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(8000 * math.Sin(2*math.Pi*440*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// chunks splits d into chunk-sized pieces.
+func chunks(d time.Duration) []time.Duration {
+	var out []time.Duration
+	for d > 0 {
+		c := min(d, chunk)
+		out = append(out, c)
+		d -= c
+	}
+	return out
+}
+
+// estimateTokens approximates text tokens for usage reports.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// words splits text into the pieces a model streams it in: each word
+// with the space after it.
+func words(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.SplitAfter(text, " ")
+}
@@ -0,0 +1,277 @@
+package fakeprovider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+	"github.com/gorilla/websocket"
+)
+
+// geminiProtocol speaks the Gemini Live messages the bridge uses, with
+// 16-bit PCM audio in both directions.
+type geminiProtocol struct{}
+
+const geminiOutputRate = 24000
+
+type geminiMessage struct {
+	Setup *struct {
+		GenerationConfig *struct {
+			ResponseModalities []string `json:"responseModalities"`
+		} `json:"generationConfig"`
+		SystemInstruction *struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"systemInstruction"`
+		Tools []struct {
+			FunctionDeclarations []struct {
+				Name string `json:"name"`
+			} `json:"functionDeclarations"`
+		} `json:"tools"`
+	} `json:"setup"`
+	ClientContent *struct {
+		Turns []struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"turns"`
+		TurnComplete bool `json:"turnComplete"`
+	} `json:"clientContent"`
+	RealtimeInput *struct {
+		ActivityStart *struct{} `json:"activityStart"`
+		ActivityEnd   *struct{} `json:"activityEnd"`
+		Audio         *struct {
+			MimeType string `json:"mimeType"`
+			Data     string `json:"data"`
+		} `json:"audio"`
+	} `json:"realtimeInput"`
+	ToolResponse *struct {
+		FunctionResponses []struct {
+			Name     string                 `json:"name"`
+			ID       string                 `json:"id"`
+			Response map[string]interface{} `json:"response"`
+		} `json:"functionResponses"`
+	} `json:"toolResponse"`
+}
+
+func (geminiProtocol) open(s *session) error {
+	return nil
+}
+
+func (geminiProtocol) handle(s *session, data []byte) {
+	var msg geminiMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		logger.Warn("unparseable client message", "error", err)
+		return
+	}
+
+	switch {
+	case msg.Setup != nil:
+		gc := msg.Setup.GenerationConfig
+		s.text.Store(gc != nil && slices.Equal(gc.ResponseModalities, []string{"TEXT"}))
+		s.update(func(r *Record) {
+			r.SessionConfigured = true
+			r.Instructions = ""
+			if si := msg.Setup.SystemInstruction; si != nil {
+				for _, p := range si.Parts {
+					r.Instructions += p.Text
+				}
+			}
+			r.Tools = r.Tools[:0]
+			for _, t := range msg.Setup.Tools {
+				for _, fd := range t.FunctionDeclarations {
+					r.Tools = append(r.Tools, fd.Name)
+				}
+			}
+		})
+		s.send(map[string]interface{}{"setupComplete": map[string]interface{}{}})
+
+	case msg.ClientContent != nil:
+		s.update(func(r *Record) {
+			for _, turn := range msg.ClientContent.Turns {
+				for _, p := range turn.Parts {
+					if turn.Role == "user" && p.Text != "" {
+						r.UserMessages = append(r.UserMessages, p.Text)
+					}
+				}
+			}
+			if msg.ClientContent.TurnComplete {
+				r.ResponseCreates++
+			}
+		})
+
+	case msg.RealtimeInput != nil && msg.RealtimeInput.ActivityStart != nil:
+		s.update(func(r *Record) { r.ActivityStarts++ })
+
+	case msg.RealtimeInput != nil && msg.RealtimeInput.ActivityEnd != nil:
+		s.update(func(r *Record) { r.ActivityEnds++ })
+
+	case msg.RealtimeInput != nil && msg.RealtimeInput.Audio != nil:
+		pcm, _ := base64.StdEncoding.DecodeString(msg.RealtimeInput.Audio.Data)
+		rate := pcmRate(msg.RealtimeInput.Audio.MimeType)
+		s.update(func(r *Record) {
+			r.CallerAudio += time.Duration(len(pcm)/2) * time.Second / time.Duration(rate)
+		})
+		if s.echo.Load() {
+			geminiProtocol{}.echoAudio(s, msg.RealtimeInput.Audio.Data)
+		}
+
+	case msg.ToolResponse != nil:
+		s.update(func(r *Record) {
+			for _, fr := range msg.ToolResponse.FunctionResponses {
+				r.ToolResults = append(r.ToolResults, ToolResult{CallID: fr.ID, Tool: fr.Name, Output: fr.Response})
+			}
+		})
+	}
+}
+
+// pcmRate reads the rate from a mime type like "audio/pcm;rate=16000";
+// Gemini assumes 16kHz when it is missing.
+func pcmRate(mimeType string) int {
+	_, params, _ := strings.Cut(mimeType, "rate=")
+	if rate, err := strconv.Atoi(params); err == nil && rate > 0 {
+		return rate
+	}
+	return 16000
+}
+
+// lateAudio sends nothing: Gemini drops the rest of a turn once it
+// reports it interrupted, and any later audio starts a new turn.
+func (geminiProtocol) lateAudio(s *session, d time.Duration) error {
+	return nil
+}
+
+func (p geminiProtocol) say(s *session, say Say) error {
+	if s.text.Load() {
+		return p.write(s, say.Text)
+	}
+	for _, d := range chunks(time.Duration(say.AudioMs) * time.Millisecond) {
+		pcm := audio.Int16ToBytes(tone(geminiOutputRate, d))
+		if err := s.send(map[string]interface{}{
+			"serverContent": map[string]interface{}{
+				"modelTurn": map[string]interface{}{
+					"parts": []interface{}{map[string]interface{}{
+						"inlineData": map[string]interface{}{
+							"mimeType": "audio/pcm;rate=24000",
+							"data":     base64.StdEncoding.EncodeToString(pcm),
+						},
+					}},
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if say.Text != "" {
+		if err := s.send(map[string]interface{}{
+			"serverContent": map[string]interface{}{
+				"outputTranscription": map[string]interface{}{"text": say.Text},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	if err := s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{"generationComplete": true},
+	}); err != nil {
+		return err
+	}
+
+	// Native audio bills output at roughly 25 tokens per second
+	textTokens, audioTokens := estimateTokens(say.Text), say.AudioMs/40
+	return s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{"turnComplete": true},
+		"usageMetadata": map[string]interface{}{
+			"responseTokenCount": textTokens + audioTokens,
+			"totalTokenCount":    textTokens + audioTokens,
+			"responseTokensDetails": []interface{}{
+				map[string]interface{}{"modality": "TEXT", "tokenCount": textTokens},
+				map[string]interface{}{"modality": "AUDIO", "tokenCount": audioTokens},
+			},
+		},
+	})
+}
+
+// write sends text as a model turn in a text-only session.
+func (geminiProtocol) write(s *session, text string) error {
+	for _, part := range words(text) {
+		if err := s.send(map[string]interface{}{
+			"serverContent": map[string]interface{}{
+				"modelTurn": map[string]interface{}{
+					"parts": []interface{}{map[string]interface{}{"text": part}},
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	tokens := estimateTokens(text)
+	return s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{"turnComplete": true},
+		"usageMetadata": map[string]interface{}{
+			"responseTokenCount": tokens,
+			"totalTokenCount":    tokens,
+			"responseTokensDetails": []interface{}{
+				map[string]interface{}{"modality": "TEXT", "tokenCount": tokens},
+			},
+		},
+	})
+}
+
+func (geminiProtocol) userSays(s *session, text string) error {
+	return s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{
+			"inputTranscription": map[string]interface{}{"text": text, "finished": true},
+		},
+	})
+}
+
+func (geminiProtocol) interrupt(s *session) error {
+	return s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{"interrupted": true},
+	})
+}
+
+func (geminiProtocol) toolCall(s *session, id string, call ToolCall) error {
+	return s.send(map[string]interface{}{
+		"toolCall": map[string]interface{}{
+			"functionCalls": []interface{}{map[string]interface{}{
+				"id":   id,
+				"name": call.Name,
+				"args": call.Args,
+			}},
+		},
+	})
+}
+
+// echoAudio sends each echoed message as a complete model turn, so the
+// bridge plays and times every one as a response of its own.
+func (geminiProtocol) echoAudio(s *session, data string) error {
+	return s.send(map[string]interface{}{
+		"serverContent": map[string]interface{}{
+			"modelTurn": map[string]interface{}{
+				"parts": []interface{}{map[string]interface{}{
+					"inlineData": map[string]interface{}{"mimeType": "audio/pcm;rate=24000", "data": data},
+				}},
+			},
+			"turnComplete": true,
+		},
+	})
+}
+
+// sendError closes the session the way Live reports fatal errors: a
+// close frame carrying the message.
+func (geminiProtocol) sendError(s *session, message string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseInternalServerErr, message),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,288 @@
+package fakeprovider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+// openAIProtocol speaks the OpenAI Realtime events the bridge uses,
+// with μ-law 8kHz audio in both directions.
+type openAIProtocol struct{}
+
+type openAIEvent struct {
+	Type       string `json:"type"`
+	Audio      string `json:"audio"`
+	ItemID     string `json:"item_id"`
+	AudioEndMs int    `json:"audio_end_ms"`
+	Session    *struct {
+		Modalities   []string `json:"modalities"`
+		Instructions string   `json:"instructions"`
+		Tools        []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	} `json:"session"`
+	Item *struct {
+		Type    string `json:"type"`
+		Role    string `json:"role"`
+		CallID  string `json:"call_id"`
+		Output  string `json:"output"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"item"`
+}
+
+func (openAIProtocol) open(s *session) error {
+	return s.send(map[string]interface{}{
+		"type":    "session.created",
+		"session": map[string]interface{}{"id": s.nextID("sess")},
+	})
+}
+
+func (openAIProtocol) handle(s *session, data []byte) {
+	var ev openAIEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		logger.Warn("unparseable client event", "error", err)
+		return
+	}
+
+	switch ev.Type {
+	case "session.update":
+		if ev.Session == nil {
+			return
+		}
+		s.text.Store(slices.Equal(ev.Session.Modalities, []string{"text"}))
+		s.update(func(r *Record) {
+			r.SessionConfigured = true
+			r.Instructions = ev.Session.Instructions
+			r.Tools = r.Tools[:0]
+			for _, t := range ev.Session.Tools {
+				r.Tools = append(r.Tools, t.Name)
+			}
+		})
+		s.send(map[string]interface{}{"type": "session.updated", "session": ev.Session})
+
+	case "input_audio_buffer.append":
+		mulaw, _ := base64.StdEncoding.DecodeString(ev.Audio)
+		s.update(func(r *Record) {
+			r.CallerAudio += time.Duration(len(mulaw)) * time.Second / 8000
+		})
+		if s.echo.Load() {
+			openAIProtocol{}.echoAudio(s, ev.Audio)
+		}
+
+	case "conversation.item.truncate":
+		s.update(func(r *Record) {
+			r.Truncations = append(r.Truncations, Truncation{ItemID: ev.ItemID, AudioEndMs: ev.AudioEndMs})
+		})
+		s.send(map[string]interface{}{
+			"type":          "conversation.item.truncated",
+			"item_id":       ev.ItemID,
+			"content_index": 0,
+			"audio_end_ms":  ev.AudioEndMs,
+		})
+
+	case "response.create":
+		s.update(func(r *Record) { r.ResponseCreates++ })
+
+	case "conversation.item.create":
+		if ev.Item != nil && ev.Item.Type == "message" && ev.Item.Role == "user" {
+			s.update(func(r *Record) {
+				for _, c := range ev.Item.Content {
+					r.UserMessages = append(r.UserMessages, c.Text)
+				}
+			})
+			return
+		}
+		if ev.Item == nil || ev.Item.Type != "function_call_output" {
+			return
+		}
+		var output map[string]interface{}
+		json.Unmarshal([]byte(ev.Item.Output), &output)
+		s.update(func(r *Record) {
+			r.ToolResults = append(r.ToolResults, ToolResult{
+				CallID: ev.Item.CallID,
+				Tool:   s.toolNames[ev.Item.CallID],
+				Output: output,
+			})
+		})
+	}
+}
+
+func (p openAIProtocol) say(s *session, say Say) error {
+	id, itemID := s.nextID("resp"), s.nextID("item")
+	s.spoke.response, s.spoke.item = id, itemID
+	if err := s.send(map[string]interface{}{
+		"type":     "response.created",
+		"response": map[string]interface{}{"id": id, "status": "in_progress"},
+	}); err != nil {
+		return err
+	}
+
+	if s.text.Load() {
+		return p.write(s, say.Text)
+	}
+	if err := p.audioDeltas(s, time.Duration(say.AudioMs)*time.Millisecond); err != nil {
+		return err
+	}
+
+	if say.Text != "" {
+		if err := s.send(map[string]interface{}{
+			"type":        "response.audio_transcript.done",
+			"response_id": id,
+			"item_id":     itemID,
+			"transcript":  say.Text,
+		}); err != nil {
+			return err
+		}
+	}
+	// Realtime bills output audio at roughly 20 tokens per second
+	return s.responseDone(id, estimateTokens(say.Text), say.AudioMs/50)
+}
+
+// write sends text as the last say step's reply in a text-only session.
+func (openAIProtocol) write(s *session, text string) error {
+	for _, delta := range words(text) {
+		if err := s.send(map[string]interface{}{
+			"type":        "response.text.delta",
+			"response_id": s.spoke.response,
+			"item_id":     s.spoke.item,
+			"delta":       delta,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := s.send(map[string]interface{}{
+		"type":        "response.text.done",
+		"response_id": s.spoke.response,
+		"item_id":     s.spoke.item,
+		"text":        text,
+	}); err != nil {
+		return err
+	}
+	return s.responseDone(s.spoke.response, estimateTokens(text), 0)
+}
+
+// audioDeltas sends d of audio for the last say step's item.
+func (openAIProtocol) audioDeltas(s *session, d time.Duration) error {
+	for _, chunk := range chunks(d) {
+		mulaw := audio.PCMToMuLaw(audio.Int16ToBytes(tone(8000, chunk)))
+		if err := s.send(map[string]interface{}{
+			"type":        "response.audio.delta",
+			"response_id": s.spoke.response,
+			"item_id":     s.spoke.item,
+			"delta":       base64.StdEncoding.EncodeToString(mulaw),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p openAIProtocol) lateAudio(s *session, d time.Duration) error {
+	return p.audioDeltas(s, d)
+}
+
+func (openAIProtocol) userSays(s *session, text string) error {
+	// After an interrupt the caller is already speaking; this ends that
+	// utterance rather than starting another
+	itemID := s.interrupted
+	s.interrupted = ""
+	events := []map[string]interface{}{}
+	if itemID == "" {
+		itemID = s.nextID("item")
+		events = append(events, map[string]interface{}{"type": "input_audio_buffer.speech_started", "item_id": itemID})
+	}
+	for _, ev := range append(events, []map[string]interface{}{
+		{"type": "input_audio_buffer.speech_stopped", "item_id": itemID},
+		{"type": "input_audio_buffer.committed", "item_id": itemID},
+		{"type": "conversation.item.input_audio_transcription.completed", "item_id": itemID, "transcript": text},
+	}...) {
+		if err := s.send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (openAIProtocol) interrupt(s *session) error {
+	s.interrupted = s.nextID("item")
+	return s.send(map[string]interface{}{
+		"type":    "input_audio_buffer.speech_started",
+		"item_id": s.interrupted,
+	})
+}
+
+func (openAIProtocol) toolCall(s *session, id string, call ToolCall) error {
+	s.srv.mu.Lock()
+	s.toolNames[id] = call.Name
+	s.srv.mu.Unlock()
+
+	respID := s.nextID("resp")
+	if err := s.send(map[string]interface{}{
+		"type":     "response.created",
+		"response": map[string]interface{}{"id": respID, "status": "in_progress"},
+	}); err != nil {
+		return err
+	}
+
+	args, _ := json.Marshal(call.Args)
+	if err := s.send(map[string]interface{}{
+		"type":        "response.function_call_arguments.done",
+		"response_id": respID,
+		"call_id":     id,
+		"name":        call.Name,
+		"arguments":   string(args),
+	}); err != nil {
+		return err
+	}
+	return s.responseDone(respID, estimateTokens(string(args)), 0)
+}
+
+func (openAIProtocol) sendError(s *session, message string) error {
+	return s.send(map[string]interface{}{
+		"type": "error",
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": message,
+		},
+	})
+}
+
+func (openAIProtocol) echoAudio(s *session, data string) error {
+	return s.send(map[string]interface{}{
+		"type":        "response.audio.delta",
+		"response_id": "echo",
+		"item_id":     "echo",
+		"delta":       data,
+	})
+}
+
+// responseDone completes a response with a plausible usage block.
+func (s *session) responseDone(id string, textTokens, audioTokens int) error {
+	return s.send(map[string]interface{}{
+		"type": "response.done",
+		"response": map[string]interface{}{
+			"id":     id,
+			"status": "completed",
+			"usage": map[string]interface{}{
+				"total_tokens":  textTokens + audioTokens,
+				"input_tokens":  0,
+				"output_tokens": textTokens + audioTokens,
+				"input_token_details": map[string]interface{}{
+					"text_tokens":   0,
+					"audio_tokens":  0,
+					"cached_tokens": 0,
+				},
+				"output_token_details": map[string]interface{}{
+					"text_tokens":  textTokens,
+					"audio_tokens": audioTokens,
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/capture"
+)
+
+// replayTail is how long a replay waits for the bridge to hang up after
+// the last captured frame
+const replayTail = 30 * time.Second
+
+// ReplayScenario turns the provider side of a capture into a scenario:
+// after the bridge configures the session, every captured provider frame
+// is sent again with its original spacing. Handshake replies are left to
+// the fake, which sends its own.
+func ReplayScenario(c *capture.Capture) *Scenario {
+	sc := &Scenario{
+		Name:  "replay " + c.Header.CallUUID,
+		Steps: []Step{{WaitFor: WaitSetup}},
+	}
+
+	var last int64 = -1
+	for _, f := range c.From(capture.Provider) {
+		if isHandshake(f.Data) {
+			last = f.AtMs
+			continue
+		}
+		if last >= 0 && f.AtMs > last {
+			sc.Steps = append(sc.Steps, Step{SleepMs: int(f.AtMs - last)})
+		}
+		last = f.AtMs
+		sc.Steps = append(sc.Steps, Step{Raw: f.Data})
+	}
+	// Leave hanging up to the bridge, as in the original call
+	sc.Steps = append(sc.Steps, Step{WaitFor: WaitBridgeClose, TimeoutMs: int(replayTail.Milliseconds())})
+	return sc
+}
+
+func isHandshake(data json.RawMessage) bool {
+	var msg struct {
+		Type          string          `json:"type"`
+		SetupComplete json.RawMessage `json:"setupComplete"`
+	}
+	if json.Unmarshal(data, &msg) != nil {
+		return false
+	}
+	return msg.Type == "session.created" || msg.Type == "session.updated" || msg.SetupComplete != nil
+}
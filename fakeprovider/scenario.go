@@ -0,0 +1,137 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Scenario is a scripted provider session. Steps run in order; each
+// step either waits for the bridge or performs one action.
+//
+//	{"name": "greeting", "steps": [
+//	  {"wait_for": "setup"},
+//	  {"wait_for": "response_create"},
+//	  {"say": {"text": "Good morning, I'm Anika.", "audio_ms": 1500}},
+//	  {"wait_for": "caller_audio", "caller_audio_ms": 1000},
+//	  {"user_says": "My car was hit"},
+//	  {"tool_call": {"name": "validate_vehicle_registration", "args": {"registration": "MH12AB1234"}}},
+//	  {"wait_for": "tool_result", "tool": "validate_vehicle_registration"},
+//	  {"close": true}
+//	]}
+type Scenario struct {
+	Name  string `json:"name"`
+	Steps []Step `json:"steps"`
+}
+
+// Wait conditions for Step.WaitFor
+const (
+	WaitSetup          = "setup"           // session.update / setup received
+	WaitResponseCreate = "response_create" // response.create / clientContent turn received
+	WaitCallerAudio    = "caller_audio"    // CallerAudioMs of caller audio received in total
+	WaitToolResult     = "tool_result"     // result for Tool (or any tool) received
+	WaitBridgeClose    = "bridge_close"    // bridge closed the connection
+)
+
+// Step is one scenario step. Exactly one of the fields below WaitFor is set.
+type Step struct {
+	WaitFor       string `json:"wait_for,omitempty"`
+	CallerAudioMs int    `json:"caller_audio_ms,omitempty"`
+	Tool          string `json:"tool,omitempty"`
+	TimeoutMs     int    `json:"timeout_ms,omitempty"`
+
+	// Say makes the model speak: audio deltas, then the transcript. In a
+	// text-only session it writes Text instead
+	Say *Say `json:"say,omitempty"`
+	// UserSays delivers a transcript of the caller's speech
+	UserSays string `json:"user_says,omitempty"`
+	// Interrupt signals that the caller barged in
+	Interrupt bool `json:"interrupt,omitempty"`
+	// LateAudioMs sends more audio for the last say step, like deltas
+	// still in flight when the caller barged in
+	LateAudioMs int `json:"late_audio_ms,omitempty"`
+	// ToolCall asks the bridge to run a tool
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
+	// Error sends a provider error event
+	Error string `json:"error,omitempty"`
+	// Raw sends a message verbatim, e.g. one taken from a capture
+	Raw json.RawMessage `json:"raw,omitempty"`
+	// EchoMs plays caller audio straight back for that long, so the
+	// round trip through the bridge can be timed frame by frame
+	EchoMs  int `json:"echo_ms,omitempty"`
+	SleepMs int `json:"sleep_ms,omitempty"`
+	// Close ends the session by closing the websocket
+	Close bool `json:"close,omitempty"`
+}
+
+type Say struct {
+	Text    string `json:"text"`
+	AudioMs int    `json:"audio_ms"`
+}
+
+type ToolCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+const defaultWaitTimeout = 10 * time.Second
+
+func (s Step) timeout() time.Duration {
+	if s.TimeoutMs > 0 {
+		return time.Duration(s.TimeoutMs) * time.Millisecond
+	}
+	return defaultWaitTimeout
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// Validate checks that every step does exactly one thing.
+func (sc *Scenario) Validate() error {
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("no steps")
+	}
+	for i, s := range sc.Steps {
+		n := 0
+		for _, set := range []bool{
+			s.WaitFor != "", s.Say != nil, s.UserSays != "", s.Interrupt, s.LateAudioMs > 0,
+			s.ToolCall != nil, s.Error != "", len(s.Raw) > 0, s.EchoMs > 0, s.SleepMs > 0, s.Close,
+		} {
+			if set {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("step %d: want exactly one action, got %d", i+1, n)
+		}
+
+		switch s.WaitFor {
+		case "", WaitSetup, WaitResponseCreate, WaitToolResult, WaitBridgeClose:
+		case WaitCallerAudio:
+			if s.CallerAudioMs <= 0 {
+				return fmt.Errorf("step %d: caller_audio needs caller_audio_ms", i+1)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown wait_for %q", i+1, s.WaitFor)
+		}
+		if s.ToolCall != nil && s.ToolCall.Name == "" {
+			return fmt.Errorf("step %d: tool_call without a name", i+1)
+		}
+	}
+	return nil
+}
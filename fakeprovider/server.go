@@ -0,0 +1,335 @@
+// Package fakeprovider implements scripted stand-ins for the OpenAI
+// Realtime and Gemini Live websocket APIs, covering the subset the
+// bridges use, so a full call can run without network or API keys.
+//
+// Point a bridge at a fake with OPENAI_REALTIME_URL or GEMINI_LIVE_URL.
+package fakeprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/gorilla/websocket"
+)
+
+// Providers
+const (
+	OpenAI = "openai"
+	Gemini = "gemini"
+)
+
+var logger = logging.For("fakeprovider")
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ToolResult is a tool output the bridge sent back.
+type ToolResult struct {
+	CallID string
+	Tool   string
+	Output map[string]interface{}
+}
+
+// Record is what the fake received from the bridge during a session.
+type Record struct {
+	SessionConfigured bool
+	Instructions      string
+	Tools             []string
+	ResponseCreates   int
+	CallerAudio       time.Duration
+	ToolResults       []ToolResult
+	// ActivityStarts and ActivityEnds count client-side turn signals (Gemini only)
+	ActivityStarts int
+	ActivityEnds   int
+	// Truncations are conversation.item.truncate requests (OpenAI only)
+	Truncations []Truncation
+	// UserMessages are typed user turns, as text chat sends them
+	UserMessages []string
+}
+
+// Truncation is an assistant item the bridge cut short after barge-in.
+type Truncation struct {
+	ItemID     string
+	AudioEndMs int
+}
+
+// protocol speaks one provider's wire format.
+type protocol interface {
+	// open runs when the bridge connects
+	open(s *session) error
+	// handle applies one client message to the session
+	handle(s *session, data []byte)
+	say(s *session, say Say) error
+	userSays(s *session, text string) error
+	interrupt(s *session) error
+	// lateAudio sends d more audio for the last say step
+	lateAudio(s *session, d time.Duration) error
+	toolCall(s *session, id string, call ToolCall) error
+	sendError(s *session, message string) error
+	// echoAudio returns one received caller audio message as model audio
+	echoAudio(s *session, data string) error
+}
+
+// Server serves one scenario to every connection; concurrent
+// connections each run their own session.
+type Server struct {
+	Provider string
+	Scenario *Scenario
+
+	proto protocol
+
+	mu     sync.Mutex
+	latest *session
+	err    error
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewServer returns a fake for provider ("openai" or "gemini").
+func NewServer(provider string, sc *Scenario) (*Server, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{Provider: provider, Scenario: sc, done: make(chan struct{})}
+	switch provider {
+	case OpenAI:
+		s.proto = openAIProtocol{}
+	case Gemini:
+		s.proto = geminiProtocol{}
+	default:
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+	return s, nil
+}
+
+// Record returns what the latest session received so far.
+func (srv *Server) Record() Record {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.latest == nil {
+		return Record{}
+	}
+	r := srv.latest.record
+	r.ToolResults = append([]ToolResult(nil), r.ToolResults...)
+	r.Truncations = append([]Truncation(nil), r.Truncations...)
+	r.UserMessages = append([]string(nil), r.UserMessages...)
+	return r
+}
+
+// Done is closed when the first session finishes its scenario.
+func (srv *Server) Done() <-chan struct{} {
+	return srv.done
+}
+
+// Err returns the error that stopped the first session, if any.
+func (srv *Server) Err() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.err
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s := &session{srv: srv, conn: conn, changed: make(chan struct{}), toolNames: map[string]string{}}
+	srv.mu.Lock()
+	srv.latest = s
+	srv.mu.Unlock()
+
+	go s.read()
+
+	err = srv.proto.open(s)
+	if err == nil {
+		err = s.run()
+	}
+	if err != nil {
+		logger.Warn("scenario failed", "provider", srv.Provider, "scenario", srv.Scenario.Name, "error", err)
+	}
+	srv.once.Do(func() {
+		srv.mu.Lock()
+		srv.err = err
+		srv.mu.Unlock()
+		close(srv.done)
+	})
+}
+
+// session is one bridge connection running the scenario.
+type session struct {
+	srv  *Server
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+	seq     int
+
+	// echo sends caller audio straight back as model audio
+	echo atomic.Bool
+	// text is set when the client asked for text replies only, as text
+	// chat does; say steps then write instead of speak
+	text atomic.Bool
+
+	record Record // guarded by srv.mu
+
+	// consumed counts the response creates and tool results already
+	// matched by wait steps
+	responsesSeen int
+	toolsSeen     int
+
+	// interrupted is the caller item an interrupt step started, which the
+	// next user_says step finishes (OpenAI only); touched by run only
+	interrupted string
+
+	// spoke is the response and item of the last say step (OpenAI only);
+	// touched by run only
+	spoke struct{ response, item string }
+
+	// toolNames maps pending tool call IDs to tool names; guarded by srv.mu
+	toolNames map[string]string
+
+	changed chan struct{} // guarded by srv.mu
+	closed  bool
+}
+
+func (s *session) send(v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(v)
+}
+
+func (s *session) nextID(prefix string) string {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.seq++
+	return fmt.Sprintf("%s_%d", prefix, s.seq)
+}
+
+// update changes the record and wakes waiting steps.
+func (s *session) update(f func(r *Record)) {
+	s.srv.mu.Lock()
+	f(&s.record)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.srv.mu.Unlock()
+}
+
+func (s *session) read() {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.srv.mu.Lock()
+			s.closed = true
+			close(s.changed)
+			s.changed = make(chan struct{})
+			s.srv.mu.Unlock()
+			return
+		}
+		s.srv.proto.handle(s, data)
+	}
+}
+
+func (s *session) run() error {
+	for i, step := range s.srv.Scenario.Steps {
+		if err := s.runStep(step); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *session) runStep(step Step) error {
+	proto := s.srv.proto
+	switch {
+	case step.WaitFor != "":
+		return s.wait(step)
+	case step.Say != nil:
+		return proto.say(s, *step.Say)
+	case step.UserSays != "":
+		return proto.userSays(s, step.UserSays)
+	case step.Interrupt:
+		return proto.interrupt(s)
+	case step.LateAudioMs > 0:
+		return proto.lateAudio(s, time.Duration(step.LateAudioMs)*time.Millisecond)
+	case step.ToolCall != nil:
+		return proto.toolCall(s, s.nextID("call"), *step.ToolCall)
+	case step.Error != "":
+		return proto.sendError(s, step.Error)
+	case len(step.Raw) > 0:
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		return s.conn.WriteMessage(websocket.TextMessage, step.Raw)
+	case step.EchoMs > 0:
+		s.echo.Store(true)
+		time.Sleep(time.Duration(step.EchoMs) * time.Millisecond)
+		s.echo.Store(false)
+		return nil
+	case step.SleepMs > 0:
+		time.Sleep(time.Duration(step.SleepMs) * time.Millisecond)
+		return nil
+	case step.Close:
+		s.writeMu.Lock()
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		return s.conn.Close()
+	}
+	return nil
+}
+
+func (s *session) wait(step Step) error {
+	ctx, cancel := context.WithTimeout(context.Background(), step.timeout())
+	defer cancel()
+
+	for {
+		s.srv.mu.Lock()
+		r, closed, changed := s.record, s.closed, s.changed
+		s.srv.mu.Unlock()
+
+		if s.satisfied(step, r) {
+			return nil
+		}
+		if closed {
+			if step.WaitFor == WaitBridgeClose {
+				return nil
+			}
+			return errors.New("bridge closed the connection")
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s", step.WaitFor)
+		}
+	}
+}
+
+func (s *session) satisfied(step Step, r Record) bool {
+	switch step.WaitFor {
+	case WaitSetup:
+		return r.SessionConfigured
+	case WaitResponseCreate:
+		if r.ResponseCreates > s.responsesSeen {
+			s.responsesSeen++
+			return true
+		}
+	case WaitCallerAudio:
+		return r.CallerAudio >= time.Duration(step.CallerAudioMs)*time.Millisecond
+	case WaitToolResult:
+		for ; s.toolsSeen < len(r.ToolResults); s.toolsSeen++ {
+			if step.Tool == "" || r.ToolResults[s.toolsSeen].Tool == step.Tool {
+				s.toolsSeen++
+				return true
+			}
+		}
+	}
+	return false
+}
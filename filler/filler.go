@@ -0,0 +1,154 @@
+// Package filler plays audio to the caller while tool calls run long, so
+// they hear a "let me check that" or hold music instead of dead air.
+package filler
+
+import (
+	"sync"
+	"time"
+)
+
+// Item is the playout item filler audio is queued under.
+const Item = "filler"
+
+// topUp is how much looped audio is kept queued ahead
+const topUp = 500 * time.Millisecond
+
+// Player is the call's outbound audio; a *playout.Player satisfies it.
+type Player interface {
+	Play(item string, mulaw []byte)
+	Drop(item string)
+	Pending() time.Duration
+}
+
+// Config is the filler of one agent.
+type Config struct {
+	Clip  []byte // 8kHz μ-law
+	After time.Duration
+	Loop  bool
+}
+
+// Filler plays the clip once any tool call has run for After, until every
+// running tool call has returned or the model speaks. A nil *Filler (no
+// clip) is valid and plays nothing.
+type Filler struct {
+	player Player
+	cfg    Config
+
+	mu      sync.Mutex
+	running int  // tool calls in progress
+	playing bool // filler is queued or looping
+	gen     int  // bumped on every stop, so stale timers and loops quit
+	timer   *time.Timer
+	closed  bool // the call is over
+}
+
+func New(player Player, cfg Config) *Filler {
+	if len(cfg.Clip) == 0 {
+		return nil
+	}
+	return &Filler{player: player, cfg: cfg}
+}
+
+// Begin marks a tool call as started; call the returned func when it
+// returns.
+func (f *Filler) Begin() (end func()) {
+	if f == nil {
+		return func() {}
+	}
+	f.mu.Lock()
+	f.running++
+	if f.running == 1 && !f.closed {
+		gen := f.gen
+		f.timer = time.AfterFunc(f.cfg.After, func() { f.start(gen) })
+	}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.running--
+			if f.running == 0 {
+				f.stopLocked()
+			}
+		})
+	}
+}
+
+// Stop silences the filler for the tool calls in progress, e.g. because
+// the model started speaking.
+func (f *Filler) Stop() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.stopLocked()
+	f.mu.Unlock()
+}
+
+// Close stops the filler for good when the call ends, before its player
+// closes. Tool calls still running, or started later, play nothing.
+func (f *Filler) Close() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.closed = true
+	f.stopLocked()
+	f.mu.Unlock()
+}
+
+// Playing reports whether filler audio is queued.
+func (f *Filler) Playing() bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.playing
+}
+
+func (f *Filler) stopLocked() {
+	f.gen++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	if f.playing {
+		f.playing = false
+		f.player.Drop(Item)
+	}
+}
+
+func (f *Filler) start(gen int) {
+	f.mu.Lock()
+	if gen != f.gen || f.running == 0 || f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.playing = true
+	f.player.Play(Item, f.cfg.Clip)
+	f.mu.Unlock()
+
+	if f.cfg.Loop {
+		go f.loop(gen)
+	}
+}
+
+// loop keeps the clip queued until the filler stops.
+func (f *Filler) loop(gen int) {
+	t := time.NewTicker(topUp / 2)
+	defer t.Stop()
+	for range t.C {
+		f.mu.Lock()
+		if gen != f.gen {
+			f.mu.Unlock()
+			return
+		}
+		if f.player.Pending() < topUp {
+			f.player.Play(Item, f.cfg.Clip)
+		}
+		f.mu.Unlock()
+	}
+}
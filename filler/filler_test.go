@@ -0,0 +1,204 @@
+package filler
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var clip = make([]byte, 800) // 100ms of 8kHz μ-law
+
+// fakePlayer records what the filler queues.
+type fakePlayer struct {
+	mu      sync.Mutex
+	plays   int
+	drops   int
+	pending time.Duration
+	closed  bool
+	late    int // plays after Close
+}
+
+func (p *fakePlayer) Play(item string, mulaw []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if item != Item {
+		panic("filler played item " + item)
+	}
+	if p.closed {
+		p.late++
+		return
+	}
+	p.plays++
+}
+
+func (p *fakePlayer) Drop(item string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.drops++
+}
+
+func (p *fakePlayer) Pending() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending
+}
+
+func (p *fakePlayer) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+}
+
+func (p *fakePlayer) counts() (plays, drops, late int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.plays, p.drops, p.late
+}
+
+func TestNoClipIsANoOp(t *testing.T) {
+	f := New(&fakePlayer{}, Config{After: time.Millisecond})
+	if f != nil {
+		t.Fatal("New without a clip returned a filler")
+	}
+	f.Begin()()
+	f.Stop()
+	f.Close()
+	if f.Playing() {
+		t.Error("nil filler is playing")
+	}
+}
+
+func TestPlaysAfterDelay(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 100 * time.Millisecond})
+
+	end := f.Begin()
+	time.Sleep(50 * time.Millisecond)
+	if plays, _, _ := p.counts(); plays != 0 || f.Playing() {
+		t.Fatalf("played %d times before After", plays)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if plays, _, _ := p.counts(); plays != 1 || !f.Playing() {
+		t.Fatalf("played %d times after After, want 1", plays)
+	}
+
+	end()
+	end() // a second call is harmless
+	if _, drops, _ := p.counts(); drops != 1 || f.Playing() {
+		t.Errorf("dropped %d times when the tool call returned, want 1", drops)
+	}
+}
+
+func TestQuickToolCallPlaysNothing(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 50 * time.Millisecond})
+
+	f.Begin()()
+	time.Sleep(100 * time.Millisecond)
+	if plays, drops, _ := p.counts(); plays != 0 || drops != 0 {
+		t.Errorf("a quick tool call played %d and dropped %d", plays, drops)
+	}
+}
+
+func TestStopsWhenModelSpeaks(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 20 * time.Millisecond, Loop: true})
+
+	end := f.Begin()
+	time.Sleep(50 * time.Millisecond)
+	f.Stop()
+	plays, drops, _ := p.counts()
+	if plays != 1 || drops != 1 || f.Playing() {
+		t.Fatalf("played %d, dropped %d after the model spoke", plays, drops)
+	}
+
+	// The tool call is still running, but the filler stays quiet
+	time.Sleep(2 * topUp)
+	if again, _, _ := p.counts(); again != plays {
+		t.Errorf("played %d more times after the model spoke", again-plays)
+	}
+	end()
+	if _, again, _ := p.counts(); again != drops {
+		t.Errorf("dropped again when the tool call returned")
+	}
+}
+
+func TestOverlappingToolCalls(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 100 * time.Millisecond})
+
+	// The delay runs from the first call, not the latest
+	endA := f.Begin()
+	time.Sleep(60 * time.Millisecond)
+	endB := f.Begin()
+	time.Sleep(60 * time.Millisecond)
+	if plays, _, _ := p.counts(); plays != 1 {
+		t.Fatalf("played %d times 120ms after the first call, want 1", plays)
+	}
+
+	endA()
+	if _, drops, _ := p.counts(); drops != 0 || !f.Playing() {
+		t.Fatal("stopped while a tool call was still running")
+	}
+	endB()
+	if _, drops, _ := p.counts(); drops != 1 || f.Playing() {
+		t.Errorf("dropped %d times when the last call returned, want 1", drops)
+	}
+}
+
+func TestLoopTopsUp(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 10 * time.Millisecond, Loop: true})
+
+	end := f.Begin()
+	time.Sleep(10*time.Millisecond + 3*topUp/2 + topUp/4)
+	plays, _, _ := p.counts()
+	if plays != 4 {
+		t.Fatalf("played %d times with nothing queued, want the clip and 3 top-ups", plays)
+	}
+
+	// Enough queued: no top-up
+	p.mu.Lock()
+	p.pending = topUp
+	p.mu.Unlock()
+	time.Sleep(topUp)
+	if again, _, _ := p.counts(); again != plays {
+		t.Errorf("topped up %d times with %v queued", again-plays, topUp)
+	}
+
+	p.mu.Lock()
+	p.pending = 0
+	p.mu.Unlock()
+	end()
+	plays, _, _ = p.counts()
+	time.Sleep(topUp)
+	if again, _, _ := p.counts(); again != plays {
+		t.Errorf("topped up %d times after the tool call returned", again-plays)
+	}
+}
+
+func TestCloseEndsPlayback(t *testing.T) {
+	p := &fakePlayer{}
+	f := New(p, Config{Clip: clip, After: 10 * time.Millisecond, Loop: true})
+
+	// A tool call still running when the call ends
+	end := f.Begin()
+	time.Sleep(topUp)
+	f.Close()
+	p.Close()
+	if f.Playing() {
+		t.Error("playing after Close")
+	}
+
+	// The loop stops topping up, and neither the call's return nor a tool
+	// call started later plays to the closed player
+	time.Sleep(2 * topUp)
+	end()
+	f.Begin()()
+	later := f.Begin()
+	time.Sleep(2 * topUp)
+	later()
+	if _, _, late := p.counts(); late != 0 {
+		t.Errorf("played %d times to the closed player", late)
+	}
+}
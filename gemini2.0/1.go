@@ -1,6 +1,6 @@
 package gemini20
 
-import "log"
+import "github.com/AVVKavvk/openai-vobiz/usage"
 
 // Gemini Message Structs
 type GeminiSetup struct {
@@ -20,7 +20,8 @@ type GeminiGenerationConfig struct {
 }
 
 type GeminiSpeechConfig struct {
-	VoiceConfig *GeminiVoiceConfig `json:"voiceConfig,omitempty"`
+	VoiceConfig  *GeminiVoiceConfig `json:"voiceConfig,omitempty"`
+	LanguageCode string             `json:"languageCode,omitempty"`
 }
 
 type GeminiVoiceConfig struct {
@@ -101,11 +102,52 @@ type GeminiServerMessage struct {
 }
 
 type GeminiUsageMetadata struct {
-	TotalTokenCount      int `json:"totalTokenCount,omitempty"`
-	TextCount            int `json:"textCount,omitempty"`
-	ImageCount           int `json:"imageCount,omitempty"`
-	VideoDurationSeconds int `json:"videoDurationSeconds,omitempty"`
-	AudioDurationSeconds int `json:"audioDurationSeconds,omitempty"`
+	PromptTokenCount        int                    `json:"promptTokenCount,omitempty"`
+	CachedContentTokenCount int                    `json:"cachedContentTokenCount,omitempty"`
+	ResponseTokenCount      int                    `json:"responseTokenCount,omitempty"`
+	ToolUsePromptTokenCount int                    `json:"toolUsePromptTokenCount,omitempty"`
+	ThoughtsTokenCount      int                    `json:"thoughtsTokenCount,omitempty"`
+	TotalTokenCount         int                    `json:"totalTokenCount,omitempty"`
+	PromptTokensDetails     []GeminiModalityTokens `json:"promptTokensDetails,omitempty"`
+	CacheTokensDetails      []GeminiModalityTokens `json:"cacheTokensDetails,omitempty"`
+	ResponseTokensDetails   []GeminiModalityTokens `json:"responseTokensDetails,omitempty"`
+}
+
+type GeminiModalityTokens struct {
+	Modality   string `json:"modality"` // TEXT, AUDIO, IMAGE, VIDEO
+	TokenCount int    `json:"tokenCount"`
+}
+
+// Tokens splits the report by modality. Counts without a breakdown are
+// treated as text; thinking and tool-use prompts bill as text.
+func (u *GeminiUsageMetadata) Tokens() usage.Tokens {
+	var t usage.Tokens
+	prompt := split(u.PromptTokensDetails, u.PromptTokenCount)
+	cached := split(u.CacheTokensDetails, u.CachedContentTokenCount)
+	response := split(u.ResponseTokensDetails, u.ResponseTokenCount)
+
+	t.CachedText, t.CachedAudio = cached[0], cached[1]
+	t.InputText = prompt[0] - cached[0] + u.ToolUsePromptTokenCount
+	t.InputAudio = prompt[1] - cached[1]
+	t.OutputText = response[0] + u.ThoughtsTokenCount
+	t.OutputAudio = response[1]
+	return t
+}
+
+// split returns [text, audio] token counts from a modality breakdown.
+func split(details []GeminiModalityTokens, total int) [2]int {
+	if len(details) == 0 {
+		return [2]int{total, 0}
+	}
+	var out [2]int
+	for _, d := range details {
+		if d.Modality == "AUDIO" {
+			out[1] += d.TokenCount
+		} else {
+			out[0] += d.TokenCount
+		}
+	}
+	return out
 }
 
 type GeminiGoAway struct {
@@ -155,6 +197,12 @@ type VobizInboundMessage struct {
 	StreamID string      `json:"streamSid,omitempty"`
 	Start    *VobizStart `json:"start,omitempty"`
 	Media    *VobizMedia `json:"media,omitempty"`
+	DTMF     *VobizDTMF  `json:"dtmf,omitempty"`
+}
+
+// VobizDTMF is a keypad press reported on the media stream.
+type VobizDTMF struct {
+	Digit string `json:"digit"`
 }
 
 type VobizStart struct {
@@ -169,11 +217,6 @@ type VobizMedia struct {
 	SampleRate  int    `json:"sampleRate,omitempty"`
 }
 
-type VobizOutboundMessage struct {
-	Event string      `json:"event"`
-	Media *VobizMedia `json:"media,omitempty"`
-}
-
 type RealtimeInputConfig struct {
 	AutomaticActivityDetection *AutomaticActivityDetection `json:"automaticActivityDetection,omitempty"`
 }
@@ -190,120 +233,6 @@ type AudioTranscriptionConfig struct {
 	// Empty struct - no fields needed according to API docs
 }
 
-// μ-law to PCM conversion
-var muLawTable = buildMuLawTable()
-
-func buildMuLawTable() [256]int16 {
-	var table [256]int16
-	for i := 0; i < 256; i++ {
-		mulaw := byte(i)
-		sign := (mulaw & 0x80)
-		exponent := (mulaw >> 4) & 0x07
-		mantissa := mulaw & 0x0F
-
-		sample := int16(mantissa)<<3 + 0x84
-		sample <<= exponent
-		sample -= 0x84
-
-		if sign != 0 {
-			sample = -sample
-		}
-		table[i] = sample
-	}
-	return table
-}
-
-func muLawToPCM(mulaw []byte) []byte {
-	pcm := make([]byte, len(mulaw)*2)
-	for i, mu := range mulaw {
-		sample := muLawTable[mu]
-		pcm[i*2] = byte(sample & 0xFF)
-		pcm[i*2+1] = byte((sample >> 8) & 0xFF)
-	}
-	return pcm
-}
-
-// Convert byte slice to int16 slice
-func bytesToInt16(data []byte) []int16 {
-	samples := make([]int16, len(data)/2)
-	for i := 0; i < len(samples); i++ {
-		samples[i] = int16(data[i*2]) | int16(data[i*2+1])<<8
-	}
-	return samples
-}
-func upsample8to24(pcm8k []byte) []byte {
-	samples8k := len(pcm8k) / 2
-	pcm24k := make([]byte, samples8k*3*2) // Triple the samples (8kHz -> 24kHz)
-
-	for i := 0; i < samples8k; i++ {
-		// Get original sample (16-bit little-endian)
-		sample := int16(pcm8k[i*2]) | int16(pcm8k[i*2+1])<<8
-
-		// Write sample three times (simple repeat, not interpolation)
-		for j := 0; j < 3; j++ {
-			offset := (i*3 + j) * 2
-			pcm24k[offset] = byte(sample & 0xFF)
-			pcm24k[offset+1] = byte((sample >> 8) & 0xFF)
-		}
-	}
-
-	return pcm24k
-}
-
-// Convert int16 slice to byte slice
-func int16ToBytes(samples []int16) []byte {
-	data := make([]byte, len(samples)*2)
-	for i, sample := range samples {
-		data[i*2] = byte(sample)
-		data[i*2+1] = byte(sample >> 8)
-	}
-	return data
-}
-
-func resample24to8(input []int16) []int16 {
-	output := make([]int16, len(input)/3) // Downsample by 3x
-	for i := 0; i < len(output); i++ {
-		output[i] = input[i*3]
-	}
-	return output
-}
-
-// PCM to μ-law conversion (for Gemini -> Vobiz)
-func pcmToMuLaw(pcm []byte) []byte {
-	mulaw := make([]byte, len(pcm)/2)
-	for i := 0; i < len(pcm); i += 2 {
-		sample := int16(pcm[i]) | int16(pcm[i+1])<<8
-		mulaw[i/2] = linearToMuLaw(sample)
-	}
-	return mulaw
-}
-
-func linearToMuLaw(sample int16) byte {
-	const BIAS = 0x84
-	const CLIP = 32635
-
-	sign := byte(0)
-	if sample < 0 {
-		sample = -sample
-		sign = 0x80
-	}
-
-	if sample > CLIP {
-		sample = CLIP
-	}
-	sample += BIAS
-
-	exponent := byte(7)
-	for mask := int16(0x4000); (sample&mask) == 0 && exponent > 0; exponent-- {
-		mask >>= 1
-	}
-
-	mantissa := byte((sample >> (exponent + 3)) & 0x0F)
-	mulaw := ^(sign | (exponent << 4) | mantissa)
-
-	return mulaw
-}
-
 // Tool functions
 func getCustomerInfo() map[string]interface{} {
 	return map[string]interface{}{
@@ -312,9 +241,3 @@ func getCustomerInfo() map[string]interface{} {
 		"address": "123 Main St, Mumbai, India",
 	}
 }
-
-func callEnd(callId string) error {
-	log.Printf("🔴 Ending call: %s", callId)
-	// Implement actual call termination logic here
-	return nil
-}
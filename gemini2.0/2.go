@@ -1,20 +1,43 @@
 package gemini20
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/amd"
+	"github.com/AVVKavvk/openai-vobiz/audio"
+	"github.com/AVVKavvk/openai-vobiz/bargein"
+	"github.com/AVVKavvk/openai-vobiz/capture"
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/dtmf"
+	"github.com/AVVKavvk/openai-vobiz/filler"
+	"github.com/AVVKavvk/openai-vobiz/inactivity"
+	"github.com/AVVKavvk/openai-vobiz/langdetect"
+	"github.com/AVVKavvk/openai-vobiz/limits"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/metrics"
 	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/playout"
 	"github.com/AVVKavvk/openai-vobiz/rabbitmq"
-	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/AVVKavvk/openai-vobiz/tools"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/AVVKavvk/openai-vobiz/usage"
+	"github.com/AVVKavvk/openai-vobiz/vad"
+	"github.com/AVVKavvk/openai-vobiz/vobiz"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var upgrader = websocket.Upgrader{
@@ -23,20 +46,93 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-var (
-	modelSpeaking   = false
-	modelSpeakingMu sync.Mutex
-)
+// GeminiLiveURL is the Live API websocket endpoint; the key is appended as a query parameter
+const GeminiLiveURL = "wss://generativelanguage.googleapis.com/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent"
+
+var logger = logging.For("bridge")
+
+// lockedConn serialises writes to a websocket shared by goroutines.
+type lockedConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *lockedConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
 
 func HandleWebSocketStreamGoogleAI(c echo.Context) error {
 	var GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
-	var callId string
+	// Set by Vobiz's start event; the Gemini reader, tools and timers
+	// read it from their own goroutines
+	var callIdValue atomic.Value
+	callId := func() string {
+		id, _ := callIdValue.Load().(string)
+		return id
+	}
 
 	from := c.QueryParam("from")
 	to := c.QueryParam("to")
 	uuid := c.QueryParam("calluuid")
 
-	log.Printf("WS Connection for Call %s: From %s to %s", uuid, from, to)
+	// Every line for this call carries call_id, stream_id and provider;
+	// media frames arrive every 20ms so their logs are sampled
+	streamID := &logging.Lazy{}
+	callLog := logging.ForCall(uuid, "gemini", streamID)
+	audioLog := logging.Sampled(callLog, 50)
+
+	// Root span for the whole call; every other request for this CallUUID joins its trace
+	callCtx, callSpan := tracing.StartCall(c.Request().Context(), uuid, attribute.String("provider", "gemini"))
+	defer callSpan.End()
+	callLog.InfoContext(callCtx, "stream connecting", "from", from, "to", to)
+
+	// Render the agent prompt for this call before touching any socket
+	persona := agent.Get(c.QueryParam("agent"))
+	callSpan.SetAttributes(attribute.String("agent", persona.Name))
+	vars := agent.NewCallVars(uuid, from, to, c.QueryParam("body_data"), getCustomerInfo())
+	instructions, err := persona.RenderInstructions(vars)
+	if err != nil {
+		callLog.ErrorContext(callCtx, "failed to render instructions", "agent", persona.Name, "error", err)
+		return err
+	}
+	greeting, err := persona.RenderGreeting(vars)
+	if err != nil {
+		callLog.ErrorContext(callCtx, "failed to render greeting", "agent", persona.Name, "error", err)
+		return err
+	}
+	// The call starts in the agent's first language (or the campaign's) and
+	// follows the caller's once it is detected
+	lang := persona.StartLanguage(vars)
+	speech := langdetect.NewSession(lang.Code, persona.LanguageCodes())
+	if len(persona.Languages) > 0 {
+		instructions += "\n\n" + lang.Instruction()
+	}
+
+	// Model, voice and tuning come from the catalogue, the agent, the
+	// campaign and the outbound request
+	requested, err := catalog.ParseSelection(c.QueryParam("session"))
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring requested session settings", "error", err)
+	}
+	settings, err := persona.SessionSettings(catalog.Gemini, lang, vars, requested)
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring invalid session settings", "error", err)
+	}
+	geminiModel := "models/" + settings.Model
+	callSpan.SetAttributes(attribute.String("model", settings.Model), attribute.String("voice", settings.Voice))
+
+	// Outbound calls may ask to be screened for answering machines first
+	policy, err := amd.ParsePolicy(c.QueryParam("amd"), vars)
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring machine detection policy", "error", err)
+	}
+	var screen *amd.Detector
+	if policy != nil {
+		screen = amd.NewDetector(uuid, amd.Config{})
+		defer screen.Close()
+	}
 
 	// 1. Upgrade Vobiz Connection
 	vobizWs, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
@@ -44,61 +140,122 @@ func HandleWebSocketStreamGoogleAI(c echo.Context) error {
 		return err
 	}
 	defer vobizWs.Close()
-	log.Println("✅ Vobiz Connected")
+	callLog.InfoContext(callCtx, "vobiz connected", "agent", persona.Name)
+
+	// With CAPTURE_DIR set, every received frame is written out for replay
+	rec := capture.Start(capture.Header{CallUUID: uuid, Provider: "gemini", Query: c.Request().URL.RawQuery})
+	defer rec.Close()
+
+	// Model audio is paced out in 20ms frames; from here on every write
+	// to Vobiz goes through the player
+	player := playout.New(vobizWs, func(err error) {
+		audioLog.WarnContext(callCtx, "failed to write to vobiz", "error", err)
+	})
+	defer func() {
+		callLog.InfoContext(callCtx, "playout closed", "played_ms", player.Total().Milliseconds(), "underruns", player.Underruns())
+		player.Close()
+	}()
+
+	// Slow tool calls get the agent's filler audio instead of dead air
+	fillerClip, fillerAfter, fillerLoop := persona.FillerAudio()
+	fill := filler.New(player, filler.Config{Clip: fillerClip, After: fillerAfter, Loop: fillerLoop})
+	defer fill.Close()
+
+	// Announce the end of the call for post-call processing, however the stream ends
+	startedAt := time.Now().UTC()
+	outcome := "disconnected"
+	meter := usage.NewMeter("gemini", geminiModel)
+	limitCfg := persona.LimitsConfig()
+	limit := limits.New(limits.Config{
+		MaxDuration:   limitCfg.MaxDuration(),
+		WrapUpAt:      limitCfg.WrapUpAt(),
+		MaxCostUSD:    limitCfg.MaxCostUSD,
+		WrapUpCostUSD: limitCfg.WrapUpCostUSD(),
+	}, func() float64 { return meter.Snapshot().EstimatedCostUSD })
+	metrics.ActiveCalls.WithLabelValues("gemini").Inc()
+	defer func() {
+		limit.Stop()
+		disposition := limit.Reason()
+		if disposition == "" {
+			disposition = screen.Disposition()
+		}
+		if disposition != "" {
+			outcome = disposition
+		}
+		metrics.ActiveCalls.WithLabelValues("gemini").Dec()
+		metrics.CallsEnded.WithLabelValues("gemini", outcome).Inc()
+		metrics.CallDuration.WithLabelValues("gemini").Observe(time.Since(startedAt).Seconds())
+
+		endedId := callId()
+		if endedId == "" {
+			endedId = uuid
+		}
+		callUsage := meter.Snapshot()
+		callLog.InfoContext(callCtx, "call usage", "model", callUsage.Model, "estimated_cost_usd", callUsage.EstimatedCostUSD,
+			"input_audio_seconds", callUsage.InputAudioSeconds, "output_audio_seconds", callUsage.OutputAudioSeconds)
+		rabbitmq.PublishCallEnded(callCtx, models.CallModel{
+			CallId:      endedId,
+			Agent:       persona.Name,
+			Provider:    "gemini",
+			Channel:     models.ChannelVoice,
+			Language:    speech.Language(),
+			From:        from,
+			To:          to,
+			ClaimRef:    vars.ClaimRef,
+			AnsweredBy:  screen.Result(),
+			Disposition: disposition,
+			StartedAt:   startedAt,
+			EndedAt:     time.Now().UTC(),
+			Usage:       &callUsage,
+		})
+	}()
 
 	// 2. Connect to Gemini Live API
-	geminiURL := fmt.Sprintf(
-		"wss://generativelanguage.googleapis.com/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent?key=%s",
-		GeminiAPIKey,
-	)
+	// GEMINI_LIVE_URL points the bridge at another endpoint, e.g. the fake provider in tests
+	liveURL := GeminiLiveURL
+	if u := os.Getenv("GEMINI_LIVE_URL"); u != "" {
+		liveURL = u
+	}
+	geminiURL := liveURL + "?key=" + url.QueryEscape(GeminiAPIKey)
 
 	header := http.Header{}
-	geminiWs, _, err := websocket.DefaultDialer.Dial(geminiURL, header)
+	conn, _, err := websocket.DefaultDialer.Dial(geminiURL, header)
 	if err != nil {
-		log.Printf("❌ Failed to connect to Gemini: %v", err)
+		callLog.ErrorContext(callCtx, "failed to connect to provider", "error", err)
+		metrics.ProviderErrors.WithLabelValues("gemini", "dial").Inc()
+		outcome = "provider_failed"
 		return err
 	}
+	// Tool responses and caller audio are written from different goroutines
+	geminiWs := &lockedConn{Conn: conn}
 	defer geminiWs.Close()
-	log.Println("✅ Gemini Connected via Google AI API")
+
+	// With GEMINI_ACTIVITY_DETECTION=client Gemini's own activity detection
+	// is off and the bridge marks the caller's turns itself
+	manualActivity := os.Getenv("GEMINI_ACTIVITY_DETECTION") == "client"
+	callLog.InfoContext(callCtx, "provider connected")
 
 	// 3. Configure Session
 	setupMsg := GeminiClientMessage{
 		Setup: &GeminiSetup{
-			Model: "models/gemini-2.5-flash-native-audio-preview-12-2025",
+			Model: geminiModel,
 			GenerationConfig: &GeminiGenerationConfig{
 				ResponseModalities: []string{"AUDIO"},
 				SpeechConfig: &GeminiSpeechConfig{
 					VoiceConfig: &GeminiVoiceConfig{
 						PrebuiltVoiceConfig: &GeminiPrebuiltVoiceConfig{
-							VoiceName: "Puck",
+							VoiceName: settings.Voice,
 						},
 					},
+					LanguageCode: lang.Locale,
 				},
-				Temperature: 0.8,
+				Temperature: *settings.Temperature,
 				TopP:        0.95,
 			},
 			SystemInstruction: &GeminiContent{
 				Parts: []GeminiPart{
 					{
-						Text: `You are Anika, a claims support agent at KIWI Insurance. You are empathetic, efficient, and reassuring.
-
-IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika from KIWI Insurance. How can I help you today?"
-
-### CORE POLICIES:
-1. ZERO-REPETITION: Never repeat customer details. Use "Recorded" or "I have that noted" and move on.
-2. ONE QUESTION AT A TIME: Keep responses short and focused.
-3. SAFETY FIRST: Always confirm safety before data collection.
-
-### FUNCTION CALLING PROTOCOLS:
-- **get_customer_info**: Call this immediately if the user asks "What information do you have on me?" or if you need to verify their identity/address to proceed with the claim.
-- **call_end**: Trigger this tool ONLY when:
-    a) The customer says goodbye or indicates they want to hang up.
-    b) You have provided the Claim Reference Number (#123098) and confirmed the WhatsApp link was sent.
-    c) The user confirms they have no further questions.
-    Always say a brief, professional closing (e.g., "Take care, goodbye") before the tool executes.
-
-### FNOL STEPS:
-1. Confirm Safety. 2. Build Reassurance. 3. Vehicle Reg (MH/KA/DL etc.). 4. Relationship to Policy. 5. Incident Narration (What/Where/When). 6. Fill Gaps. 7. Police/FIR (if injuries). 8. Closing & Reference Number.`,
+						Text: fmt.Sprintf("IMMEDIATELY greet the caller when the call starts by saying: %q\n\n%s", greeting, instructions),
 					},
 				},
 			},
@@ -111,11 +268,11 @@ IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika f
 			// ✅ ADD THIS - Configure voice activity detection
 			RealtimeInputConfig: &RealtimeInputConfig{
 				AutomaticActivityDetection: &AutomaticActivityDetection{
-					Disabled:                 false,
-					StartOfSpeechSensitivity: "START_SENSITIVITY_HIGH", // Keep this
-					PrefixPaddingMs:          300,                      // Increase from 200
-					EndOfSpeechSensitivity:   "END_SENSITIVITY_HIGH",   // Change from LOW to HIGH
-					SilenceDurationMs:        500,                      // Increase from
+					Disabled:                 manualActivity,
+					StartOfSpeechSensitivity: startSensitivity(settings),
+					PrefixPaddingMs:          300,                    // Increase from 200
+					EndOfSpeechSensitivity:   "END_SENSITIVITY_HIGH", // Change from LOW to HIGH
+					SilenceDurationMs:        500,                    // Increase from
 				},
 			},
 
@@ -126,14 +283,8 @@ IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika f
 							Name:        "call_end",
 							Description: "Ends the current phone call immediately. Trigger this when the conversation is finished or the user wants to hang up.",
 							Parameters: map[string]interface{}{
-								"type": "object",
-								"properties": map[string]interface{}{
-									"callId": map[string]interface{}{
-										"type":        "string",
-										"description": "The unique identifier for the call session.",
-									},
-								},
-								"required": []string{"callId"},
+								"type":       "object",
+								"properties": map[string]interface{}{},
 							},
 						},
 						{
@@ -150,235 +301,378 @@ IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika f
 		},
 	}
 
+	// Tools from the shared registry are declared after the built-in ones
+	toolCall := tools.Call{CallId: uuid, From: from, To: to, ClaimRef: vars.ClaimRef}
+	for _, t := range tools.List() {
+		// Settings are fixed at setup, so there is nothing to adjust
+		if t.Name == catalog.ToolName {
+			continue
+		}
+		setupMsg.Setup.Tools[0].FunctionDeclarations = append(setupMsg.Setup.Tools[0].FunctionDeclarations, GeminiFunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		})
+	}
+
 	if err := geminiWs.WriteJSON(setupMsg); err != nil {
-		log.Println("❌ Error sending setup message:", err)
+		callLog.ErrorContext(callCtx, "failed to send setup message", "error", err)
 		return err
 	}
-	// ADD THIS DEBUG LOG
-	setupJSON, _ := json.MarshalIndent(setupMsg, "", "  ")
-	log.Printf("📋 Setup message sent:\n%s", string(setupJSON))
-	log.Println("📤 Session configuration sent")
-	log.Println("📤 Session configuration sent")
+	callLog.DebugContext(callCtx, "session configuration sent", "model", setupMsg.Setup.Model, "tools", len(setupMsg.Setup.Tools[0].FunctionDeclarations))
 
 	done := make(chan struct{})
+	// switchLanguage tells the model the caller's language. The Live API
+	// fixes the voice and speech language at setup, so only the model's
+	// replies change.
+	switchLanguage := func(l agent.Language) {
+		callLog.InfoContext(callCtx, "caller language detected", "language", l.Code)
+		err := geminiWs.WriteJSON(GeminiClientMessage{
+			ClientContent: &GeminiClientContent{
+				Turns: []GeminiContent{{Role: "user", Parts: []GeminiPart{{Text: "[The caller speaks " + l.Name + ". " + l.Instruction() + "]"}}}},
+			},
+		})
+		if err != nil {
+			callLog.WarnContext(callCtx, "failed to switch language", "language", l.Code, "error", err)
+			return
+		}
+		rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{
+			Role:    "Event",
+			Content: "Caller speaks " + l.Name + ", AI switched to " + l.Name,
+			CallId:  callId(),
+		})
+	}
+
+	// The Live API fixes voice, temperature and activity detection at
+	// setup; the call is registered so a change is refused with the reason
+	// rather than as an unknown call
+	unregister := catalog.Register(uuid, catalog.Gemini, settings, func(catalog.Settings) error {
+		return fmt.Errorf("gemini: session settings are fixed when the call connects")
+	})
+	defer unregister()
+
 	setupComplete := make(chan bool, 1)
 
 	userInputBuffer := ""
+	aiOutputBuffer := ""
+
+	// Model turns are numbered so the player can tell them apart
+	turnSeq := 0
+	turnID := ""
+
+	// Gemini has no end-of-speech event, so response latency is measured
+	// from the last caller frame with audio content (unix nanos, 0 = none)
+	// to the first frame of the model's turn reaching Vobiz
+	var lastCallerAudio atomic.Int64
+	player.OnItemStart(func(item string) {
+		if item == filler.Item {
+			return
+		}
+		if t := lastCallerAudio.Swap(0); t != 0 {
+			metrics.ResponseLatency.WithLabelValues("gemini").Observe(time.Since(time.Unix(0, t)).Seconds())
+		}
+	})
+
+	// Running tool calls by ID, so Gemini can cancel them
+	var asyncTools sync.Map // context.CancelFunc
+
+	// sendText adds a bracketed instruction to the conversation; the model
+	// replies only when turnComplete is set
+	sendText := func(text string, turnComplete bool) error {
+		return geminiWs.WriteJSON(GeminiClientMessage{
+			ClientContent: &GeminiClientContent{
+				Turns:        []GeminiContent{{Role: "user", Parts: []GeminiPart{{Text: "[" + text + "]"}}}},
+				TurnComplete: turnComplete,
+			},
+		})
+	}
+	publishEvent := func(content string) {
+		rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{Role: "Event", Content: content, CallId: callId()})
+	}
+	// sayGoodbye has the model speak closing, waits until the caller has
+	// heard it and hangs up
+	sayGoodbye := func(closing string) {
+		if err := sendText(fmt.Sprintf("End the call now by saying: %q", closing), true); err != nil {
+			callLog.WarnContext(callCtx, "failed to send closing line", "error", err)
+		}
+		player.Drain(10 * time.Second)
+		if err := vobiz.Hangup(callCtx, callId()); err != nil {
+			callLog.WarnContext(callCtx, "failed to hang up", "error", err)
+		}
+	}
+
+	// A silent caller is asked if they are still there, then hung up on
+	inactive := persona.InactivityConfig()
+	repromptAfter, hangupAfter := inactive.Timers()
+	idle := inactivity.Start(inactivity.Config{RepromptAfter: repromptAfter, HangupAfter: hangupAfter}, inactivity.Actions{
+		Reprompt: func(silence time.Duration) {
+			callLog.InfoContext(callCtx, "caller silent, reprompting", "silence_ms", silence.Milliseconds())
+			publishEvent(fmt.Sprintf("Caller silent for %.0fs, AI reprompted", silence.Seconds()))
+			if err := sendText(inactive.Reprompt, true); err != nil {
+				callLog.WarnContext(callCtx, "failed to send reprompt", "error", err)
+			}
+		},
+		Hangup: func(silence time.Duration) {
+			callLog.InfoContext(callCtx, "caller silent after reprompt, hanging up", "silence_ms", silence.Milliseconds())
+			publishEvent(fmt.Sprintf("Caller silent for %.0fs after the reprompt, call ended", silence.Seconds()))
+			sayGoodbye(inactive.Closing)
+		},
+	})
+	defer idle.Stop()
 
 	// --- Goroutine A: Gemini -> Vobiz (Speaking) ---
 	go func() {
 		defer close(done)
+
+		// One span per model turn; tool calls nest under it
+		turnCtx := callCtx
+		var turnSpan trace.Span
+		endTurn := func(reason string) {
+			if turnSpan != nil {
+				turnSpan.SetAttributes(attribute.String("turn.end_reason", reason))
+				turnSpan.End()
+				turnCtx, turnSpan = callCtx, nil
+			}
+		}
+		defer endTurn("closed")
+
+		// Transcriptions arrive in fragments; publish each side once complete
+		flushUser := func() {
+			if userInputBuffer == "" {
+				return
+			}
+			callLog.DebugContext(callCtx, "transcript", "role", "User", "chars", len(userInputBuffer))
+			rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{
+				Role:    "User",
+				Content: userInputBuffer,
+				CallId:  callId(),
+			})
+			if code := speech.Observe(userInputBuffer, ""); code != "" {
+				switchLanguage(persona.Language(code))
+			}
+			userInputBuffer = ""
+		}
+		flushAI := func(interrupted bool) {
+			if aiOutputBuffer == "" {
+				return
+			}
+			callLog.DebugContext(turnCtx, "transcript", "role", "AI", "chars", len(aiOutputBuffer), "interrupted", interrupted)
+			rabbitmq.RabbitMQProducerWithContext(turnCtx, models.TranscriptModel{
+				Role:        "AI",
+				Content:     aiOutputBuffer,
+				CallId:      callId(),
+				Interrupted: interrupted,
+			})
+			aiOutputBuffer = ""
+		}
+		addInput := func(t *GeminiTranscription) {
+			if t == nil {
+				return
+			}
+			if t.Text != "" {
+				// Gemini transcribes speech the local VAD may miss
+				idle.CallerSpoke()
+			}
+			userInputBuffer += t.Text
+			if t.Finished {
+				flushUser()
+			}
+		}
+		defer func() {
+			flushUser()
+			flushAI(false)
+		}()
+
+		// inTurn is set from the first audio of a model turn until it ends
+		inTurn := false
+
 		for {
 			_, rawMsg, err := geminiWs.ReadMessage()
 			if err != nil {
-				log.Println("❌ Error reading from Gemini:", err)
+				callLog.WarnContext(callCtx, "provider read failed", "error", err)
+				metrics.ProviderErrors.WithLabelValues("gemini", "read").Inc()
 				return
 			}
-			// ✅ ADD THIS - Log EVERY message from Gemini
-			log.Printf("📥 Raw Gemini message: %s", string(rawMsg))
-
+			rec.Provider(rawMsg)
 			var msg GeminiServerMessage
 			if err := json.Unmarshal(rawMsg, &msg); err != nil {
-				log.Printf("❌ Error parsing Gemini message: %v", err)
+				callLog.WarnContext(callCtx, "failed to parse provider message", "error", err)
 				continue
 			}
 
 			if msg.SetupComplete != nil {
-				log.Println("✅ Setup complete, sending greeting trigger")
+				callLog.InfoContext(callCtx, "setup complete")
 				setupComplete <- true
 				continue
 			}
 
 			if msg.ServerContent != nil {
+				addInput(msg.ServerContent.InputTranscription)
+				if t := msg.ServerContent.OutputTranscription; t != nil {
+					aiOutputBuffer += t.Text
+				}
+
 				if msg.ServerContent.Interrupted {
-					log.Println("🎤 User interrupted - Clearing Vobiz buffer")
-					err = vobizWs.WriteJSON(VobizOutboundMessage{Event: "clearAudio"})
-					if err != nil {
-						log.Printf("❌ Error clearing Vobiz buffer: %v", err)
+					fill.Stop()
+					cut := player.Clear()
+					callLog.DebugContext(turnCtx, "caller interrupted, cleared playout", "turn", cut.Item, "played_ms", cut.Played.Milliseconds())
+					inTurn = false
+					heardPart := cut.Item != "" && cut.Item != filler.Item && cut.Played < cut.Total
+					flushAI(heardPart)
+					if heardPart {
+						metrics.Interruptions.WithLabelValues("gemini").Inc()
+						rabbitmq.RabbitMQProducerWithContext(turnCtx, models.TranscriptModel{
+							Role:    "Event",
+							Content: fmt.Sprintf("AI interrupted by the caller after %.1fs of %.1fs", cut.Played.Seconds(), cut.Total.Seconds()),
+							CallId:  callId(),
+						})
 					}
-					modelSpeaking = false
+					endTurn("interrupted")
 				}
 
 				if msg.ServerContent.ModelTurn != nil {
-					modelSpeakingMu.Lock()
-					if !modelSpeaking {
-						modelSpeaking = true
-						log.Println("🗣️ Model started speaking")
+					if !inTurn {
+						// The caller's turn is over once the model answers
+						flushUser()
+						inTurn = true
+						turnSeq++
+						turnID = fmt.Sprintf("turn-%d", turnSeq)
+						turnCtx, turnSpan = tracing.Tracer().Start(callCtx, "model.turn")
+						callLog.DebugContext(turnCtx, "model started speaking")
 					}
-					modelSpeakingMu.Unlock()
 					for _, part := range msg.ServerContent.ModelTurn.Parts {
 						// Skip thought parts
 						if part.Thought {
-							log.Printf("💭 AI thinking: %s", part.Text)
 							continue
 						}
 
 						if part.InlineData != nil {
-							// Decode base64 audio
-							// Decode base64
 							pcm24k, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
 							if err != nil {
 								continue
 							}
 
-							// Convert bytes to int16
-							pcmSamples24k := bytesToInt16(pcm24k)
-
-							// Downsample 24kHz → 8kHz
-							pcmSamples8k := resample24to8(pcmSamples24k)
-
-							// Convert int16 to bytes for pcmToMuLaw
-							pcmBytes8k := int16ToBytes(pcmSamples8k)
-
-							// Convert to μ-law
-							mulaw := pcmToMuLaw(pcmBytes8k)
-
-							// Encode to base64
-							mulawBase64 := base64.StdEncoding.EncodeToString(mulaw)
-
-							payload := VobizOutboundMessage{
-								Event: "playAudio",
-								Media: &VobizMedia{
-									ContentType: "audio/x-mulaw",
-									SampleRate:  8000,
-									Payload:     mulawBase64,
-								},
-							}
-							if err := vobizWs.WriteJSON(payload); err != nil {
-								log.Printf("❌ Error sending audio to Vobiz: %v", err)
-							} else {
-								log.Printf("🔊 Sent %d bytes of audio to Vobiz", len(mulaw))
-							}
+							// Downsample 24kHz → 8kHz and convert to μ-law
+							mulaw := audio.PCMToMuLaw(audio.Downsample24to8(pcm24k))
+							fill.Stop()
+							player.Play(turnID, mulaw)
+							audioLog.DebugContext(turnCtx, "model audio queued", "bytes", len(mulaw))
+							metrics.CountAudio("gemini", metrics.Outbound, len(mulaw))
+							meter.AddMuLaw(metrics.Outbound, len(mulaw))
 						}
 
+						// Text parts only come with a TEXT response modality
 						if part.Text != "" {
-							log.Printf("🤖 AI says: %s", part.Text)
-							if msg.ServerContent.TurnComplete {
-								trans := models.TranscriptModel{
-									Role:    "AI",
-									Content: part.Text,
-									CallId:  callId,
-								}
-								rabbitmq.RabbitMQProducer(trans)
-							}
+							aiOutputBuffer += part.Text
 						}
 					}
 				}
 
 				if msg.ServerContent.TurnComplete {
-					log.Println("✅ Model turn complete")
-					modelSpeakingMu.Lock()
-					modelSpeaking = false
-					modelSpeakingMu.Unlock()
+					callLog.DebugContext(turnCtx, "model turn complete")
+					inTurn = false
+					flushAI(false)
+					endTurn("complete")
 
 				}
-				if msg.ServerContent.OutputTranscription != nil {
-					log.Printf("🤖 AI transcription: %s", msg.ServerContent.OutputTranscription.Text)
-				}
-				if msg.ServerContent.OutputTranscription != nil {
-					log.Printf("👤 User speaking: %s", msg.ServerContent.InputTranscription.Text)
-				}
-				if msg.ServerContent.Interrupted {
-					log.Println("🎤 User interrupted - Clearing Vobiz buffer")
-					err = vobizWs.WriteJSON(VobizOutboundMessage{Event: "clearAudio"})
-					if err != nil {
-						log.Printf("❌ Error clearing Vobiz buffer: %v", err)
-					}
-					modelSpeakingMu.Lock()
-					modelSpeaking = false
-					modelSpeakingMu.Unlock()
-				}
-
 				if msg.ServerContent.GenerationComplete {
-					log.Println("✅ Generation complete")
-					modelSpeakingMu.Lock()
-					modelSpeaking = false
-					modelSpeakingMu.Unlock()
-					log.Println("🎧 Model finished generating - listening mode")
+					callLog.DebugContext(turnCtx, "generation complete, listening")
 				}
 			}
 
-			if msg.InputTranscription != nil {
-				if msg.InputTranscription.Text != "" {
-					userInputBuffer += msg.InputTranscription.Text
-					log.Printf("👤 User speaking: %s", msg.InputTranscription.Text)
-				}
-				if msg.InputTranscription.Finished {
-					log.Printf("👤 USER said (complete): %s", userInputBuffer)
-					trans := models.TranscriptModel{
-						Role:    "User",
-						Content: userInputBuffer,
-						CallId:  callId,
-					}
-					rabbitmq.RabbitMQProducer(trans)
-					userInputBuffer = ""
-				}
-			}
-
-			if msg.OutputTranscription != nil && msg.OutputTranscription.Text != "" {
-				log.Printf("🤖 AI Old transcription: %s", msg.OutputTranscription.Text)
+			// Older API versions send transcriptions at the top level
+			addInput(msg.InputTranscription)
+			if msg.OutputTranscription != nil {
+				aiOutputBuffer += msg.OutputTranscription.Text
 			}
 
 			if msg.ToolCall != nil {
 				for _, fnCall := range msg.ToolCall.FunctionCalls {
-					log.Printf("🛠️ Tool Call: %s (ID: %s) with args: %v", fnCall.Name, fnCall.ID, fnCall.Args)
-
-					var toolOutput map[string]interface{}
-
-					if fnCall.Name == "get_customer_info" {
-						toolOutput = getCustomerInfo()
-					} else if fnCall.Name == "call_end" {
-						targetID, _ := fnCall.Args["callId"].(string)
-						if targetID == "" {
-							targetID = callId
-						}
-
-						err := callEnd(targetID)
-						if err != nil {
-							toolOutput = map[string]interface{}{"error": err.Error()}
+					callLog.InfoContext(turnCtx, "tool call", "tool", fnCall.Name, "tool_call_id", fnCall.ID)
+					callLog.DebugContext(turnCtx, "tool arguments", "tool", fnCall.Name, "args", fnCall.Args)
+
+					// Tools run off the read loop so model audio keeps flowing; a
+					// slow one gets filler audio. Gemini may cancel them by ID.
+					ctx, cancel := context.WithCancel(turnCtx)
+					asyncTools.Store(fnCall.ID, cancel)
+					go func() {
+						defer asyncTools.Delete(fnCall.ID)
+						defer cancel()
+						defer fill.Begin()()
+
+						var toolOutput map[string]interface{}
+						toolStart := time.Now()
+						toolCtx, toolSpan := tracing.Tracer().Start(ctx, "tool "+fnCall.Name, trace.WithAttributes(
+							attribute.String("tool.name", fnCall.Name),
+							attribute.String("tool.call_id", fnCall.ID),
+						))
+
+						if fnCall.Name == "get_customer_info" {
+							toolOutput = getCustomerInfo()
+						} else if fnCall.Name == "call_end" {
+							// Only ever this stream's own call, never one the model names
+							err := vobiz.Hangup(toolCtx, callId())
+							if err != nil {
+								toolOutput = map[string]interface{}{"error": err.Error()}
+							} else {
+								toolOutput = map[string]interface{}{"status": "call_terminated"}
+							}
 						} else {
-							toolOutput = map[string]interface{}{"status": "call_terminated"}
+							toolOutput = tools.Execute(toolCtx, toolCall, fnCall.Name, fnCall.Args)
 						}
-					}
-
-					responseMsg := GeminiClientMessage{
-						ToolResponse: &GeminiToolResponse{
-							FunctionResponses: []GeminiFunctionResponse{
-								{
-									Name:     fnCall.Name,
-									ID:       fnCall.ID,
-									Response: toolOutput,
+						_, failed := toolOutput["error"]
+						metrics.ObserveTool(fnCall.Name, toolStart, failed)
+						if failed {
+							callLog.WarnContext(toolCtx, "tool failed", "tool", fnCall.Name, "result", toolOutput)
+							toolSpan.SetStatus(codes.Error, "tool returned an error")
+						}
+						toolSpan.End()
+
+						responseMsg := GeminiClientMessage{
+							ToolResponse: &GeminiToolResponse{
+								FunctionResponses: []GeminiFunctionResponse{
+									{
+										Name:     fnCall.Name,
+										ID:       fnCall.ID,
+										Response: toolOutput,
+									},
 								},
 							},
-						},
-					}
+						}
 
-					if err := geminiWs.WriteJSON(responseMsg); err != nil {
-						log.Printf("❌ Error sending tool response: %v", err)
-					} else {
-						log.Printf("✅ Tool response sent for %s", fnCall.Name)
-					}
+						if ctx.Err() != nil {
+							// Cancelled by Gemini, which expects no response
+							return
+						}
+						if err := geminiWs.WriteJSON(responseMsg); err != nil {
+							callLog.ErrorContext(ctx, "failed to send tool response", "tool", fnCall.Name, "error", err)
+						}
+					}()
 				}
 			}
 
 			if msg.ToolCallCancellation != nil {
-				log.Printf("🚫 Tool calls cancelled: %v", msg.ToolCallCancellation.IDs)
+				callLog.InfoContext(turnCtx, "tool calls cancelled", "tool_call_ids", msg.ToolCallCancellation.IDs)
+				for _, id := range msg.ToolCallCancellation.IDs {
+					if cancel, ok := asyncTools.LoadAndDelete(id); ok {
+						cancel.(context.CancelFunc)()
+					}
+				}
 			}
 			if msg.UsageMetadata != nil {
-				log.Printf("User Metadata: %v", msg.UsageMetadata)
+				meter.Add(msg.UsageMetadata.Tokens())
+				callLog.DebugContext(turnCtx, "usage metadata", "total_tokens", msg.UsageMetadata.TotalTokenCount)
 			}
 			if msg.GoAway != nil {
-				log.Printf("👋 User disconnected: %v", msg.GoAway)
+				callLog.WarnContext(callCtx, "provider going away", "go_away", msg.GoAway)
 			}
 			if msg.SessionResumptionUpdate != nil {
-				log.Printf("Session Resumption Update: %v", msg.SessionResumptionUpdate)
+				callLog.DebugContext(callCtx, "session resumption update", "update", msg.SessionResumptionUpdate)
 			}
 		}
 	}()
 
-	// Wait for setup to complete before processing audio
-	select {
-	case <-setupComplete:
+	greet := func() {
 		// Send greeting trigger
 		greeting := GeminiClientMessage{
 			ClientContent: &GeminiClientContent{
@@ -395,66 +689,180 @@ IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika f
 		}
 
 		if err := geminiWs.WriteJSON(greeting); err != nil {
-			log.Printf("❌ Error sending greeting trigger: %v", err)
+			callLog.ErrorContext(callCtx, "failed to send greeting trigger", "error", err)
 		} else {
-			log.Println("📤 Greeting trigger sent successfully")
+			callLog.DebugContext(callCtx, "greeting triggered")
+		}
+	}
+
+	// Wait for setup to complete before processing audio
+	select {
+	case <-setupComplete:
+		// A screened call is greeted once a person is known to be there
+		if screen.Done() {
+			greet()
 		}
 	case <-time.After(5 * time.Second):
-		log.Println("⚠️ Timeout waiting for setup complete")
+		callLog.ErrorContext(callCtx, "timed out waiting for setup complete")
+		metrics.ProviderErrors.WithLabelValues("gemini", "setup_timeout").Inc()
+		outcome = "provider_failed"
 		return fmt.Errorf("setup timeout")
 	}
 
 	// --- Goroutine B: Vobiz -> Gemini (Listening) ---
+	guard := bargein.New(player, bargein.DefaultConfig)
+	sendActivity := func(input *GeminiRealtimeInput) {
+		if err := geminiWs.WriteJSON(GeminiClientMessage{RealtimeInput: input}); err != nil {
+			callLog.WarnContext(callCtx, "failed to send activity signal", "error", err)
+		}
+	}
+
+	// Keypad presses outside collect_digits reach the model as text
+	keypad := dtmf.NewKeypad(uuid, func(digits string) {
+		shown := digits
+		if !persona.RecordKeypadDigits {
+			shown = dtmf.Mask(digits)
+		}
+		publishEvent("Caller pressed " + shown + " on the keypad")
+		if err := sendText("Caller pressed "+digits+" on the keypad", true); err != nil {
+			callLog.WarnContext(callCtx, "failed to forward keypad input", "error", err)
+		}
+	})
+	defer keypad.Close()
+	var tones dtmf.Detector
+
+	// Near a limit the model is told to wrap up; at it the call ends
+	limit.Start(limits.Actions{
+		WrapUp: func(reason string) {
+			callLog.InfoContext(callCtx, "call limit near, wrapping up", "limit", reason)
+			publishEvent("Call nearing its " + limits.Describe(reason) + ", AI asked to wrap up")
+			if err := sendText(limitCfg.WrapUp, false); err != nil {
+				callLog.WarnContext(callCtx, "failed to send wrap-up instruction", "error", err)
+			}
+		},
+		End: func(reason string) {
+			callLog.InfoContext(callCtx, "call limit reached, hanging up", "limit", reason, "estimated_cost_usd", meter.Snapshot().EstimatedCostUSD)
+			idle.Stop()
+			publishEvent("Call reached its " + limits.Describe(reason) + ", call ended")
+			sayGoodbye(limitCfg.Closing)
+		},
+	})
+
+	// While screening, caller audio goes to the detector instead of the
+	// model; a person gets the greeting, a machine the voicemail message
+	// or a hangup
+	hangUpOnMachine := func(disposition, message string) {
+		idle.Stop()
+		screen.SetDisposition(disposition)
+		if message != "" {
+			sayGoodbye(message)
+			return
+		}
+		if err := vobiz.Hangup(callCtx, callId()); err != nil {
+			callLog.WarnContext(callCtx, "failed to hang up", "error", err)
+		}
+	}
+	onScreen := func(ev amd.Event) {
+		switch ev {
+		case amd.Answered:
+			result := screen.Result()
+			callLog.InfoContext(callCtx, "call answered", "answered_by", result, "reason", screen.Reason())
+			publishEvent("Call answered by " + result)
+			switch {
+			case result != amd.Machine:
+				greet()
+			case policy.Action == amd.ActionHangup:
+				publishEvent("Answering machine, call ended")
+				go hangUpOnMachine(models.DispositionMachine, "")
+			}
+		case amd.MessageReady:
+			message, err := policy.RenderMessage(vars)
+			if err != nil {
+				callLog.ErrorContext(callCtx, "failed to render voicemail message", "error", err)
+				publishEvent("Answering machine, call ended")
+				go hangUpOnMachine(models.DispositionMachine, "")
+				return
+			}
+			callLog.InfoContext(callCtx, "leaving voicemail")
+			publishEvent("Answering machine, AI left a voicemail")
+			go hangUpOnMachine(models.DispositionVoicemail, message)
+		}
+	}
+
 	for {
 		var msg VobizInboundMessage
-		err = vobizWs.ReadJSON(&msg)
+		_, rawMsg, err := vobizWs.ReadMessage()
 		if err != nil {
-			transcripts := redisClient.GetAllTranscript(callId)
-			for _, transcript := range transcripts {
-				fmt.Printf("Role: %s \t", transcript.Role)
-				fmt.Printf("Content: %s \t", transcript.Content)
-				fmt.Printf("CallId: %s \n", transcript.CallId)
-			}
-
-			log.Println("🛑 Vobiz connection closed:", err)
+			callLog.InfoContext(callCtx, "vobiz connection closed", "error", err)
 			break
 		}
+		rec.Vobiz(rawMsg)
+		if err := json.Unmarshal(rawMsg, &msg); err != nil {
+			callLog.WarnContext(callCtx, "failed to parse vobiz message", "error", err)
+			continue
+		}
 
 		switch msg.Event {
 		case "start":
-			callId = msg.Start.CallId
-			log.Printf("📞 Call Started (SID: %s) (CallID: %s)", msg.Start.StreamId, msg.Start.CallId)
+			callIdValue.Store(msg.Start.CallId)
+			streamID.Set(msg.Start.StreamId)
+			callLog.InfoContext(callCtx, "call started", "vobiz_call_id", msg.Start.CallId)
 
 		case "media":
 			if msg.Media.Payload != "" {
-				modelSpeakingMu.Lock()
-				isSpeaking := modelSpeaking
-				modelSpeakingMu.Unlock()
+				mulawData, _ := base64.StdEncoding.DecodeString(msg.Media.Payload)
 
-				if isSpeaking {
-					// Skip sending audio while AI is speaking
-					continue
-				}
+				pcm8k := audio.MuLawToPCM(mulawData)
 
-				mulawData, _ := base64.StdEncoding.DecodeString(msg.Media.Payload)
-				log.Printf("📥 Received %d bytes of μ-law from Vobiz", len(mulawData))
-
-				pcm8k := muLawToPCM(mulawData)
-				log.Printf("🔄 Converted to %d bytes of PCM 8kHz", len(pcm8k))
-
-				// Verify PCM has actual audio data (not silence)
-				hasAudio := false
-				for i := 0; i < len(pcm8k) && i < 100; i += 2 {
-					sample := int16(pcm8k[i]) | int16(pcm8k[i+1])<<8
-					if sample > 100 || sample < -100 {
-						hasAudio = true
-						break
+				// Caller audio stays open while the model talks; the guard
+				// tells the caller's voice from echo of our own playout
+				now := time.Now()
+				pcm := audio.BytesToInt16(pcm8k)
+				if key, ok := tones.Process(pcm); ok && keypad.Press(key, dtmf.SourceInBand) {
+					idle.CallerSpoke()
+				}
+				hasAudio, ev := guard.Frame(pcm, now)
+				if !screen.Done() {
+					// The detector hears the caller, the model silence
+					onScreen(screen.Frame(pcm, guard.Speaking()))
+					hasAudio, ev = false, vad.None
+					pcm8k = make([]byte, len(pcm8k))
+					idle.Hold()
+				}
+				if hasAudio {
+					idle.CallerSpoke()
+					lastCallerAudio.Store(now.UnixNano())
+					metrics.CallerSpeechSeconds.WithLabelValues("gemini").Add(playout.FrameDuration.Seconds())
+				}
+				modelAudible := player.Busy()
+				if modelAudible || keypad.Collecting() {
+					idle.Hold()
+				}
+				switch ev {
+				case vad.Start:
+					metrics.CallerUtterances.WithLabelValues("gemini").Inc()
+					callLog.DebugContext(callCtx, "caller speech started", "model_audible", modelAudible)
+					if manualActivity {
+						if modelAudible {
+							// Gemini drops its reply on activityStart; stop playing it now
+							fill.Stop()
+							cut := player.Clear()
+							callLog.DebugContext(callCtx, "caller barged in, cleared playout", "turn", cut.Item, "played_ms", cut.Played.Milliseconds())
+						}
+						sendActivity(&GeminiRealtimeInput{ActivityStart: struct{}{}})
 					}
+				case vad.End:
+					callLog.DebugContext(callCtx, "caller speech ended")
+					if manualActivity {
+						sendActivity(&GeminiRealtimeInput{ActivityEnd: struct{}{}})
+					}
+				}
+				if modelAudible && !hasAudio && !guard.Speaking() {
+					// Echo of the model must not reach Gemini's activity detection
+					pcm8k = make([]byte, len(pcm8k))
 				}
-				log.Printf("🎵 Audio content detected: %v", hasAudio)
 
-				pcm24k := upsample8to24(pcm8k)
-				log.Printf("⬆️ Upsampled to %d bytes of PCM 24kHz", len(pcm24k))
+				pcm24k := audio.Upsample8to24(pcm8k)
 
 				pcmBase64 := base64.StdEncoding.EncodeToString(pcm24k)
 
@@ -468,17 +876,35 @@ IMMEDIATELY greet the caller when the call starts by saying: "Hello, I'm Anika f
 				}
 
 				if err := geminiWs.WriteJSON(realtimeMsg); err != nil {
-					log.Printf("❌ Error sending audio to Gemini: %v", err)
+					audioLog.WarnContext(callCtx, "failed to send audio to provider", "error", err)
+					metrics.ProviderErrors.WithLabelValues("gemini", "write").Inc()
 				} else {
-					log.Printf("🎤 Sent %d bytes of PCM audio (24kHz) to Gemini", len(pcm24k))
+					metrics.CountAudio("gemini", metrics.Inbound, len(mulawData))
+					meter.AddMuLaw(metrics.Inbound, len(mulawData))
+					audioLog.DebugContext(callCtx, "caller audio forwarded", "bytes", len(mulawData), "has_audio", hasAudio)
 				}
 			}
 
+		case "dtmf":
+			if msg.DTMF != nil && msg.DTMF.Digit != "" && keypad.Press(msg.DTMF.Digit[0], dtmf.SourceVobiz) {
+				idle.CallerSpoke()
+			}
+
 		case "stop":
-			log.Println("🛑 Stream Stopped by Vobiz")
+			callLog.InfoContext(callCtx, "stream stopped by vobiz")
+			outcome = "stopped"
 			return nil
 		}
 	}
 
 	return nil
 }
+
+// startSensitivity maps a VAD threshold to Gemini's two start-of-speech
+// sensitivities; unset keeps the high sensitivity calls have always used.
+func startSensitivity(s catalog.Settings) string {
+	if s.VADThreshold != nil && *s.VADThreshold > 0.5 {
+		return "START_SENSITIVITY_LOW"
+	}
+	return "START_SENSITIVITY_HIGH"
+}
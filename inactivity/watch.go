@@ -0,0 +1,117 @@
+// Package inactivity times how long the caller has been silent and
+// triggers a reprompt and then a hangup, per the agent's settings.
+package inactivity
+
+import (
+	"sync"
+	"time"
+)
+
+// tick is how often the silence is checked
+const tick = 250 * time.Millisecond
+
+// Config sets the timers; a zero RepromptAfter disables them.
+type Config struct {
+	// RepromptAfter is the silence before the model checks in
+	RepromptAfter time.Duration
+	// HangupAfter is the further silence after the reprompt before hanging up
+	HangupAfter time.Duration
+}
+
+// Actions are run from the watch goroutine with how long the caller has
+// been silent.
+type Actions struct {
+	Reprompt func(silence time.Duration)
+	Hangup   func(silence time.Duration)
+}
+
+// Watch is the inactivity timer of one call. A nil *Watch (timers
+// disabled) is valid and does nothing.
+type Watch struct {
+	cfg     Config
+	actions Actions
+
+	mu         sync.Mutex
+	last       time.Time // last caller speech or model audio
+	quietSince time.Time // last caller speech
+	reprompted bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Start begins timing; the clock starts now.
+func Start(cfg Config, actions Actions) *Watch {
+	if cfg.RepromptAfter <= 0 {
+		return nil
+	}
+	now := time.Now()
+	w := &Watch{cfg: cfg, actions: actions, last: now, quietSince: now, stop: make(chan struct{})}
+	go w.run()
+	return w
+}
+
+// CallerSpoke resets both timers.
+func (w *Watch) CallerSpoke() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.last = time.Now()
+	w.quietSince = w.last
+	w.reprompted = false
+	w.mu.Unlock()
+}
+
+// Hold restarts the clock without undoing a reprompt, for while the
+// model is talking: silence only counts once it has finished.
+func (w *Watch) Hold() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.last = time.Now()
+	w.mu.Unlock()
+}
+
+// Stop ends the timers.
+func (w *Watch) Stop() {
+	if w == nil {
+		return
+	}
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *Watch) run() {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		}
+
+		now := time.Now()
+		w.mu.Lock()
+		idle, silence := now.Sub(w.last), now.Sub(w.quietSince)
+		reprompt := !w.reprompted && idle >= w.cfg.RepromptAfter
+		hangup := w.reprompted && idle >= w.cfg.HangupAfter
+		if reprompt {
+			w.reprompted = true
+			w.last = now
+		}
+		w.mu.Unlock()
+
+		switch {
+		case reprompt && w.actions.Reprompt != nil:
+			w.actions.Reprompt(silence)
+		case hangup:
+			w.Stop()
+			if w.actions.Hangup != nil {
+				w.actions.Hangup(silence)
+			}
+			return
+		}
+	}
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -14,16 +14,36 @@ func HandleIncomingCall(c echo.Context) error {
 	callUUID := c.FormValue("CallUUID")
 	from := c.FormValue("From")
 	to := c.FormValue("To")
+	// Campaign variables forwarded by HandleOutboundCall on the answer URL
+	bodyData := c.QueryParam("body_data")
 
-	// Log them for debugging
-	log.Printf("Call Received - UUID: %s, From: %s, To: %s", callUUID, from, to)
+	logger.InfoContext(c.Request().Context(), "call received", "call_id", callUUID, "from", from, "to", to)
 
 	// 2. Construct the WebSocket URL
 	host := c.Request().Host
 	baseURL := fmt.Sprintf("wss://%s/stream", host)
 
 	// You can manually append the specific params to the WebSocket URL
-	fullURL := fmt.Sprintf("%s?calluuid=%s&from=%s&to=%s", baseURL, callUUID, from, to)
+	params := url.Values{}
+	params.Set("calluuid", callUUID)
+	params.Set("from", from)
+	params.Set("to", to)
+	if bodyData != "" {
+		params.Set("body_data", bodyData)
+	}
+	// Agent picked by HandleOutboundCall, or set on the inbound answer URL
+	if name := c.QueryParam("agent"); name != "" {
+		params.Set("agent", name)
+	}
+	// Answering machine policy forwarded by HandleOutboundCall
+	if policy := c.QueryParam("amd"); policy != "" {
+		params.Set("amd", policy)
+	}
+	// Session settings of this outbound call
+	if session := c.QueryParam("session"); session != "" {
+		params.Set("session", session)
+	}
+	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
 
 	// 3. Escape & for XML
 	finalURLForXML := strings.ReplaceAll(fullURL, "&", "&amp;")
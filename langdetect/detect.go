@@ -0,0 +1,128 @@
+// Package langdetect identifies the language a caller speaks from the
+// transcript of what they said. The built-in detector tells the languages
+// of our callers apart by script and common words; a better one can be
+// plugged in with Use.
+package langdetect
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Detector identifies the language of a piece of transcript.
+type Detector interface {
+	// Detect returns an ISO 639-1 code and a confidence between 0 and 1,
+	// or "" when it cannot tell.
+	Detect(text string) (code string, confidence float64)
+}
+
+// DetectorFunc adapts a function to Detector.
+type DetectorFunc func(text string) (string, float64)
+
+func (f DetectorFunc) Detect(text string) (string, float64) { return f(text) }
+
+var (
+	mu      sync.RWMutex
+	current Detector = Script{}
+)
+
+// Use replaces the detector used by Detect; nil restores Script.
+func Use(d Detector) {
+	if d == nil {
+		d = Script{}
+	}
+	mu.Lock()
+	current = d
+	mu.Unlock()
+}
+
+// Detect runs the current detector.
+func Detect(text string) (string, float64) {
+	mu.RLock()
+	d := current
+	mu.RUnlock()
+	return d.Detect(text)
+}
+
+// Script detects English, Hindi, Marathi and Kannada. Kannada has its own
+// script; Hindi and Marathi share Devanagari and are told apart by common
+// words, as is Hindi transcribed in Latin letters from English.
+type Script struct{}
+
+// Marker words, lowercase
+var (
+	hindiWords   = words("है हैं था थी नहीं मैं मुझे मेरा मेरी आप क्या हाँ हां और में को से के लिए कहाँ कब गया गई गयी हुआ हुई हूँ हूं रहा रही")
+	marathiWords = words("आहे आहेत होता होती नाही मी मला माझा माझी तुम्ही काय हो आणि मध्ये ला ने साठी कुठे केव्हा")
+	// Romanised Hindi as English transcription tends to render it
+	hinglishWords = words("hai hain nahi nahin kya mera meri mujhe aap haan haa ji theek thik kaha kahan bhai accha acha " +
+		"ka ki ke gaya gayi hua hui tha thi raha rahi mein hoon hun kuch abhi kaise kyun")
+)
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+func (Script) Detect(text string) (string, float64) {
+	var latin, devanagari, kannada, letters int
+	for _, r := range text {
+		switch {
+		case r >= 0x0900 && r <= 0x097F:
+			devanagari++
+		case r >= 0x0C80 && r <= 0x0CFF:
+			kannada++
+		case unicode.In(r, unicode.Latin):
+			latin++
+		default:
+			continue
+		}
+		letters++
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || r == '।'
+	})
+	count := func(set map[string]bool) int {
+		n := 0
+		for _, w := range fields {
+			if set[w] {
+				n++
+			}
+		}
+		return n
+	}
+
+	switch {
+	case kannada > devanagari && kannada > latin:
+		return "kn", float64(kannada) / float64(letters)
+
+	case devanagari >= latin:
+		share := float64(devanagari) / float64(letters)
+		hi, mr := count(hindiWords), count(marathiWords)
+		switch {
+		case mr > hi:
+			return "mr", share * float64(mr) / float64(hi+mr)
+		case hi > mr:
+			return "hi", share * float64(hi) / float64(hi+mr)
+		}
+		// Devanagari without a telling word is more likely Hindi
+		return "hi", share * 0.5
+
+	default:
+		share := float64(latin) / float64(letters)
+		// Hindi words may be in either script when English words are mixed in
+		if n := count(hinglishWords) + count(hindiWords); n > 0 {
+			// One word in a long English sentence is a loanword
+			return "hi", share * min(1, 2*float64(n)/float64(len(fields)))
+		}
+		// "Hello" or "okay" opens a call in any language
+		return "en", share * min(1, float64(len(fields))/3)
+	}
+}
@@ -0,0 +1,62 @@
+package langdetect
+
+import "testing"
+
+func TestScriptDetect(t *testing.T) {
+	tests := []struct {
+		text      string
+		code      string
+		confident bool // at least MinConfidence
+	}{
+		// Devanagari
+		{"मेरा नाम राहुल है", "hi", true},
+		{"मेरी गाड़ी का एक्सीडेंट हो गया है", "hi", true},
+		{"माझी गाडी अपघातात सापडली आहे", "mr", true},
+		{"मला मदत हवी आहे", "mr", true},
+		// Shared by Hindi and Marathi
+		{"नमस्ते", "hi", false},
+		{"ನನ್ನ ಕಾರು ಅಪಘಾತವಾಗಿದೆ", "kn", true},
+
+		// Hindi in Latin letters
+		{"meri gaadi ka accident ho gaya hai", "hi", true},
+		{"mera naam rahul hai", "hi", true},
+		{"haan ji", "hi", true},
+		{"kya aap meri madad kar sakte hain", "hi", true},
+
+		// English
+		{"I had an accident with my car this morning", "en", true},
+		{"Yes, that is correct", "en", true},
+		{"okay yes", "en", true},
+		// Opens a call in any language
+		{"hello", "en", false},
+		// A loanword in an English sentence
+		{"I think there is no problem with the car hai", "hi", false},
+
+		// Mixed scripts
+		{"मेरी car का accident हो गया है", "hi", false},
+		{"मेरा claim number KW 123 है", "hi", false},
+		{"accident हुआ", "hi", true},
+		{"hello नमस्ते", "hi", false},
+
+		{"123 456", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		code, confidence := Script{}.Detect(tt.text)
+		if code != tt.code || (confidence >= MinConfidence) != tt.confident {
+			t.Errorf("Detect(%q) = %s %.2f, want %s confident=%v", tt.text, code, confidence, tt.code, tt.confident)
+		}
+	}
+}
+
+func TestUse(t *testing.T) {
+	defer Use(nil)
+	Use(DetectorFunc(func(string) (string, float64) { return "ta", 0.9 }))
+	if code, _ := Detect("मेरा नाम राहुल है"); code != "ta" {
+		t.Errorf("Detect used %s, want the plugged-in detector", code)
+	}
+	Use(nil)
+	if code, _ := Detect("मेरा नाम राहुल है"); code != "hi" {
+		t.Errorf("Detect after Use(nil) = %s, want Script's hi", code)
+	}
+}
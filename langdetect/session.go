@@ -0,0 +1,68 @@
+package langdetect
+
+import "sync"
+
+// MinConfidence is the confidence a detection needs to switch language.
+const MinConfidence = 0.6
+
+// maxTries is how many caller utterances are checked before the call
+// stays in its starting language
+const maxTries = 3
+
+// Session follows one call: the caller's first utterances are checked
+// until one confidently names a supported language, which the call then
+// stays in.
+type Session struct {
+	supported map[string]bool
+
+	mu       sync.Mutex
+	language string
+	done     bool
+	tries    int
+}
+
+// NewSession starts a call in start; only the supported languages are
+// switched to. With fewer than two supported there is nothing to detect
+// and Observe does nothing.
+func NewSession(start string, supported []string) *Session {
+	s := &Session{supported: map[string]bool{}, language: start}
+	for _, code := range supported {
+		s.supported[code] = true
+	}
+	s.done = len(s.supported) < 2
+	return s
+}
+
+// Observe checks one caller utterance. hint is the language the provider's
+// transcription reported, if any, and is trusted over the detector. It
+// returns the language to switch to, or "" to stay.
+func (s *Session) Observe(text, hint string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done || text == "" {
+		return ""
+	}
+	s.tries++
+	s.done = s.tries >= maxTries
+
+	code, confidence := hint, 1.0
+	if code == "" {
+		code, confidence = Detect(text)
+	}
+	if !s.supported[code] || confidence < MinConfidence {
+		return ""
+	}
+	s.done = true
+	if code == s.language {
+		return ""
+	}
+	s.language = code
+	return code
+}
+
+// Language returns the call's current language.
+func (s *Session) Language() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.language
+}
@@ -0,0 +1,82 @@
+package langdetect
+
+import "testing"
+
+// utterance is a caller turn and what Observe should return for it.
+type utterance struct {
+	text, hint string
+	want       string
+}
+
+func TestSession(t *testing.T) {
+	const (
+		hindi   = "meri gaadi ka accident ho gaya hai"
+		english = "I had an accident with my car this morning"
+		marathi = "माझी गाडी अपघातात सापडली आहे"
+	)
+	tests := []struct {
+		name      string
+		start     string
+		supported []string
+		turns     []utterance
+		language  string
+	}{
+		{"switches on a confident utterance", "en", []string{"en", "hi"}, []utterance{
+			{hindi, "", "hi"},
+			// and stays there
+			{english, "", ""},
+		}, "hi"},
+		{"confirms the starting language", "en", []string{"en", "hi"}, []utterance{
+			{english, "", ""},
+			{hindi, "", ""},
+		}, "en"},
+		{"keeps trying while unsure", "en", []string{"en", "hi"}, []utterance{
+			{"hello", "", ""},
+			{"नमस्ते", "", ""},
+			{hindi, "", "hi"},
+		}, "hi"},
+		{"gives up after three tries", "en", []string{"en", "hi"}, []utterance{
+			{"hello", "", ""},
+			{"नमस्ते", "", ""},
+			{"123", "", ""},
+			{hindi, "", ""},
+		}, "en"},
+		{"ignores unsupported languages", "en", []string{"en", "hi"}, []utterance{
+			{marathi, "", ""},
+			{hindi, "", "hi"},
+		}, "hi"},
+		{"unsupported languages count as tries", "en", []string{"en", "hi"}, []utterance{
+			{marathi, "", ""},
+			{marathi, "", ""},
+			{marathi, "", ""},
+			{hindi, "", ""},
+		}, "en"},
+		{"empty turns do not count", "en", []string{"en", "hi"}, []utterance{
+			{"", "", ""},
+			{"", "", ""},
+			{"", "", ""},
+			{hindi, "", "hi"},
+		}, "hi"},
+		{"trusts the provider's language", "hi", []string{"en", "hi", "mr"}, []utterance{
+			{"hello", "mr", "mr"},
+		}, "mr"},
+		{"ignores an unsupported provider language", "en", []string{"en", "hi"}, []utterance{
+			{hindi, "ta", ""},
+			{hindi, "", "hi"},
+		}, "hi"},
+		{"one language has nothing to detect", "en", []string{"en"}, []utterance{
+			{hindi, "hi", ""},
+		}, "en"},
+	}
+	for _, tt := range tests {
+		s := NewSession(tt.start, tt.supported)
+		for i, u := range tt.turns {
+			if got := s.Observe(u.text, u.hint); got != u.want {
+				t.Errorf("%s: turn %d %q switched to %q, want %q", tt.name, i+1, u.text, got, u.want)
+			}
+		}
+		if got := s.Language(); got != tt.language {
+			t.Errorf("%s: ended in %s, want %s", tt.name, got, tt.language)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package limits enforces a call's maximum duration and cost: it asks the
+// model to wrap up as a limit approaches and ends the call at the limit.
+package limits
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// Reasons a call was ended, recorded as its disposition
+const (
+	MaxDuration = models.DispositionMaxDuration
+	MaxCost     = models.DispositionMaxCost
+)
+
+// Describe names a reason for people, e.g. in transcripts.
+func Describe(reason string) string {
+	switch reason {
+	case MaxDuration:
+		return "time limit"
+	case MaxCost:
+		return "cost limit"
+	}
+	return reason
+}
+
+// tick is how often the limits are checked
+const tick = time.Second
+
+// Config sets the limits; zero fields are not enforced.
+type Config struct {
+	MaxDuration time.Duration
+	// WrapUpAt is the call length at which to start wrapping up
+	WrapUpAt   time.Duration
+	MaxCostUSD float64
+	// WrapUpCostUSD is the cost at which to start wrapping up
+	WrapUpCostUSD float64
+}
+
+// Actions are run from the watch goroutine with the reason (MaxDuration
+// or MaxCost). WrapUp runs at most once, End once.
+type Actions struct {
+	WrapUp func(reason string)
+	End    func(reason string)
+}
+
+// Watch enforces the limits of one call. A nil *Watch is valid and
+// enforces nothing.
+type Watch struct {
+	cfg     Config
+	cost    func() float64
+	started time.Time
+
+	mu     sync.Mutex
+	reason string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New times the call from now; cost returns its estimated cost so far.
+// Nothing is enforced until Start.
+func New(cfg Config, cost func() float64) *Watch {
+	return &Watch{cfg: cfg, cost: cost, started: time.Now(), stop: make(chan struct{})}
+}
+
+// Start begins enforcing the limits.
+func (w *Watch) Start(actions Actions) {
+	if w == nil {
+		return
+	}
+	go w.run(actions)
+}
+
+// Reason returns the limit that ended the call, or "" if none did.
+func (w *Watch) Reason() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason
+}
+
+// Stop stops enforcing the limits.
+func (w *Watch) Stop() {
+	if w == nil {
+		return
+	}
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// check returns the limit reached and the limit being approached.
+func (w *Watch) check() (reached, near string) {
+	elapsed := time.Since(w.started)
+	cost := 0.0
+	if w.cost != nil {
+		cost = w.cost()
+	}
+	c := w.cfg
+	switch {
+	case c.MaxDuration > 0 && elapsed >= c.MaxDuration:
+		return MaxDuration, ""
+	case c.MaxCostUSD > 0 && cost >= c.MaxCostUSD:
+		return MaxCost, ""
+	case c.WrapUpAt > 0 && elapsed >= c.WrapUpAt:
+		return "", MaxDuration
+	case c.WrapUpCostUSD > 0 && cost >= c.WrapUpCostUSD:
+		return "", MaxCost
+	}
+	return "", ""
+}
+
+func (w *Watch) run(actions Actions) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	wrapped := false
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		}
+
+		reached, near := w.check()
+		if near != "" && !wrapped {
+			wrapped = true
+			if actions.WrapUp != nil {
+				actions.WrapUp(near)
+			}
+		}
+		if reached != "" {
+			w.mu.Lock()
+			w.reason = reached
+			w.mu.Unlock()
+			w.Stop()
+			if actions.End != nil {
+				actions.End(reached)
+			}
+			return
+		}
+	}
+}
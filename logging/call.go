@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Lazy is a log attribute value that can be filled in after a logger
+// was created, such as the stream ID that arrives with Vobiz's start event.
+type Lazy struct {
+	v atomic.Value
+}
+
+// Set stores the value reported from now on.
+func (l *Lazy) Set(s string) {
+	l.v.Store(s)
+}
+
+// LogValue implements slog.LogValuer.
+func (l *Lazy) LogValue() slog.Value {
+	s, _ := l.v.Load().(string)
+	return slog.StringValue(s)
+}
+
+// ForCall returns the bridge logger for one call. Every line carries
+// call_id, stream_id and provider.
+func ForCall(callId, provider string, streamId *Lazy) *slog.Logger {
+	inner := For("bridge").With(
+		slog.String("call_id", callId),
+		slog.String("provider", provider),
+	).Handler()
+	return slog.New(&lazyHandler{inner: inner, key: "stream_id", value: streamId})
+}
+
+// lazyHandler adds a Lazy attribute per record; handlers resolve
+// attributes passed to WithAttrs once, which would freeze it.
+type lazyHandler struct {
+	inner slog.Handler
+	key   string
+	value *Lazy
+}
+
+func (h *lazyHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l)
+}
+
+func (h *lazyHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.Attr{Key: h.key, Value: h.value.LogValue()})
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &lazyHandler{inner: h.inner.WithAttrs(attrs), key: h.key, value: h.value}
+}
+
+func (h *lazyHandler) WithGroup(name string) slog.Handler {
+	return &lazyHandler{inner: h.inner.WithGroup(name), key: h.key, value: h.value}
+}
+
+// Sampled returns a logger that only emits one in every n records. It is
+// meant for per-frame audio events, which arrive every 20ms.
+func Sampled(l *slog.Logger, n int) *slog.Logger {
+	if n <= 1 {
+		return l
+	}
+	return slog.New(&sampledHandler{inner: l.Handler(), every: uint64(n), count: new(atomic.Uint64)}).
+		With(slog.Int("sample_every", n))
+}
+
+type sampledHandler struct {
+	inner slog.Handler
+	every uint64
+	count *atomic.Uint64
+}
+
+func (h *sampledHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l)
+}
+
+func (h *sampledHandler) Handle(ctx context.Context, r slog.Record) error {
+	if (h.count.Add(1)-1)%h.every != 0 {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *sampledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampledHandler{inner: h.inner.WithAttrs(attrs), every: h.every, count: h.count}
+}
+
+func (h *sampledHandler) WithGroup(name string) slog.Handler {
+	return &sampledHandler{inner: h.inner.WithGroup(name), every: h.every, count: h.count}
+}
@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Environment:
+//
+//	LOG_FORMAT=json|text        output format (default text)
+//	LOG_LEVEL=debug|info|...    default level (default info)
+//	LOG_LEVEL_<SUBSYSTEM>=...   per-subsystem override, e.g. LOG_LEVEL_BRIDGE=debug
+
+type config struct {
+	root   slog.Handler
+	levels map[string]slog.Level
+	def    slog.Level
+}
+
+var (
+	current    atomic.Pointer[config]
+	generation atomic.Uint64
+)
+
+func init() {
+	configure(os.Stderr, false, slog.LevelInfo, nil)
+}
+
+// Setup configures logging from the environment. Loggers obtained with
+// For before Setup pick up the new configuration.
+func Setup() {
+	levels := map[string]slog.Level{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if sub, ok := strings.CutPrefix(key, "LOG_LEVEL_"); ok {
+			levels[strings.ToLower(sub)] = parseLevel(value, slog.LevelInfo)
+		}
+	}
+	configure(os.Stderr, os.Getenv("LOG_FORMAT") == "json", parseLevel(os.Getenv("LOG_LEVEL"), slog.LevelInfo), levels)
+}
+
+func configure(w io.Writer, asJSON bool, def slog.Level, levels map[string]slog.Level) {
+	opts := &slog.HandlerOptions{
+		Level:       slog.LevelDebug, // subsystem levels are enforced by subsystemHandler
+		ReplaceAttr: redactAttr,
+	}
+
+	var root slog.Handler
+	if asJSON {
+		root = slog.NewJSONHandler(w, opts)
+	} else {
+		root = slog.NewTextHandler(w, opts)
+	}
+
+	current.Store(&config{root: root, levels: levels, def: def})
+	generation.Add(1)
+
+	// Route the standard log package (echo, libraries) through slog too
+	slog.SetDefault(slog.New(&subsystemHandler{subsystem: "default"}))
+	log.SetFlags(0)
+}
+
+func parseLevel(s string, fallback slog.Level) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return fallback
+	}
+	return l
+}
+
+// For returns the logger for a subsystem such as "bridge", "rabbitmq"
+// or "postcall". Every line carries a subsystem attribute.
+func For(subsystem string) *slog.Logger {
+	return slog.New(&subsystemHandler{subsystem: subsystem})
+}
+
+// subsystemHandler enforces the subsystem's level and forwards to the
+// currently configured root handler, rebuilding its attribute chain
+// whenever the configuration changes.
+type subsystemHandler struct {
+	subsystem string
+	ops       []func(slog.Handler) slog.Handler
+
+	mu    sync.Mutex
+	gen   uint64
+	built slog.Handler
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, l slog.Level) bool {
+	cfg := current.Load()
+	level, ok := cfg.levels[h.subsystem]
+	if !ok {
+		level = cfg.def
+	}
+	return l >= level
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.handler().Handle(ctx, r)
+}
+
+func (h *subsystemHandler) handler() slog.Handler {
+	gen := generation.Load()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.built == nil || h.gen != gen {
+		built := current.Load().root.WithAttrs([]slog.Attr{slog.String("subsystem", h.subsystem)})
+		for _, op := range h.ops {
+			built = op(built)
+		}
+		h.built, h.gen = built, gen
+	}
+	return h.built
+}
+
+func (h *subsystemHandler) with(op func(slog.Handler) slog.Handler) *subsystemHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &subsystemHandler{subsystem: h.subsystem, ops: append(ops, op)}
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
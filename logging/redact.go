@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// audioKeys carry base64 media and are replaced by their size.
+var audioKeys = map[string]bool{
+	"payload": true, "audio": true, "delta": true, "data": true,
+}
+
+// phoneKeys hold phone numbers and keep only their last four digits.
+var phoneKeys = map[string]bool{
+	"from": true, "to": true, "phone": true, "caller": true,
+	"caller_number": true, "dialed_number": true, "mobile": true,
+}
+
+// piiKeys are never logged.
+var piiKeys = map[string]bool{
+	"name": true, "address": true, "email": true, "age": true,
+	"gender": true, "customer": true, "transcript": true, "text": true,
+}
+
+// phonePattern matches bare phone numbers inside free text.
+var phonePattern = regexp.MustCompile(`\+?\b\d{10,13}\b`)
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	v := a.Value.Resolve()
+
+	switch {
+	case audioKeys[key] && v.Kind() == slog.KindString:
+		return slog.String(a.Key, fmt.Sprintf("[%d bytes]", len(v.String())))
+	case phoneKeys[key]:
+		return slog.String(a.Key, MaskPhone(v.String()))
+	case piiKeys[key]:
+		return slog.String(a.Key, "[redacted]")
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, phonePattern.ReplaceAllStringFunc(v.String(), MaskPhone))
+	case slog.KindAny:
+		if m, ok := v.Any().(map[string]interface{}); ok {
+			return slog.Any(a.Key, redactMap(m))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// redactMap applies the same rules to tool arguments and results.
+func redactMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		key := strings.ToLower(k)
+		switch {
+		case audioKeys[key]:
+			if s, ok := val.(string); ok {
+				out[k] = fmt.Sprintf("[%d bytes]", len(s))
+				continue
+			}
+			out[k] = val
+		case phoneKeys[key]:
+			out[k] = MaskPhone(fmt.Sprint(val))
+		case piiKeys[key]:
+			out[k] = "[redacted]"
+		default:
+			switch val := val.(type) {
+			case map[string]interface{}:
+				out[k] = redactMap(val)
+			case string:
+				out[k] = phonePattern.ReplaceAllStringFunc(val, MaskPhone)
+			default:
+				out[k] = val
+			}
+		}
+	}
+	return out
+}
+
+// MaskPhone keeps the last four digits of a phone number.
+func MaskPhone(s string) string {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits <= 4 {
+		return s
+	}
+
+	var b strings.Builder
+	seen := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			seen++
+			if seen <= digits-4 {
+				r = '*'
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -1,22 +1,37 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/chat"
 	gemini20 "github.com/AVVKavvk/openai-vobiz/gemini2.0"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/postcall"
 	"github.com/AVVKavvk/openai-vobiz/rabbitmq"
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/AVVKavvk/openai-vobiz/usage"
+	"github.com/AVVKavvk/openai-vobiz/vad"
 	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Configuration
 const (
-	OpenAIRealtimeURL = "wss://api.openai.com/v1/realtime?model=gpt-realtime-mini"
-	ServerPort        = ":8080"
+	ServerPort = ":8080"
+
+	// consumerRetry spaces out attempts to restart a stopped consumer
+	consumerRetry = 5 * time.Second
 )
 
 var (
@@ -24,6 +39,8 @@ var (
 	upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool { return true },
 	}
+
+	logger = logging.For("api")
 )
 
 func main() {
@@ -31,47 +48,141 @@ func main() {
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
+	logging.Setup()
+
+	// Agents pick voices and models from the catalogue, so it loads first
+	if err := catalog.Load(os.Getenv("CATALOG_CONFIG")); err != nil {
+		log.Fatalf("Error loading catalog config: %v", err)
+	}
+	// Broken prompt templates must fail startup, not a live call
+	if err := agent.Load(os.Getenv("AGENT_CONFIG")); err != nil {
+		log.Fatalf("Error loading agent config: %v", err)
+	}
+	if err := usage.Load(os.Getenv("PRICING_CONFIG")); err != nil {
+		log.Fatalf("Error loading pricing config: %v", err)
+	}
+	if err := vad.Load(os.Getenv("VAD_MODE")); err != nil {
+		log.Fatalf("Error loading VAD mode: %v", err)
+	}
+
+	if err := redisClient.Connect(); err != nil {
+		log.Fatalf("Error connecting to redis: %v", err)
+	}
+	if err := rabbitmq.Connect(); err != nil {
+		log.Fatalf("Error connecting to rabbitmq: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatalf("Error configuring tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	pipeline, err := postcall.NewPipelineFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring post-call pipeline: %v", err)
+	}
+
+	e := newRouter(gemini20.HandleWebSocketStreamGoogleAI)
 
+	// Consumers
+	go consume("transcript", rabbitmq.RabbitMQConsumer)
+	// Post-call processing runs off the call-ended event
+	go consume("call ended", func() error {
+		return rabbitmq.CallEndedConsumer(pipeline.Workers, pipeline.HandleCallEnded)
+	})
+
+	e.Logger.Fatal(e.Start(ServerPort))
+
+}
+
+// newRouter registers every route, with stream as the media websocket
+// the answer XML points Vobiz at.
+func newRouter(stream echo.HandlerFunc) *echo.Echo {
 	e := echo.New()
-	e.Use(middleware.Logger())
+	e.HideBanner = true
+	e.Use(requestLogger())
 	e.Use(middleware.Recover())
+	e.Use(tracing.Middleware())
 
 	// 1. Validates Vobiz is connecting and returns XML
 	e.POST("/incoming-call", HandleIncomingCall)
 
 	// 2. The WebSocket Bridge
-	e.GET("/stream", gemini20.HandleWebSocketStreamGoogleAI)
+	e.GET("/stream", stream)
+	// Text chat with the same agents, tools and call records
+	e.GET("/chat", chat.Handler(getCustomerInfo))
 	e.POST("/hangup", handleHangup)
 	e.POST("/outbound-call", HandleOutboundCall)
+	e.POST("/machine-detection", HandleMachineDetection)
+	e.GET("/claims/:ref", HandleGetClaim)
+	e.GET("/calls", HandleListCalls)
+	e.GET("/calls/:id", HandleGetCall)
+	e.GET("/calls/:id/usage", HandleGetCallUsage)
+	e.GET("/calls/:id/session", HandleGetSession)
+	e.PATCH("/calls/:id/session", HandleUpdateSession)
+	e.GET("/usage/daily", HandleDailyUsage)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	return e
+}
 
-	go func() {
-
-		defer func() {
-			if err := recover(); err != nil {
-				fmt.Println(err)
-			}
-			//Consumers
-			rabbitmq.RabbitMQConsumer()
-		}()
-		//Consumers
-		rabbitmq.RabbitMQConsumer()
-	}()
-
-	e.Logger.Fatal(e.Start(ServerPort))
-
+// consume keeps a queue consumer running, starting it again whenever it
+// stops, e.g. after the broker connection dropped.
+func consume(name string, run func() error) {
+	for {
+		err := run()
+		logger.Error("consumer stopped, restarting", "consumer", name, "error", err, "retry_in", consumerRetry)
+		time.Sleep(consumerRetry)
+	}
 }
 
 // handleIncomingCall returns the XML telling Vobiz to connect to our WebSocket
 func handleHangup(c echo.Context) error {
-	fmt.Println("Hangup called")
+	logger.InfoContext(c.Request().Context(), "hangup callback received")
 	var body map[string]interface{}
 
 	if err := c.Bind(&body); err != nil {
 		return err
 	}
 
-	fmt.Println(body)
+	logger.DebugContext(c.Request().Context(), "hangup callback", "call_id", body["CallUUID"], "cause", body["HangupCause"])
 
 	return nil
 
 }
+
+// requestLogger logs one line per request. Only the path is logged: query
+// strings carry phone numbers and campaign data.
+func requestLogger() echo.MiddlewareFunc {
+	httpLog := logging.For("http")
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogStatus:   true,
+		LogMethod:   true,
+		LogURIPath:  true,
+		LogLatency:  true,
+		LogError:    true,
+		HandleError: true,
+		LogRemoteIP: true,
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == "/metrics"
+		},
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			level := slog.LevelInfo
+			if v.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			attrs := []slog.Attr{
+				slog.String("method", v.Method),
+				slog.String("path", v.URIPath),
+				slog.Int("status", v.Status),
+				slog.Duration("latency", v.Latency),
+				slog.String("remote_ip", v.RemoteIP),
+			}
+			if v.Error != nil {
+				attrs = append(attrs, slog.String("error", v.Error.Error()))
+			}
+			httpLog.LogAttrs(c.Request().Context(), level, "request", attrs...)
+			return nil
+		},
+	})
+}
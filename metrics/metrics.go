@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "vobiz"
+
+// Audio directions for AudioFrames and AudioBytes
+const (
+	Inbound  = "vobiz_to_provider"
+	Outbound = "provider_to_vobiz"
+)
+
+var (
+	ActiveCalls = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_calls",
+		Help:      "Calls currently bridged.",
+	}, []string{"provider"})
+
+	CallsEnded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "calls_ended_total",
+		Help:      "Bridged calls by how the media stream ended.",
+	}, []string{"provider", "outcome"})
+
+	CallDispositions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "call_dispositions_total",
+		Help:      "Analyzed calls by disposition.",
+	}, []string{"agent", "disposition"})
+
+	CallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "call_duration_seconds",
+		Help:      "Duration of bridged calls.",
+		Buckets:   []float64{10, 30, 60, 120, 180, 300, 600, 900, 1800},
+	}, []string{"provider"})
+
+	AudioFrames = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audio_frames_total",
+		Help:      "Audio frames forwarded between Vobiz and the provider.",
+	}, []string{"provider", "direction"})
+
+	AudioBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audio_bytes_total",
+		Help:      "Decoded audio bytes forwarded between Vobiz and the provider.",
+	}, []string{"provider", "direction"})
+
+	ProviderErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_errors_total",
+		Help:      "Provider websocket and API errors.",
+	}, []string{"provider", "kind"})
+
+	ToolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Tool calls made by the model.",
+	}, []string{"tool", "status"})
+
+	ToolLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Time spent executing tool calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool"})
+
+	PublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rabbitmq_publish_failures_total",
+		Help:      "Failed RabbitMQ publishes.",
+	}, []string{"exchange"})
+
+	ResponseLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "response_latency_seconds",
+		Help:      "Time from the end of caller speech to the first playAudio frame sent to Vobiz.",
+		Buckets:   []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.75, 1, 1.5, 2, 3, 5},
+	}, []string{"provider"})
+
+	UsageTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_tokens_total",
+		Help:      "Provider tokens by kind (input_text, input_audio, cached_text, cached_audio, output_text, output_audio).",
+	}, []string{"provider", "model", "kind"})
+
+	UsageCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_cost_usd_total",
+		Help:      "Estimated provider cost in USD from the pricing table.",
+	}, []string{"provider", "model"})
+
+	UsageAudioSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_audio_seconds_total",
+		Help:      "Seconds of audio forwarded between Vobiz and the provider.",
+	}, []string{"provider", "model", "direction"})
+
+	Interruptions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "interruptions_total",
+		Help:      "Model replies cut off by the caller talking over them.",
+	}, []string{"provider"})
+
+	CallerUtterances = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "caller_utterances_total",
+		Help:      "Caller utterances found by the local VAD.",
+	}, []string{"provider"})
+
+	CallerSpeechSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "caller_speech_seconds_total",
+		Help:      "Seconds of caller audio the local VAD classed as speech, echo excluded.",
+	}, []string{"provider"})
+)
+
+// ObserveTool records one tool call that started at start.
+func ObserveTool(name string, start time.Time, failed bool) {
+	status := "ok"
+	if failed {
+		status = "error"
+	}
+	ToolCalls.WithLabelValues(name, status).Inc()
+	ToolLatency.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
+
+// CountAudio records one forwarded audio frame of n decoded bytes.
+func CountAudio(provider, direction string, n int) {
+	AudioFrames.WithLabelValues(provider, direction).Inc()
+	AudioBytes.WithLabelValues(provider, direction).Add(float64(n))
+}
+
+// CountAudioBase64 records one forwarded frame given its base64 payload.
+func CountAudioBase64(provider, direction, payload string) {
+	CountAudio(provider, direction, DecodedLen(payload))
+}
+
+// DecodedLen is the number of bytes a padded base64 payload decodes to.
+func DecodedLen(payload string) int {
+	return base64.StdEncoding.DecodedLen(len(payload)) - (len(payload) - len(strings.TrimRight(payload, "=")))
+}
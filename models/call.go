@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CallModel is the record kept for every bridged call. The bridge
+// publishes it on the call-ended event; post-call steps fill in the rest.
+type CallModel struct {
+	CallId   string `json:"callId"`
+	Agent    string `json:"agent"`
+	Provider string `json:"provider"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+
+	// Channel is ChannelVoice for phone calls, ChannelChat for text chat
+	// sessions, which are recorded as calls too.
+	Channel string `json:"channel,omitempty"`
+
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+
+	// ClaimRef is the claim reference reserved for the call; a claim
+	// exists under it only if create_claim was called.
+	ClaimRef string `json:"claimRef,omitempty"`
+
+	// AnsweredBy is "human", "machine" or "unknown" on outbound calls
+	// screened for answering machines.
+	AnsweredBy string `json:"answeredBy,omitempty"`
+
+	// Language is the ISO 639-1 code of the language the call was held
+	// in, detected from the caller when the agent speaks several.
+	Language string `json:"language,omitempty"`
+
+	Summary     string          `json:"summary,omitempty"`
+	Disposition string          `json:"disposition,omitempty"`
+	Sentiment   *SentimentModel `json:"sentiment,omitempty"`
+
+	// Extraction is the structured data pulled from the transcript,
+	// shaped by the agent's extraction schema.
+	Extraction map[string]interface{} `json:"extraction,omitempty"`
+
+	// Usage is the provider usage accumulated by the bridge.
+	Usage *UsageModel `json:"usage,omitempty"`
+}
+
+func (c *CallModel) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *CallModel) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+// Channels for CallModel.Channel
+const (
+	ChannelVoice = "voice"
+	ChannelChat  = "chat"
+)
+
+// Disposition codes for CallModel.Disposition
+const (
+	DispositionClaimFiled  = "claim_filed"
+	DispositionInfoOnly    = "info_only"
+	DispositionTransferred = "transferred"
+	DispositionDropped     = "dropped"
+
+	// Set by the bridge when it ended the call at a limit
+	DispositionMaxDuration = "max_duration"
+	DispositionMaxCost     = "max_cost"
+
+	// Set by the bridge when an answering machine picked up
+	DispositionVoicemail = "voicemail_left"
+	DispositionMachine   = "answering_machine"
+)
+
+// SentimentModel is the caller's sentiment over the course of the call.
+// Start and End are "positive", "neutral" or "negative"; Trajectory is
+// "improving", "stable" or "declining".
+type SentimentModel struct {
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Trajectory string `json:"trajectory"`
+}
+
+// ExtractionModel is published to downstream claim systems once the
+// post-call extraction for a call has finished.
+type ExtractionModel struct {
+	CallId string                 `json:"callId"`
+	Agent  string                 `json:"agent"`
+	Data   map[string]interface{} `json:"data"`
+}
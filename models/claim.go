@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ClaimModel is a First Notice of Loss record captured during a call.
+// Fields follow the FNOL steps in the agent prompt.
+type ClaimModel struct {
+	Reference string `json:"reference"`
+	CallId    string `json:"callId"`
+	Caller    string `json:"caller"`
+
+	CallerSafe           bool   `json:"callerSafe"`
+	VehicleRegistration  string `json:"vehicleRegistration"`
+	RelationshipToPolicy string `json:"relationshipToPolicy"`
+
+	Incident IncidentModel `json:"incident"`
+
+	Injuries      bool   `json:"injuries"`
+	InjuryDetails string `json:"injuryDetails,omitempty"`
+	FIRFiled      bool   `json:"firFiled"`
+	FIRNumber     string `json:"firNumber,omitempty"`
+	PoliceStation string `json:"policeStation,omitempty"`
+
+	CurrentLocation string `json:"currentLocation,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// IncidentModel is the caller's narration of what happened.
+type IncidentModel struct {
+	What               string `json:"what"`
+	Where              string `json:"where"`
+	When               string `json:"when"`
+	Damage             string `json:"damage,omitempty"`
+	ThirdPartyInvolved bool   `json:"thirdPartyInvolved"`
+}
+
+func (c *ClaimModel) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *ClaimModel) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
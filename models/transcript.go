@@ -6,6 +6,9 @@ type TranscriptModel struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 	CallId  string `json:"callId"`
+	// Interrupted marks an AI line the caller cut off; an "Event" line
+	// records how much of it was heard
+	Interrupted bool `json:"interrupted,omitempty"`
 }
 
 func (t *TranscriptModel) MarshalBinary() ([]byte, error) {
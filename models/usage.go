@@ -0,0 +1,32 @@
+package models
+
+// UsageModel is the provider usage of one call and its estimated cost.
+// Input token counts exclude cached tokens, which are counted separately.
+type UsageModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	InputTextTokens   int `json:"inputTextTokens"`
+	InputAudioTokens  int `json:"inputAudioTokens"`
+	CachedTextTokens  int `json:"cachedTextTokens"`
+	CachedAudioTokens int `json:"cachedAudioTokens"`
+	OutputTextTokens  int `json:"outputTextTokens"`
+	OutputAudioTokens int `json:"outputAudioTokens"`
+
+	// Audio forwarded in each direction, in seconds of 8kHz μ-law
+	InputAudioSeconds  float64 `json:"inputAudioSeconds"`
+	OutputAudioSeconds float64 `json:"outputAudioSeconds"`
+
+	// EstimatedCostUSD is computed from the pricing table; Priced is
+	// false when the model has no entry there.
+	EstimatedCostUSD float64 `json:"estimatedCostUsd"`
+	Priced           bool    `json:"priced"`
+}
+
+// DailyUsageModel is the usage of all calls started on one UTC day on
+// one provider model.
+type DailyUsageModel struct {
+	Date  string `json:"date"`
+	Calls int    `json:"calls"`
+	UsageModel
+}
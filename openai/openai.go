@@ -0,0 +1,57 @@
+// Package openai holds what the voice bridge and text chat share about
+// the OpenAI Realtime API.
+package openai
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/AVVKavvk/openai-vobiz/usage"
+)
+
+// RealtimeURL is the Realtime API endpoint; the model is added as ?model=
+const RealtimeURL = "wss://api.openai.com/v1/realtime"
+
+// Endpoint returns the URL to dial for a session on model.
+// OPENAI_REALTIME_URL points it at another endpoint, e.g. the fake
+// provider in tests.
+func Endpoint(model string) (string, error) {
+	endpoint := RealtimeURL
+	if u := os.Getenv("OPENAI_REALTIME_URL"); u != "" {
+		endpoint = u
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("model", model)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Tokens converts the usage of a response.done event. Cached tokens are
+// reported as part of the input tokens, so they are subtracted.
+func Tokens(u map[string]interface{}) usage.Tokens {
+	count := func(m map[string]interface{}, key string) int {
+		n, _ := m[key].(float64)
+		return int(n)
+	}
+	details := func(m map[string]interface{}, key string) map[string]interface{} {
+		d, _ := m[key].(map[string]interface{})
+		return d
+	}
+
+	in := details(u, "input_token_details")
+	cached := details(in, "cached_tokens_details")
+	out := details(u, "output_token_details")
+
+	return usage.Tokens{
+		InputText:   count(in, "text_tokens") - count(cached, "text_tokens"),
+		InputAudio:  count(in, "audio_tokens") - count(cached, "audio_tokens"),
+		CachedText:  count(cached, "text_tokens"),
+		CachedAudio: count(cached, "audio_tokens"),
+		OutputText:  count(out, "text_tokens"),
+		OutputAudio: count(out, "audio_tokens"),
+	}
+}
@@ -0,0 +1,53 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/AVVKavvk/openai-vobiz/usage"
+)
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage string
+		want  usage.Tokens
+	}{
+		{"voice turn with a cached prompt", `{
+			"total_tokens": 1788, "input_tokens": 1620, "output_tokens": 168,
+			"input_token_details": {
+				"text_tokens": 1420, "audio_tokens": 200, "cached_tokens": 1152,
+				"cached_tokens_details": {"text_tokens": 1088, "audio_tokens": 64}
+			},
+			"output_token_details": {"text_tokens": 38, "audio_tokens": 130}
+		}`, usage.Tokens{InputText: 332, InputAudio: 136, CachedText: 1088, CachedAudio: 64, OutputText: 38, OutputAudio: 130}},
+		{"text chat turn", `{
+			"total_tokens": 910, "input_tokens": 880, "output_tokens": 30,
+			"input_token_details": {"text_tokens": 880, "audio_tokens": 0, "cached_tokens": 0},
+			"output_token_details": {"text_tokens": 30, "audio_tokens": 0}
+		}`, usage.Tokens{InputText: 880, OutputText: 30}},
+		{"no details", `{"total_tokens": 0}`, usage.Tokens{}},
+	}
+	for _, tt := range tests {
+		var u map[string]interface{}
+		if err := json.Unmarshal([]byte(tt.usage), &u); err != nil {
+			t.Fatal(err)
+		}
+		if got := Tokens(u); got != tt.want {
+			t.Errorf("%s: Tokens = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	got, err := Endpoint("gpt-realtime")
+	if want := RealtimeURL + "?model=gpt-realtime"; err != nil || got != want {
+		t.Errorf("Endpoint = %q, %v; want %q", got, err, want)
+	}
+
+	t.Setenv("OPENAI_REALTIME_URL", "ws://127.0.0.1:9000/v1/realtime?trace=1")
+	got, err = Endpoint("gpt-realtime-mini")
+	if want := "ws://127.0.0.1:9000/v1/realtime?model=gpt-realtime-mini&trace=1"; err != nil || got != want {
+		t.Errorf("Endpoint = %q, %v; want %q", got, err, want)
+	}
+}
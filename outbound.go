@@ -2,15 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"time"
 
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/amd"
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // --- Structs for Request/Response ---
@@ -20,6 +26,14 @@ type OutboundCallRequest struct {
 	FromNumber string                 `json:"from_number"`
 	ToNumber   string                 `json:"to_number"`
 	Body       map[string]interface{} `json:"body"` // Optional extra data
+	// Agent names the persona that handles the call; empty uses the default
+	Agent string `json:"agent,omitempty"`
+	// MachineDetection screens the call for answering machines; nil connects
+	// whoever picks up straight to the agent
+	MachineDetection *amd.Policy `json:"machine_detection,omitempty"`
+	// Session picks the model, voice and tuning per provider for this
+	// call, over the agent's and the campaign's (body.session)
+	Session catalog.Selection `json:"session,omitempty"`
 }
 
 // VobizCallPayload represents the payload sent to Vobiz API
@@ -28,6 +42,11 @@ type VobizCallPayload struct {
 	To           string `json:"to"`
 	AnswerURL    string `json:"answer_url"`
 	AnswerMethod string `json:"answer_method"`
+
+	MachineDetection       string `json:"machine_detection,omitempty"`
+	MachineDetectionTime   int    `json:"machine_detection_time,omitempty"` // ms
+	MachineDetectionURL    string `json:"machine_detection_url,omitempty"`
+	MachineDetectionMethod string `json:"machine_detection_method,omitempty"`
 }
 
 // --- Configuration ---
@@ -42,7 +61,8 @@ var (
 func HandleOutboundCall(c echo.Context) error {
 	var VobizAuthID = os.Getenv("VOBIZ_AUTH_ID")
 	var VobizAuthToken = os.Getenv("VOBIZ_AUTH_TOKEN")
-	log.Println("[Vobiz] outbound call api is triggered")
+	ctx := c.Request().Context()
+	logger.InfoContext(ctx, "outbound call requested")
 
 	// 1. Parse Incoming Request
 	req := new(OutboundCallRequest)
@@ -55,7 +75,24 @@ func HandleOutboundCall(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Missing 'from_number' or 'to_number' in request body")
 	}
 
-	log.Printf("[DEBUG] Body data: %v\n", req.Body)
+	logger.DebugContext(ctx, "outbound call body", "fields", len(req.Body))
+
+	if p := req.MachineDetection; p != nil {
+		// The message is rendered with this call's campaign variables
+		sample := agent.SampleVars()
+		if len(req.Body) > 0 {
+			sample.Campaign = req.Body
+		}
+		if err := p.Validate(sample); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := req.Session.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := catalog.SelectionFrom(req.Body["session"]); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "body.session: "+err.Error())
+	}
 
 	// 3. Construct Answer URL
 	// We need to determine the protocol (http/https) and host dynamically
@@ -72,18 +109,33 @@ func HandleOutboundCall(c echo.Context) error {
 	answerURL := fmt.Sprintf("%s://%s/incoming-call", scheme, host)
 
 	// Append encoded body data if present
+	query := url.Values{}
 	if len(req.Body) > 0 {
 		jsonData, err := json.Marshal(req.Body)
 		if err == nil {
-			// URL Encode the JSON string
-			encodedData := url.QueryEscape(string(jsonData))
-			answerURL = fmt.Sprintf("%s?body_data=%s", answerURL, encodedData)
+			query.Set("body_data", string(jsonData))
 		} else {
-			log.Printf("[WARN] Failed to marshal body data: %v", err)
+			logger.WarnContext(ctx, "failed to marshal body data", "error", err)
 		}
 	}
+	if req.Agent != "" {
+		query.Set("agent", req.Agent)
+	}
+	// The stream needs the machine detection policy to act on the verdict
+	if req.MachineDetection != nil {
+		policy, _ := json.Marshal(req.MachineDetection)
+		query.Set("amd", string(policy))
+	}
+	if len(req.Session) > 0 {
+		session, _ := json.Marshal(req.Session)
+		query.Set("session", string(session))
+	}
+	if len(query) > 0 {
+		answerURL += "?" + query.Encode()
+	}
 
-	log.Printf("[INFO] Answer URL that will be sent to Vobiz: %s", answerURL)
+	// body_data carries campaign PII, so only the target is logged
+	logger.InfoContext(ctx, "answer url prepared", "answer_url", fmt.Sprintf("%s://%s/incoming-call", scheme, host), "body_data", len(req.Body) > 0)
 
 	// 4. Prepare Request to Vobiz
 	vobizURL := fmt.Sprintf("%s/%s/Call/", VobizBaseURL, VobizAuthID)
@@ -94,6 +146,13 @@ func HandleOutboundCall(c echo.Context) error {
 		AnswerURL:    answerURL,
 		AnswerMethod: "POST",
 	}
+	if req.MachineDetection != nil {
+		// Vobiz's verdict is a hint; the stream also screens the audio itself
+		payload.MachineDetection = "true"
+		payload.MachineDetectionTime = int(amd.DefaultConfig.Window.Milliseconds())
+		payload.MachineDetectionURL = fmt.Sprintf("%s://%s/machine-detection", scheme, host)
+		payload.MachineDetectionMethod = "POST"
+	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -101,8 +160,8 @@ func HandleOutboundCall(c echo.Context) error {
 	}
 
 	// 5. Send HTTP POST to Vobiz
-	client := &http.Client{Timeout: 10 * time.Second}
-	vobizReq, err := http.NewRequest("POST", vobizURL, bytes.NewBuffer(payloadBytes))
+	client := tracing.HTTPClient(10 * time.Second)
+	vobizReq, err := http.NewRequestWithContext(ctx, "POST", vobizURL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create request")
 	}
@@ -114,7 +173,7 @@ func HandleOutboundCall(c echo.Context) error {
 
 	resp, err := client.Do(vobizReq)
 	if err != nil {
-		log.Printf("[ERROR] HTTP request error: %v", err)
+		logger.ErrorContext(ctx, "vobiz request failed", "error", err)
 		return echo.NewHTTPError(http.StatusBadGateway, fmt.Sprintf("Vobiz API request failed: %v", err))
 	}
 	defer resp.Body.Close()
@@ -122,15 +181,23 @@ func HandleOutboundCall(c echo.Context) error {
 	// 6. Handle Response
 	var vobizResp map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&vobizResp); err != nil {
-		log.Printf("[ERROR] Failed to decode Vobiz response")
+		logger.ErrorContext(ctx, "failed to decode vobiz response", "error", err)
 	}
 
 	if resp.StatusCode != 201 {
-		log.Printf("[ERROR] Vobiz API call failed with status %d: %v", resp.StatusCode, vobizResp)
+		logger.ErrorContext(ctx, "vobiz call api failed", "status", resp.StatusCode, "response", vobizResp)
 		return echo.NewHTTPError(resp.StatusCode, fmt.Sprintf("Vobiz API error: %v", vobizResp))
 	}
 
-	log.Println("[SUCCESS] Vobiz API call successful!", vobizResp)
+	logger.InfoContext(ctx, "outbound call placed", "call_id", vobizResp["request_uuid"])
+
+	// The call UUID only exists now; link this request into the call's trace
+	if callUUID, ok := vobizResp["request_uuid"].(string); ok {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("call.uuid", callUUID))
+		_, span := tracing.Tracer().Start(tracing.ContextForCall(context.Background(), callUUID), "vobiz.call_fired",
+			trace.WithLinks(trace.LinkFromContext(ctx)))
+		span.End()
+	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -0,0 +1,475 @@
+// Package playout schedules model audio out to Vobiz. Providers deliver
+// audio in bursts of arbitrary size; a Player re-frames it into 20ms μ-law
+// frames, sends each one just ahead of its play time, and keeps a
+// timeline of what the caller has actually heard so an interrupted
+// response can be truncated at the right point.
+package playout
+
+import (
+	"encoding/base64"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+const (
+	FrameDuration = 20 * time.Millisecond
+	FrameBytes    = 160 // 20ms of 8kHz μ-law
+
+	// Lead is how far ahead of its play time a frame is sent, to absorb
+	// network jitter between us and Vobiz
+	Lead = 60 * time.Millisecond
+)
+
+// Conn is the Vobiz media stream.
+type Conn interface {
+	WriteJSON(v interface{}) error
+}
+
+// Truncation is where an interrupted item stopped.
+type Truncation struct {
+	Item   string
+	Played time.Duration // heard by the caller
+	Total  time.Duration // received for the item
+}
+
+type outbound struct {
+	Event string `json:"event"`
+	Media *media `json:"media,omitempty"`
+}
+
+type media struct {
+	Payload     string `json:"payload"`
+	ContentType string `json:"contentType"`
+	SampleRate  int    `json:"sampleRate"`
+}
+
+type chunk struct {
+	item string
+	data []byte
+}
+
+// level is the loudness of one frame at its play time.
+type level struct {
+	due time.Time
+	rms float64
+}
+
+// levelHistory is how many frames of levels are kept for PlayedLevel
+const levelHistory = 100
+
+// segment is a run of frames played back to back.
+type segment struct {
+	start, end time.Time
+}
+
+// Player is the outbound audio scheduler of one call. All writes to the
+// Vobiz stream must go through it once it exists.
+type Player struct {
+	conn    Conn
+	writeMu sync.Mutex
+
+	// gen changes on every Clear, so a frame taken before it is not sent after
+	gen atomic.Uint64
+
+	mu        sync.Mutex
+	queue     []chunk
+	next      time.Time // play time of the next frame; zero when idle
+	timeline  map[string][]segment
+	received  map[string]int // bytes queued per item
+	last      string         // item of the latest frame sent
+	underruns int
+	levels    []level // recent frames sent, oldest first
+
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	onError   func(error)
+	onStart   func(item string) // guarded by mu
+}
+
+// pending is the next frame to send and the item it starts, if any.
+type pending struct {
+	data  []byte
+	gen   uint64
+	item  string
+	start func(item string) // set on the first frame of an item
+}
+
+// New starts a player writing to conn. onError, if set, is called for
+// failed writes.
+func New(conn Conn, onError func(error)) *Player {
+	p := &Player{
+		conn:     conn,
+		timeline: map[string][]segment{},
+		received: map[string]int{},
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		onError:  onError,
+	}
+	go p.run()
+	return p
+}
+
+// Play queues μ-law audio of item (a response or turn ID).
+func (p *Player) Play(item string, mulaw []byte) {
+	if len(mulaw) == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.queue = append(p.queue, chunk{item: item, data: mulaw})
+	p.received[item] += len(mulaw)
+	p.mu.Unlock()
+	p.signal()
+}
+
+// OnItemStart sets fn to be called, from the playout goroutine, once the
+// first frame of an item has been written to Vobiz; that is when the
+// caller starts hearing it.
+func (p *Player) OnItemStart(fn func(item string)) {
+	p.mu.Lock()
+	p.onStart = fn
+	p.mu.Unlock()
+}
+
+// Clear drops all queued audio, tells Vobiz to discard what it has
+// buffered and returns how much of the playing item the caller heard.
+// Vobiz is only told when audio is queued or still to be played there.
+func (p *Player) Clear() Truncation {
+	now := time.Now()
+
+	p.mu.Lock()
+	p.gen.Add(1)
+	buffered := len(p.queue) > 0 || p.next.After(now)
+	t := Truncation{
+		Item:   p.last,
+		Played: p.playedLocked(p.last, now),
+		Total:  time.Duration(p.received[p.last]) * FrameDuration / FrameBytes,
+	}
+	// The padded tail of an item is silence, not part of it
+	t.Played = min(t.Played, t.Total)
+	p.queue = nil
+	p.next = time.Time{}
+	// Frames sent ahead are discarded by clearAudio, so the timeline ends now
+	for len(p.levels) > 0 && p.levels[len(p.levels)-1].due.After(now) {
+		p.levels = p.levels[:len(p.levels)-1]
+	}
+	for item, segs := range p.timeline {
+		for i := range segs {
+			if segs[i].end.After(now) {
+				segs[i].end = maxTime(segs[i].start, now)
+			}
+		}
+		p.timeline[item] = segs
+	}
+	p.mu.Unlock()
+	p.signal()
+
+	if buffered {
+		p.write(outbound{Event: "clearAudio"})
+	}
+	return t
+}
+
+// Drop removes the queued audio of item, leaving other items and the
+// frames already sent alone. Unlike Clear it does not tell Vobiz, so up to
+// Lead of item may still be heard.
+func (p *Player) Drop(item string) {
+	p.mu.Lock()
+	kept := p.queue[:0]
+	for _, c := range p.queue {
+		if c.item != item {
+			kept = append(kept, c)
+		}
+	}
+	p.queue = kept
+	p.mu.Unlock()
+	p.signal()
+}
+
+// Played returns how much of item the caller has heard so far.
+func (p *Player) Played(item string) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.playedLocked(item, time.Now())
+}
+
+// Total returns how much audio the caller has heard in all.
+func (p *Player) Total() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var d time.Duration
+	for item := range p.timeline {
+		d += p.playedLocked(item, now)
+	}
+	return d
+}
+
+// Pending returns the audio that is queued or sent but not yet heard.
+func (p *Player) Pending() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d := time.Duration(p.queued()) * FrameDuration / FrameBytes
+	if wait := time.Until(p.next); wait > 0 {
+		d += wait
+	}
+	return d
+}
+
+// Busy reports whether any audio is still to be heard.
+func (p *Player) Busy() bool {
+	return p.Pending() > 0
+}
+
+// PlayedLevel returns the loudest RMS level of the frames played between
+// from and to, the reference for telling echo from the caller.
+func (p *Player) PlayedLevel(from, to time.Time) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var peak float64
+	for _, l := range p.levels {
+		if l.due.Add(FrameDuration).After(from) && !l.due.After(to) {
+			peak = max(peak, l.rms)
+		}
+	}
+	return peak
+}
+
+// Drain waits for audio queued from now on to be heard: up to timeout for
+// it to start, then until nothing is pending. It reports false if no
+// audio came or the player closed.
+func (p *Player) Drain(timeout time.Duration) bool {
+	t := time.NewTicker(FrameDuration)
+	defer t.Stop()
+	deadline := time.Now().Add(timeout)
+	started := false
+	for {
+		busy := p.Busy()
+		switch {
+		case busy:
+			started = true
+		case started:
+			return true
+		case time.Now().After(deadline):
+			return false
+		}
+		select {
+		case <-p.done:
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+// Underruns returns how often the queue ran dry in the middle of an item.
+func (p *Player) Underruns() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.underruns
+}
+
+// Close stops the player; queued audio is dropped.
+func (p *Player) Close() {
+	p.closeOnce.Do(func() { close(p.done) })
+}
+
+func (p *Player) playedLocked(item string, now time.Time) time.Duration {
+	var d time.Duration
+	for _, s := range p.timeline[item] {
+		if end := minTime(s.end, now); end.After(s.start) {
+			d += end.Sub(s.start)
+		}
+	}
+	return d
+}
+
+func (p *Player) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Player) run() {
+	for {
+		f, ok := p.nextFrame()
+		if !ok {
+			return
+		}
+		if p.send(f.data, f.gen) && f.start != nil {
+			f.start(f.item)
+		}
+	}
+}
+
+// nextFrame blocks until a frame is due to be sent.
+func (p *Player) nextFrame() (pending, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if len(p.queue) == 0 {
+			if !p.waitLocked(0) {
+				return pending{}, false
+			}
+			continue
+		}
+
+		now := time.Now()
+		item := p.queue[0].item
+		if p.next.Before(now) {
+			if !p.next.IsZero() && item == p.last {
+				p.underruns++
+			}
+			p.next = now
+		}
+		if sendAt := p.next.Add(-Lead); now.Before(sendAt) {
+			if !p.waitLocked(sendAt.Sub(now)) {
+				return pending{}, false
+			}
+			continue
+		}
+
+		// A short tail may still be growing; give it until just before
+		// its play time
+		if n := p.available(item); n < FrameBytes && n == p.queued() {
+			if deadline := p.next.Add(-FrameDuration / 2); now.Before(deadline) {
+				if !p.waitLocked(deadline.Sub(now)) {
+					return pending{}, false
+				}
+				continue
+			}
+		}
+
+		f := pending{data: p.take(item), gen: p.gen.Load(), item: item}
+		if item != p.last {
+			f.start = p.onStart
+		}
+		due := p.next
+		p.next = due.Add(FrameDuration)
+		p.last = item
+
+		segs := p.timeline[item]
+		if n := len(segs); n > 0 && segs[n-1].end.Equal(due) {
+			segs[n-1].end = p.next
+		} else {
+			segs = append(segs, segment{start: due, end: p.next})
+		}
+		p.timeline[item] = segs
+
+		if len(p.levels) == levelHistory {
+			p.levels = append(p.levels[:0], p.levels[1:]...)
+		}
+		p.levels = append(p.levels, level{due: due, rms: audio.MuLawRMS(f.data)})
+		return f, true
+	}
+}
+
+// waitLocked releases p.mu until d passes (forever when 0), audio is
+// queued or the player is closed. It reports false once closed.
+func (p *Player) waitLocked(d time.Duration) bool {
+	p.mu.Unlock()
+	defer p.mu.Lock()
+
+	var timeout <-chan time.Time
+	if d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-p.done:
+		return false
+	case <-p.wake:
+	case <-timeout:
+	}
+	return true
+}
+
+// available counts the queued bytes of item at the head of the queue.
+func (p *Player) available(item string) int {
+	n := 0
+	for _, c := range p.queue {
+		if c.item != item {
+			break
+		}
+		n += len(c.data)
+	}
+	return n
+}
+
+func (p *Player) queued() int {
+	n := 0
+	for _, c := range p.queue {
+		n += len(c.data)
+	}
+	return n
+}
+
+// take removes one frame of item from the queue, padding the end of the
+// item with silence.
+func (p *Player) take(item string) []byte {
+	frame := make([]byte, 0, FrameBytes)
+	for len(frame) < FrameBytes && len(p.queue) > 0 && p.queue[0].item == item {
+		c := &p.queue[0]
+		n := min(FrameBytes-len(frame), len(c.data))
+		frame = append(frame, c.data[:n]...)
+		c.data = c.data[n:]
+		if len(c.data) == 0 {
+			p.queue = p.queue[1:]
+		}
+	}
+	for len(frame) < FrameBytes {
+		frame = append(frame, audio.MuLawSilence)
+	}
+	return frame
+}
+
+// send writes frame unless a Clear came after it was taken, and reports
+// whether it went out.
+func (p *Player) send(frame []byte, gen uint64) bool {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if p.gen.Load() != gen {
+		return false
+	}
+	return p.writeLocked(outbound{
+		Event: "playAudio",
+		Media: &media{
+			Payload:     base64.StdEncoding.EncodeToString(frame),
+			ContentType: "audio/x-mulaw",
+			SampleRate:  8000,
+		},
+	})
+}
+
+func (p *Player) write(msg outbound) {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.writeLocked(msg)
+}
+
+func (p *Player) writeLocked(msg outbound) bool {
+	err := p.conn.WriteJSON(msg)
+	if err != nil && p.onError != nil {
+		p.onError(err)
+	}
+	return err == nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
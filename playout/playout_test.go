@@ -0,0 +1,95 @@
+package playout
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingConn struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (c *recordingConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	c.events = append(c.events, v.(outbound).Event)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *recordingConn) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.events)
+}
+
+func (c *recordingConn) clears() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, ev := range c.events {
+		if ev == "clearAudio" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestOnItemStartAfterFirstFrameIsSent(t *testing.T) {
+	conn := &recordingConn{}
+	p := New(conn, nil)
+	defer p.Close()
+
+	type start struct {
+		item   string
+		writes int
+	}
+	starts := make(chan start, 4)
+	p.OnItemStart(func(item string) {
+		starts <- start{item: item, writes: conn.count()}
+	})
+
+	p.Play("a", make([]byte, 3*FrameBytes))
+	p.Play("b", make([]byte, 2*FrameBytes))
+
+	for _, want := range []start{{"a", 1}, {"b", 4}} {
+		select {
+		case got := <-starts:
+			if got != want {
+				t.Errorf("start = %+v, want %+v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no start for %s", want.item)
+		}
+	}
+	if !p.Drain(time.Second) {
+		t.Fatal("player did not drain")
+	}
+	select {
+	case got := <-starts:
+		t.Errorf("unexpected start %+v", got)
+	default:
+	}
+}
+
+func TestClearTellsVobizOnlyWhenAudioIsBuffered(t *testing.T) {
+	conn := &recordingConn{}
+	p := New(conn, nil)
+	defer p.Close()
+
+	p.Clear()
+	if n := conn.count(); n != 0 {
+		t.Fatalf("idle clear wrote %d messages, want none", n)
+	}
+
+	p.Play("a", make([]byte, 50*FrameBytes))
+	time.Sleep(5 * FrameDuration)
+	cut := p.Clear()
+	if n := conn.clears(); n != 1 {
+		t.Errorf("clear while playing sent %d clearAudio, want 1", n)
+	}
+	if cut.Item != "a" || cut.Played <= 0 || cut.Played >= cut.Total {
+		t.Errorf("truncation = %+v, want part of a", cut)
+	}
+}
@@ -0,0 +1,40 @@
+package postcall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// Extractor turns a finished call's transcript into a JSON object that
+// matches schema.
+type Extractor interface {
+	Extract(ctx context.Context, transcript []models.TranscriptModel, schema map[string]interface{}) (map[string]interface{}, error)
+}
+
+// NewExtractorFromEnv picks the extractor named by POSTCALL_EXTRACTOR:
+// "openai" (default), "fake" or "off". It returns nil for "off".
+func NewExtractorFromEnv() (Extractor, error) {
+	switch name := os.Getenv("POSTCALL_EXTRACTOR"); name {
+	case "", "openai":
+		return NewOpenAIClient(os.Getenv("OPENAI_API_KEY"), os.Getenv("POSTCALL_MODEL")), nil
+	case "fake":
+		return &FakeExtractor{}, nil
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown POSTCALL_EXTRACTOR %q", name)
+	}
+}
+
+// formatTranscript renders the transcript as "Role: content" lines.
+func formatTranscript(transcript []models.TranscriptModel) string {
+	var b strings.Builder
+	for _, t := range transcript {
+		fmt.Fprintf(&b, "%s: %s\n", t.Role, t.Content)
+	}
+	return b.String()
+}
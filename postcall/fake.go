@@ -0,0 +1,89 @@
+package postcall
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// FakeExtractor is a deterministic Extractor for tests and local runs.
+// It returns Result when set, otherwise an object with every top-level
+// schema property set to its zero value.
+type FakeExtractor struct {
+	Result map[string]interface{}
+	Err    error
+
+	mu    sync.Mutex
+	calls [][]models.TranscriptModel
+}
+
+// Calls returns the transcripts it was asked to extract from.
+func (f *FakeExtractor) Calls() [][]models.TranscriptModel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]models.TranscriptModel(nil), f.calls...)
+}
+
+func (f *FakeExtractor) Extract(ctx context.Context, transcript []models.TranscriptModel, schema map[string]interface{}) (map[string]interface{}, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, transcript)
+	f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.Result != nil {
+		return f.Result, nil
+	}
+	return zeroValue(schema).(map[string]interface{}), nil
+}
+
+func zeroValue(schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "object":
+		obj := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, p := range props {
+			if ps, ok := p.(map[string]interface{}); ok {
+				obj[name] = zeroValue(ps)
+			}
+		}
+		return obj
+	case "array":
+		return []interface{}{}
+	case "boolean":
+		return false
+	case "number", "integer":
+		return 0
+	case "string":
+		return ""
+	default:
+		return nil
+	}
+}
+
+// FakeSummarizer is a deterministic Summarizer for tests and local runs.
+// It returns Result when set, otherwise a summary counting the turns.
+type FakeSummarizer struct {
+	Result *Analysis
+	Err    error
+}
+
+func (f *FakeSummarizer) Summarize(ctx context.Context, transcript []models.TranscriptModel) (*Analysis, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.Result != nil {
+		return f.Result, nil
+	}
+	return &Analysis{
+		Summary:     fmt.Sprintf("Call with %d transcript turns.", len(transcript)),
+		Disposition: models.DispositionInfoOnly,
+		Sentiment: models.SentimentModel{
+			Start:      "neutral",
+			End:        "neutral",
+			Trajectory: "stable",
+		},
+	}, nil
+}
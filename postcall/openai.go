@@ -0,0 +1,149 @@
+package postcall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIClient implements Extractor and Summarizer on the Chat
+// Completions API with JSON schema response formats.
+type OpenAIClient struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewOpenAIClient(apiKey, model string) *OpenAIClient {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIClient{
+		APIKey:  apiKey,
+		Model:   model,
+		BaseURL: baseURL,
+		Client:  tracing.HTTPClient(60 * time.Second),
+	}
+}
+
+const extractionPrompt = `You extract structured data from phone call transcripts between an insurance claims agent ("AI") and a caller ("User").
+Fill the fields using only what was said in the call. Leave a field empty or false when the caller did not provide it. Do not guess.`
+
+func (o *OpenAIClient) Extract(ctx context.Context, transcript []models.TranscriptModel, schema map[string]interface{}) (map[string]interface{}, error) {
+	content, err := o.complete(ctx, extractionPrompt, formatTranscript(transcript), map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "call_extraction",
+			"schema": schema,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("decode extraction: %w", err)
+	}
+	return result, nil
+}
+
+// complete sends a single system+user chat completion and returns the
+// assistant message content.
+func (o *OpenAIClient) complete(ctx context.Context, system, user string, responseFormat map[string]interface{}) (string, error) {
+	reqBody := map[string]interface{}{
+		"model": o.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": user},
+		},
+		"temperature":     0,
+		"response_format": responseFormat,
+	}
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.BaseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode response (status %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil {
+			return "", fmt.Errorf("openai api returned %s: %s", resp.Status, out.Error.Message)
+		}
+		return "", fmt.Errorf("openai api returned error status: %s", resp.Status)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("openai api returned no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+const summaryPrompt = `You review phone calls between an insurance claims agent ("AI") and a caller ("User") for a supervisor.
+Write a one-paragraph summary, pick the disposition:
+- claim_filed: a claim was registered and a reference number given
+- info_only: the caller only asked for information
+- transferred: the caller was handed to a human or another team
+- dropped: the call ended before the caller's need was handled
+and rate the caller's sentiment at the start and end of the call and how it moved.`
+
+func (o *OpenAIClient) Summarize(ctx context.Context, transcript []models.TranscriptModel) (*Analysis, error) {
+	content, err := o.complete(ctx, summaryPrompt, formatTranscript(transcript), map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "call_analysis",
+			"strict": true,
+			"schema": analysisSchema,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var analysis Analysis
+	if err := json.Unmarshal([]byte(content), &analysis); err != nil {
+		return nil, fmt.Errorf("decode analysis: %w", err)
+	}
+	return &analysis, nil
+}
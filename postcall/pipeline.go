@@ -0,0 +1,186 @@
+package postcall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/claims"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/metrics"
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/rabbitmq"
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+)
+
+// transcriptGrace gives the transcript consumer time to drain the last
+// turns into Redis; they travel on a different queue than call-ended.
+const transcriptGrace = 2 * time.Second
+
+// defaultWorkers is how many calls are processed at once when
+// POSTCALL_WORKERS is unset.
+const defaultWorkers = 4
+
+const stepTimeout = 90 * time.Second
+
+var logger = logging.For("postcall")
+
+// Pipeline runs the post-call steps for a finished call. Nil steps are skipped.
+type Pipeline struct {
+	Extractor  Extractor
+	Summarizer Summarizer
+
+	// TranscriptGrace is how long a call waits for its last transcript
+	// lines before it is analyzed.
+	TranscriptGrace time.Duration
+	// Workers is how many calls are processed at once.
+	Workers int
+}
+
+// NewPipelineFromEnv builds the pipeline configured by environment.
+func NewPipelineFromEnv() (*Pipeline, error) {
+	extractor, err := NewExtractorFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	summarizer, err := NewSummarizerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	workers := defaultWorkers
+	if v := os.Getenv("POSTCALL_WORKERS"); v != "" {
+		if workers, err = strconv.Atoi(v); err != nil || workers < 1 {
+			return nil, fmt.Errorf("invalid POSTCALL_WORKERS %q", v)
+		}
+	}
+	return &Pipeline{
+		Extractor:       extractor,
+		Summarizer:      summarizer,
+		TranscriptGrace: transcriptGrace,
+		Workers:         workers,
+	}, nil
+}
+
+// HandleCallEnded is the call-ended event handler: it stores the call
+// record and its usage, then analyzes the transcript and extracts structured data.
+func (p *Pipeline) HandleCallEnded(ctx context.Context, call models.CallModel) {
+	log := logger.With("call_id", call.CallId, "agent", call.Agent)
+	log.InfoContext(ctx, "processing call")
+
+	if err := redisClient.SaveCall(call); err != nil {
+		log.ErrorContext(ctx, "failed to save call", "error", err)
+		return
+	}
+	if call.Usage != nil {
+		if err := redisClient.AddDailyUsage(call.StartedAt, *call.Usage); err != nil {
+			log.ErrorContext(ctx, "failed to add daily usage", "error", err)
+		}
+	}
+
+	select {
+	case <-time.After(p.TranscriptGrace):
+	case <-ctx.Done():
+		return
+	}
+	transcript := redisClient.GetAllTranscript(call.CallId)
+
+	p.analyze(ctx, &call, transcript)
+	if call.Disposition != "" {
+		metrics.CallDispositions.WithLabelValues(call.Agent, call.Disposition).Inc()
+	}
+	extracted := p.extract(ctx, &call, transcript)
+
+	if err := redisClient.SaveCall(call); err != nil {
+		log.ErrorContext(ctx, "failed to save post-call results", "error", err)
+	}
+
+	if extracted {
+		if err := rabbitmq.PublishExtraction(ctx, models.ExtractionModel{
+			CallId: call.CallId,
+			Agent:  call.Agent,
+			Data:   call.Extraction,
+		}); err != nil {
+			log.ErrorContext(ctx, "failed to publish extraction", "error", err)
+		}
+	}
+}
+
+func (p *Pipeline) analyze(ctx context.Context, call *models.CallModel, transcript []models.TranscriptModel) {
+	ctx, span := tracing.Tracer().Start(ctx, "postcall.analyze")
+	defer span.End()
+
+	// The bridge sets a disposition only when it ended the call itself
+	// (a limit, an answering machine); that stands, whatever the
+	// transcript suggests
+	if ended := call.Disposition; ended != "" {
+		defer func() { call.Disposition = ended }()
+	}
+
+	if !hasCallerTurn(transcript) {
+		call.Disposition = models.DispositionDropped
+		return
+	}
+	if p.Summarizer != nil {
+		ctx, cancel := context.WithTimeout(ctx, stepTimeout)
+		defer cancel()
+
+		analysis, err := p.Summarizer.Summarize(ctx, transcript)
+		if err != nil {
+			logger.ErrorContext(ctx, "summary failed", "call_id", call.CallId, "error", err)
+			span.RecordError(err)
+		} else {
+			call.Summary = analysis.Summary
+			call.Disposition = analysis.Disposition
+			call.Sentiment = &analysis.Sentiment
+		}
+	}
+
+	// A stored claim is authoritative, whatever the transcript suggests
+	if call.ClaimRef != "" {
+		claim, err := claims.Get(call.ClaimRef)
+		if err != nil {
+			logger.ErrorContext(ctx, "claim lookup failed", "call_id", call.CallId, "claim_ref", call.ClaimRef, "error", err)
+		} else if claim != nil {
+			call.Disposition = models.DispositionClaimFiled
+		}
+	}
+}
+
+func (p *Pipeline) extract(ctx context.Context, call *models.CallModel, transcript []models.TranscriptModel) bool {
+	schema := agent.Get(call.Agent).ExtractionSchema
+	if p.Extractor == nil || schema == nil {
+		return false
+	}
+	if len(transcript) == 0 {
+		logger.InfoContext(ctx, "no transcript, skipping extraction", "call_id", call.CallId)
+		return false
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "postcall.extract")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, stepTimeout)
+	defer cancel()
+
+	data, err := p.Extractor.Extract(ctx, transcript, schema)
+	if err != nil {
+		logger.ErrorContext(ctx, "extraction failed", "call_id", call.CallId, "error", err)
+		span.RecordError(err)
+		return false
+	}
+	call.Extraction = data
+	return true
+}
+
+func hasCallerTurn(transcript []models.TranscriptModel) bool {
+	for _, t := range transcript {
+		if t.Role == "User" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package postcall
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// Analysis is the supervisor-facing outcome of a call.
+type Analysis struct {
+	Summary     string                `json:"summary"`
+	Disposition string                `json:"disposition"`
+	Sentiment   models.SentimentModel `json:"sentiment"`
+}
+
+// Summarizer produces the summary, disposition and sentiment of a call.
+type Summarizer interface {
+	Summarize(ctx context.Context, transcript []models.TranscriptModel) (*Analysis, error)
+}
+
+// NewSummarizerFromEnv picks the summarizer named by POSTCALL_SUMMARIZER:
+// "openai" (default), "fake" or "off". It returns nil for "off".
+func NewSummarizerFromEnv() (Summarizer, error) {
+	switch name := os.Getenv("POSTCALL_SUMMARIZER"); name {
+	case "", "openai":
+		return NewOpenAIClient(os.Getenv("OPENAI_API_KEY"), os.Getenv("POSTCALL_MODEL")), nil
+	case "fake":
+		return &FakeSummarizer{}, nil
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown POSTCALL_SUMMARIZER %q", name)
+	}
+}
+
+var sentimentLevels = []string{"positive", "neutral", "negative"}
+
+// analysisSchema is the response format for OpenAIClient.Summarize.
+var analysisSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"summary": map[string]interface{}{
+			"type":        "string",
+			"description": "One paragraph summary of the call for a supervisor.",
+		},
+		"disposition": map[string]interface{}{
+			"type": "string",
+			"enum": []string{
+				models.DispositionClaimFiled,
+				models.DispositionInfoOnly,
+				models.DispositionTransferred,
+				models.DispositionDropped,
+			},
+		},
+		"sentiment": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"start":      map[string]interface{}{"type": "string", "enum": sentimentLevels},
+				"end":        map[string]interface{}{"type": "string", "enum": sentimentLevels},
+				"trajectory": map[string]interface{}{"type": "string", "enum": []string{"improving", "stable", "declining"}},
+			},
+			"required":             []string{"start", "end", "trajectory"},
+			"additionalProperties": false,
+		},
+	},
+	"required":             []string{"summary", "disposition", "sentiment"},
+	"additionalProperties": false,
+}
@@ -0,0 +1,136 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/metrics"
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// postCallQueue is shared by all instances so each call-ended event is
+// processed once.
+const postCallQueue = "postcall"
+
+func publishJSON(ctx context.Context, exchange string, body interface{}) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+exchange, trace.WithSpanKind(trace.SpanKindProducer))
+	defer func() {
+		if err != nil {
+			metrics.PublishFailures.WithLabelValues(exchange).Inc()
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	ch, err := channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := ch.ExchangeDeclare(exchange, "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return ch.PublishWithContext(ctx, exchange, "", false, false, amqp091.Publishing{
+		ContentType:  "application/json",
+		Headers:      tracing.InjectAMQP(ctx),
+		DeliveryMode: amqp091.Persistent,
+		Body:         data,
+	})
+}
+
+// PublishCallEnded announces that a call's media stream has closed.
+func PublishCallEnded(ctx context.Context, call models.CallModel) {
+	if err := publishJSON(ctx, CallEnded, call); err != nil {
+		logger.ErrorContext(ctx, "failed to publish call ended", "call_id", call.CallId, "error", err)
+		return
+	}
+	logger.InfoContext(ctx, "call ended published", "call_id", call.CallId)
+}
+
+// PublishExtraction hands the post-call extraction to downstream consumers.
+func PublishExtraction(ctx context.Context, extraction models.ExtractionModel) error {
+	if err := publishJSON(ctx, CallExtraction, extraction); err != nil {
+		return fmt.Errorf("publish extraction for %s: %w", extraction.CallId, err)
+	}
+	logger.InfoContext(ctx, "extraction published", "call_id", extraction.CallId)
+	return nil
+}
+
+// CallEndedConsumer runs handle for every call-ended event, with ctx
+// continuing the publisher's trace, on up to workers events at a time. It
+// returns when the deliveries stop, e.g. because the broker went away;
+// callers run it again to reconnect. Events still being handled then are
+// redelivered.
+func CallEndedConsumer(workers int, handle func(ctx context.Context, call models.CallModel)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ch, err := channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := ch.ExchangeDeclare(CallEnded, "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+	q, err := ch.QueueDeclare(postCallQueue, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	if err := ch.QueueBind(q.Name, "", CallEnded, false, nil); err != nil {
+		return err
+	}
+	// The broker hands out no more events than there are workers to take them
+	if err := ch.Qos(workers, 0, false); err != nil {
+		return err
+	}
+
+	msgs, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	logger.Info("waiting for call ended events", "workers", workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range msgs {
+				handleCallEnded(d, handle)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.New("call ended deliveries stopped")
+}
+
+func handleCallEnded(d amqp091.Delivery, handle func(ctx context.Context, call models.CallModel)) {
+	var call models.CallModel
+	if err := json.Unmarshal(d.Body, &call); err != nil {
+		logger.Warn("dropping malformed call ended event", "error", err)
+		d.Nack(false, false)
+		return
+	}
+
+	ctx := tracing.ExtractAMQP(context.Background(), d.Headers)
+	ctx, span := tracing.Tracer().Start(ctx, "consume "+CallEnded, trace.WithSpanKind(trace.SpanKindConsumer))
+	handle(ctx, call)
+	span.End()
+	if err := d.Ack(false); err != nil {
+		logger.WarnContext(ctx, "failed to ack call ended event", "call_id", call.CallId, "error", err)
+	}
+}
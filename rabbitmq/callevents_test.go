@@ -0,0 +1,59 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/rabbitmq/amqp091-go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCallEndedHandlerContinuesPublisherTrace(t *testing.T) {
+	exporter := tracing.SetupInMemory()
+	const callId = "6b3c2a9e-1f4d-4c7a-9e21-0d5f8a7b3c11"
+
+	// What publishJSON puts on the wire
+	ctx, publish := tracing.Tracer().Start(tracing.ContextForCall(context.Background(), callId), "publish "+CallEnded, trace.WithSpanKind(trace.SpanKindProducer))
+	body, _ := json.Marshal(models.CallModel{CallId: callId})
+	d := amqp091.Delivery{Headers: tracing.InjectAMQP(ctx), Body: body}
+	publish.End()
+
+	var handled trace.SpanContext
+	handleCallEnded(d, func(ctx context.Context, call models.CallModel) {
+		if call.CallId != callId {
+			t.Errorf("call id = %q", call.CallId)
+		}
+		_, span := tracing.Tracer().Start(ctx, "postcall.analyze")
+		handled = span.SpanContext()
+		span.End()
+	})
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range exporter.GetSpans().Snapshots() {
+		spans[s.Name()] = s
+	}
+	pub, sub, step := spans["publish "+CallEnded], spans["consume "+CallEnded], spans["postcall.analyze"]
+	if pub == nil || sub == nil || step == nil {
+		t.Fatalf("missing spans, got %v", spans)
+	}
+
+	traceID, rootID := tracing.CallIDs(callId)
+	for _, s := range []sdktrace.ReadOnlySpan{pub, sub, step} {
+		if s.SpanContext().TraceID() != traceID {
+			t.Errorf("%s is in trace %s, want the call's %s", s.Name(), s.SpanContext().TraceID(), traceID)
+		}
+	}
+	if pub.Parent().SpanID() != rootID {
+		t.Errorf("publish parent = %s, want the call root %s", pub.Parent().SpanID(), rootID)
+	}
+	if sub.Parent().SpanID() != pub.SpanContext().SpanID() {
+		t.Errorf("consume parent = %s, want publish %s", sub.Parent().SpanID(), pub.SpanContext().SpanID())
+	}
+	if step.Parent().SpanID() != sub.SpanContext().SpanID() || !handled.Equal(step.SpanContext()) {
+		t.Errorf("handler span is not a child of the consume span")
+	}
+}
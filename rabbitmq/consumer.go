@@ -1,54 +1,61 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 
 	"github.com/AVVKavvk/openai-vobiz/models"
 	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func RabbitMQConsumer() {
-
-	ch, _ := RabbitMQConn.Channel()
-
-	err := ch.ExchangeDeclare(Transcript, "direct", true, false, false, false, nil)
+// RabbitMQConsumer stores every transcript line in Redis. It returns when
+// the deliveries stop, e.g. because the broker went away; callers run it
+// again to reconnect.
+func RabbitMQConsumer() error {
+	ch, err := channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
 
+	err = ch.ExchangeDeclare(Transcript, "direct", true, false, false, false, nil)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	q, err := ch.QueueDeclare("", false, false, true, false, nil)
-
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	err = ch.QueueBind(q.Name, "", Transcript, false, nil)
-
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	msgs, err := ch.Consume(q.Name, "", true, false, false, false, nil)
-
 	if err != nil {
-		panic(err)
+		return err
 	}
-	log.Printf(" [*] Waiting for logs. To exit press CTRL+C")
+	logger.Info("waiting for transcript lines")
 	for d := range msgs {
 		var transcript models.TranscriptModel
-		err := json.Unmarshal(d.Body, &transcript)
-		if err != nil {
-			panic(err)
+		if err := json.Unmarshal(d.Body, &transcript); err != nil {
+			logger.Warn("dropping malformed transcript line", "error", err)
+			continue
 		}
-		log.Printf(" [x] %s", d.Body)
-
+		ctx := tracing.ExtractAMQP(context.Background(), d.Headers)
+		logger.DebugContext(ctx, "transcript line received", "call_id", transcript.CallId, "role", transcript.Role)
+		_, span := tracing.Tracer().Start(ctx, "consume "+Transcript, trace.WithSpanKind(trace.SpanKindConsumer))
 		err = redisClient.
 			AppendTranscript(transcript, transcript.CallId)
-
 		if err != nil {
-			panic(err)
+			span.RecordError(err)
+			logger.ErrorContext(ctx, "failed to store transcript line", "call_id", transcript.CallId, "error", err)
 		}
+		span.End()
 	}
-
+	return errors.New("transcript deliveries stopped")
 }
@@ -3,32 +3,53 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
 
+	"github.com/AVVKavvk/openai-vobiz/metrics"
 	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
 	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func RabbitMQProducer(body models.TranscriptModel) {
-	ch, _ := RabbitMQConn.Channel()
-	defer ch.Close()
+	RabbitMQProducerWithContext(context.Background(), body)
+}
+
+// RabbitMQProducerWithContext publishes a transcript line carrying the
+// trace context of ctx in the message headers.
+func RabbitMQProducerWithContext(ctx context.Context, body models.TranscriptModel) {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+Transcript, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
 
-	err := ch.ExchangeDeclare(Transcript, "direct", true, false, false, false, nil)
+	// A lost transcript line must not take the call down with it
+	fail := func(err error) {
+		span.RecordError(err)
+		metrics.PublishFailures.WithLabelValues(Transcript).Inc()
+		logger.ErrorContext(ctx, "failed to publish transcript line", "call_id", body.CallId, "error", err)
+	}
 
+	ch, err := channel()
 	if err != nil {
-		panic(err)
+		fail(err)
+		return
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(Transcript, "direct", true, false, false, false, nil)
+	if err != nil {
+		fail(err)
+		return
 	}
 
 	bodystr, err := json.Marshal(body)
 	if err != nil {
-		// Handle the error (e.g., return it or log it)
-		fmt.Println("Error encoding JSON:", err)
+		logger.ErrorContext(ctx, "failed to encode transcript line", "call_id", body.CallId, "error", err)
 		return
 	}
-	err = ch.PublishWithContext(context.Background(), Transcript, "", false, false, amqp091.Publishing{ContentType: "text/plan", Body: bodystr})
+	err = ch.PublishWithContext(ctx, Transcript, "", false, false, amqp091.Publishing{ContentType: "text/plan", Headers: tracing.InjectAMQP(ctx), Body: bodystr})
 	if err != nil {
-		panic(err)
+		fail(err)
+		return
 	}
-	log.Printf(" [x] Sent %s", body)
+	logger.DebugContext(ctx, "transcript line published", "call_id", body.CallId, "role", body.Role)
 }
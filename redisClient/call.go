@@ -0,0 +1,87 @@
+package redisClient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/go-redis/redis"
+)
+
+// callIndex is a sorted set of call IDs scored by start time.
+const callIndex = "calls"
+
+func SaveCall(call models.CallModel) error {
+	rc := GetRedisClient()
+	key := "call:" + call.CallId
+
+	data, err := call.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	pipe := rc.TxPipeline()
+	pipe.Set(key, data, 0)
+	pipe.ZAdd(callIndex, redis.Z{Score: float64(call.StartedAt.Unix()), Member: call.CallId})
+	_, err = pipe.Exec()
+	return err
+}
+
+// GetCall returns the call record, or nil if it does not exist.
+func GetCall(callId string) (*models.CallModel, error) {
+	rc := GetRedisClient()
+	key := "call:" + callId
+
+	data, err := rc.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var call models.CallModel
+	if err := call.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+// ListCalls returns calls started between since and until, newest first.
+func ListCalls(since, until time.Time) ([]models.CallModel, error) {
+	rc := GetRedisClient()
+
+	ids, err := rc.ZRevRangeByScore(callIndex, redis.ZRangeBy{
+		Min: fmt.Sprint(since.Unix()),
+		Max: fmt.Sprint(until.Unix()),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "call:" + id
+	}
+	values, err := rc.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]models.CallModel, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var call models.CallModel
+		if err := call.UnmarshalBinary([]byte(data)); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
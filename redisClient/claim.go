@@ -0,0 +1,91 @@
+package redisClient
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/go-redis/redis"
+)
+
+// ErrClaimConflict is returned by UpdateClaim when the claim kept changing
+// under it and every attempt lost the race.
+var ErrClaimConflict = errors.New("claim changed concurrently")
+
+const claimUpdateAttempts = 3
+
+// ReserveClaimRef marks a claim reference as taken for ttl. It returns
+// false if the reference was already reserved. UpdateClaim makes the
+// reservation permanent once a claim is stored under it.
+func ReserveClaimRef(ref string, callId string, ttl time.Duration) (bool, error) {
+	rc := GetRedisClient()
+	return rc.SetNX("claimref:"+ref, callId, ttl).Result()
+}
+
+// UpdateClaim reads the claim for ref (nil if it does not exist), passes it
+// to update and stores the result, all in one transaction: a concurrent
+// write to the claim makes the attempt start over. An error from update
+// aborts without writing.
+func UpdateClaim(ref string, update func(existing *models.ClaimModel) (models.ClaimModel, error)) (*models.ClaimModel, error) {
+	rc := GetRedisClient()
+	key := "claim:" + ref
+
+	var saved models.ClaimModel
+	txf := func(tx *redis.Tx) error {
+		existing, err := getClaim(tx, key)
+		if err != nil {
+			return err
+		}
+		claim, err := update(existing)
+		if err != nil {
+			return err
+		}
+		data, err := claim.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, data, 0)
+			pipe.Persist("claimref:" + ref)
+			return nil
+		})
+		if err == nil {
+			saved = claim
+		}
+		return err
+	}
+
+	for i := 0; i < claimUpdateAttempts; i++ {
+		err := rc.Watch(txf, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &saved, nil
+	}
+	return nil, ErrClaimConflict
+}
+
+// GetClaim returns the claim for ref, or nil if it does not exist.
+func GetClaim(ref string) (*models.ClaimModel, error) {
+	return getClaim(GetRedisClient(), "claim:"+ref)
+}
+
+func getClaim(rc redis.Cmdable, key string) (*models.ClaimModel, error) {
+	data, err := rc.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var claim models.ClaimModel
+	if err := claim.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
@@ -1,33 +1,47 @@
 package redisClient
 
 import (
-	"log"
+	"os"
 	"sync"
 
+	"github.com/AVVKavvk/openai-vobiz/logging"
 	"github.com/go-redis/redis"
 )
 
 var (
 	rc   *redis.Client = nil
 	once sync.Once
+
+	logger = logging.For("redis")
 )
 
+// GetRedisClient returns the shared client for REDIS_ADDR (localhost:6379
+// by default) and REDIS_PASSWORD. The client connects on first use.
 func GetRedisClient() *redis.Client {
-	return rc
-}
-func init() {
 	once.Do(func() {
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		password, ok := os.LookupEnv("REDIS_PASSWORD")
+		if !ok {
+			password = "mypass"
+		}
 		rc = redis.NewClient(&redis.Options{
-			Addr:     "localhost:6379",
-			Password: "mypass",
+			Addr:     addr,
+			Password: password,
 			DB:       0, // use default DB
 		})
-		_, err := rc.Ping().Result()
-		if err != nil {
-			log.Fatalf("Could not connect to Redis: %v", err)
-		}
-
-		log.Println("redis client successfully connected")
-
 	})
+	return rc
+}
+
+// Connect checks that Redis is reachable; main calls it so a missing
+// Redis fails startup rather than the first call.
+func Connect() error {
+	if _, err := GetRedisClient().Ping().Result(); err != nil {
+		return err
+	}
+	logger.Info("redis client connected")
+	return nil
 }
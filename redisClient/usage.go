@@ -0,0 +1,99 @@
+package redisClient
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// usageDay is the layout of the date in daily usage keys (UTC).
+const usageDay = "2006-01-02"
+
+// Daily usage lives in one hash per day and model,
+// usage:daily:<date>:<provider>:<model>, listed in the set usage:daily:<date>.
+func dailyUsageKey(day string) string {
+	return "usage:daily:" + day
+}
+
+// AddDailyUsage adds one call's usage to the totals of the UTC day it started on.
+func AddDailyUsage(startedAt time.Time, u models.UsageModel) error {
+	rc := GetRedisClient()
+	day := startedAt.UTC().Format(usageDay)
+	member := u.Provider + ":" + u.Model
+	key := dailyUsageKey(day) + ":" + member
+
+	pipe := rc.TxPipeline()
+	pipe.SAdd(dailyUsageKey(day), member)
+	pipe.HIncrBy(key, "calls", 1)
+	pipe.HIncrBy(key, "inputTextTokens", int64(u.InputTextTokens))
+	pipe.HIncrBy(key, "inputAudioTokens", int64(u.InputAudioTokens))
+	pipe.HIncrBy(key, "cachedTextTokens", int64(u.CachedTextTokens))
+	pipe.HIncrBy(key, "cachedAudioTokens", int64(u.CachedAudioTokens))
+	pipe.HIncrBy(key, "outputTextTokens", int64(u.OutputTextTokens))
+	pipe.HIncrBy(key, "outputAudioTokens", int64(u.OutputAudioTokens))
+	pipe.HIncrByFloat(key, "inputAudioSeconds", u.InputAudioSeconds)
+	pipe.HIncrByFloat(key, "outputAudioSeconds", u.OutputAudioSeconds)
+	pipe.HIncrByFloat(key, "estimatedCostUsd", u.EstimatedCostUSD)
+	if !u.Priced {
+		pipe.HSet(key, "unpriced", 1)
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
+// ListDailyUsage returns the daily totals per model for every UTC day
+// from since to until, inclusive.
+func ListDailyUsage(since, until time.Time) ([]models.DailyUsageModel, error) {
+	rc := GetRedisClient()
+
+	days := []models.DailyUsageModel{}
+	for d := since.UTC().Truncate(24 * time.Hour); !d.After(until.UTC()); d = d.AddDate(0, 0, 1) {
+		day := d.Format(usageDay)
+
+		members, err := rc.SMembers(dailyUsageKey(day)).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			fields, err := rc.HGetAll(dailyUsageKey(day) + ":" + member).Result()
+			if err != nil {
+				return nil, err
+			}
+			provider, model, _ := strings.Cut(member, ":")
+			days = append(days, dailyUsageFromHash(day, provider, model, fields))
+		}
+	}
+	return days, nil
+}
+
+func dailyUsageFromHash(day, provider, model string, fields map[string]string) models.DailyUsageModel {
+	atoi := func(k string) int {
+		n, _ := strconv.Atoi(fields[k])
+		return n
+	}
+	atof := func(k string) float64 {
+		f, _ := strconv.ParseFloat(fields[k], 64)
+		return f
+	}
+
+	return models.DailyUsageModel{
+		Date:  day,
+		Calls: atoi("calls"),
+		UsageModel: models.UsageModel{
+			Provider:           provider,
+			Model:              model,
+			InputTextTokens:    atoi("inputTextTokens"),
+			InputAudioTokens:   atoi("inputAudioTokens"),
+			CachedTextTokens:   atoi("cachedTextTokens"),
+			CachedAudioTokens:  atoi("cachedAudioTokens"),
+			OutputTextTokens:   atoi("outputTextTokens"),
+			OutputAudioTokens:  atoi("outputAudioTokens"),
+			InputAudioSeconds:  atof("inputAudioSeconds"),
+			OutputAudioSeconds: atof("outputAudioSeconds"),
+			EstimatedCostUSD:   atof("estimatedCostUsd"),
+			Priced:             fields["unpriced"] == "",
+		},
+	}
+}
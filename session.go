@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetSession returns the session settings of a live call.
+func HandleGetSession(c echo.Context) error {
+	s, err := catalog.Current(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Call is not live on this instance")
+	}
+	return c.JSON(http.StatusOK, s)
+}
+
+// HandleUpdateSession changes the voice, temperature or VAD threshold of a
+// live call, for supervisors. Fields left out keep their value. Gemini
+// calls refuse every change, as their settings are fixed at setup.
+func HandleUpdateSession(c echo.Context) error {
+	id := c.Param("id")
+
+	var change catalog.Settings
+	if err := c.Bind(&change); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid JSON body")
+	}
+
+	s, err := catalog.Update(id, change)
+	switch {
+	case errors.Is(err, catalog.ErrNotLive):
+		return echo.NewHTTPError(http.StatusNotFound, "Call is not live on this instance")
+	case errors.Is(err, catalog.ErrInvalid):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err != nil:
+		// The provider cannot make this change on a live session
+		logger.InfoContext(c.Request().Context(), "session change refused", "call_id", id, "error", err)
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	logger.InfoContext(c.Request().Context(), "session changed by supervisor", "call_id", id)
+	return c.JSON(http.StatusOK, s)
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+// Call is the per-call context handed to tool handlers.
+type Call struct {
+	CallId   string
+	From     string
+	To       string
+	ClaimRef string // claim reference reserved for this call
+}
+
+// Handler runs a tool with the arguments the model produced and returns
+// the JSON-serialisable result sent back to the model.
+type Handler func(ctx context.Context, call Call, args map[string]interface{}) map[string]interface{}
+
+// Tool is a function exposed to the model. Parameters is a JSON schema
+// object and is sent to both OpenAI and Gemini as-is.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+	Handler     Handler
+	// PhoneOnly tools need a call, e.g. keypad input; text chat leaves
+	// them out
+	PhoneOnly bool
+}
+
+var logger = logging.For("tools")
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Tool{}
+	order    []string
+)
+
+// Register adds a tool to the shared registry. Registering the same name
+// twice replaces the earlier tool.
+func Register(t Tool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := registry[t.Name]; !exists {
+		order = append(order, t.Name)
+	}
+	registry[t.Name] = t
+}
+
+// List returns all registered tools in registration order.
+func List() []Tool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Tool, 0, len(order))
+	for _, name := range order {
+		list = append(list, registry[name])
+	}
+	return list
+}
+
+// ListChat returns the tools usable in text chat, in registration order.
+func ListChat() []Tool {
+	list := []Tool{}
+	for _, t := range List() {
+		if !t.PhoneOnly {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// Lookup returns the registered tool with the given name.
+func Lookup(name string) (Tool, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Execute runs a registered tool. Unknown tools return an error payload
+// so the model can recover instead of waiting forever.
+func Execute(ctx context.Context, call Call, name string, args map[string]interface{}) map[string]interface{} {
+	t, ok := Lookup(name)
+	if !ok {
+		logger.WarnContext(ctx, "unknown tool requested", "tool", name, "call_id", call.CallId)
+		return map[string]interface{}{"error": "unknown tool: " + name}
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return t.Handler(ctx, call, args)
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+)
+
+// amqpCarrier adapts AMQP message headers to a propagation.TextMapCarrier.
+type amqpCarrier amqp091.Table
+
+func (c amqpCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c amqpCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c amqpCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InjectAMQP returns message headers carrying the trace context of ctx.
+func InjectAMQP(ctx context.Context) amqp091.Table {
+	headers := amqp091.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, amqpCarrier(headers))
+	return headers
+}
+
+// ExtractAMQP returns ctx continuing the trace carried in message headers.
+func ExtractAMQP(ctx context.Context, headers amqp091.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, amqpCarrier(headers))
+}
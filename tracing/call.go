@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Every request belonging to a call (answer URL, /incoming-call,
+// /stream, queue consumers) is traced under the same trace ID, derived
+// from the Vobiz CallUUID, and parented to the call's root span, whose
+// span ID is derived the same way. No state has to be shared between the
+// requests to correlate them.
+
+type callRootKey struct{}
+
+// CallIDs returns the trace ID and root span ID for a call. A UUID is
+// used as the trace ID verbatim so traces can be looked up by CallUUID.
+func CallIDs(callUUID string) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+
+	sum := sha256.Sum256([]byte("call:" + callUUID))
+	if b, err := hex.DecodeString(strings.ReplaceAll(callUUID, "-", "")); err == nil && len(b) == len(traceID) {
+		copy(traceID[:], b)
+	} else {
+		copy(traceID[:], sum[:16])
+	}
+	copy(spanID[:], sum[16:24])
+	return traceID, spanID
+}
+
+// StartCall starts the root span for a call. It ignores any parent in ctx.
+func StartCall(ctx context.Context, callUUID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("call.uuid", callUUID))
+	_, span := Tracer().Start(context.WithValue(ctx, callRootKey{}, callUUID), "call",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	// Don't leak the root marker to descendants
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// ContextForCall returns ctx parented to the call's root span, so spans
+// started from it join the call's trace even from another request.
+func ContextForCall(ctx context.Context, callUUID string) context.Context {
+	if callUUID == "" {
+		return ctx
+	}
+	traceID, spanID := CallIDs(callUUID)
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+// callIDGenerator issues the derived IDs for call root spans and random
+// IDs for everything else.
+type callIDGenerator struct{}
+
+func (callIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if callUUID, ok := ctx.Value(callRootKey{}).(string); ok {
+		return CallIDs(callUUID)
+	}
+	var traceID trace.TraceID
+	rand.Read(traceID[:])
+	return traceID, randomSpanID()
+}
+
+func (callIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	return randomSpanID()
+}
+
+func randomSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	rand.Read(spanID[:])
+	return spanID
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span per request. Requests that carry a
+// Vobiz call UUID (CallUUID form value or calluuid query parameter) join
+// that call's trace; others continue any incoming traceparent.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Path() == "/metrics" {
+				return next(c)
+			}
+
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			callUUID := c.QueryParam("calluuid")
+			if callUUID == "" {
+				callUUID = c.FormValue("CallUUID")
+			}
+			ctx = ContextForCall(ctx, callUUID)
+
+			ctx, span := Tracer().Start(ctx, req.Method+" "+c.Path(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", c.Path()),
+				),
+			)
+			defer span.End()
+			if callUUID != "" {
+				span.SetAttributes(attribute.String("call.uuid", callUUID))
+			}
+
+			c.SetRequest(req.WithContext(ctx))
+			err := next(c)
+
+			status := c.Response().Status
+			if he, ok := err.(*echo.HTTPError); ok {
+				status = he.Code
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if err != nil || status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
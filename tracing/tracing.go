@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/AVVKavvk/openai-vobiz"
+
+var logger = logging.For("tracing")
+
+// Tracer returns the tracer used across the bridge.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider selected by
+// OTEL_TRACES_EXPORTER: "otlp" (endpoint from the standard
+// OTEL_EXPORTER_OTLP_* variables) or "none" (default). Spans kept in
+// memory are only readable from tests, which use SetupInMemory.
+// The returned function flushes and stops the provider.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch name := os.Getenv("OTEL_TRACES_EXPORTER"); name {
+	case "", "none":
+		logger.Info("tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		exporter = exp
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", name)
+	}
+
+	tp := newProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	logger.Info("exporting traces", "exporter", os.Getenv("OTEL_TRACES_EXPORTER"))
+	return tp.Shutdown, nil
+}
+
+// SetupInMemory installs a synchronous in-memory provider for tests and
+// returns its exporter.
+func SetupInMemory() *tracetest.InMemoryExporter {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(newProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
+
+func newProvider(opt sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "vobiz-bridge"
+	}
+	return sdktrace.NewTracerProvider(
+		opt,
+		sdktrace.WithIDGenerator(callIDGenerator{}),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+		)),
+	)
+}
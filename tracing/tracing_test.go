@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const callUUID = "6b3c2a9e-1f4d-4c7a-9e21-0d5f8a7b3c11"
+
+func spanNamed(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range exporter.GetSpans() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no %q span in %d exported", name, len(exporter.GetSpans()))
+	return tracetest.SpanStub{}
+}
+
+func TestSetupRejectsMemoryExporter(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "memory")
+	if _, err := Setup(context.Background()); err == nil {
+		t.Fatal("Setup accepted the memory exporter")
+	}
+}
+
+func TestCallRootSpanIsDerivedFromCallUUID(t *testing.T) {
+	exporter := SetupInMemory()
+
+	_, span := StartCall(context.Background(), callUUID)
+	span.End()
+
+	root := spanNamed(t, exporter, "call")
+	traceID, spanID := CallIDs(callUUID)
+	if got := root.SpanContext.TraceID(); got != traceID {
+		t.Errorf("trace id = %s, want %s", got, traceID)
+	}
+	if got := root.SpanContext.TraceID().String(); got != "6b3c2a9e1f4d4c7a9e210d5f8a7b3c11" {
+		t.Errorf("trace id %s is not the call uuid", got)
+	}
+	if got := root.SpanContext.SpanID(); got != spanID {
+		t.Errorf("span id = %s, want %s", got, spanID)
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("root span has parent %s", root.Parent.SpanID())
+	}
+
+	other, _ := CallIDs("another-call")
+	if other == traceID {
+		t.Error("two calls share a trace id")
+	}
+}
+
+func TestContextForCallJoinsRootFromAnotherRequest(t *testing.T) {
+	exporter := SetupInMemory()
+
+	_, root := StartCall(context.Background(), callUUID)
+	root.End()
+
+	// A later request knows only the CallUUID
+	_, tool := Tracer().Start(ContextForCall(context.Background(), callUUID), "tool create_claim")
+	tool.End()
+
+	rootStub := spanNamed(t, exporter, "call")
+	toolStub := spanNamed(t, exporter, "tool create_claim")
+	if toolStub.SpanContext.TraceID() != rootStub.SpanContext.TraceID() {
+		t.Errorf("tool span in trace %s, call in %s", toolStub.SpanContext.TraceID(), rootStub.SpanContext.TraceID())
+	}
+	if toolStub.Parent.SpanID() != rootStub.SpanContext.SpanID() {
+		t.Errorf("tool span parent = %s, want the call span %s", toolStub.Parent.SpanID(), rootStub.SpanContext.SpanID())
+	}
+}
+
+func TestAMQPHeadersCarryTheTrace(t *testing.T) {
+	exporter := SetupInMemory()
+
+	ctx, producer := Tracer().Start(ContextForCall(context.Background(), callUUID), "publish call_ended", trace.WithSpanKind(trace.SpanKindProducer))
+	headers := InjectAMQP(ctx)
+	producer.End()
+
+	if headers["traceparent"] == nil {
+		t.Fatalf("no traceparent in headers %v", headers)
+	}
+
+	_, consumer := Tracer().Start(ExtractAMQP(context.Background(), headers), "consume call_ended", trace.WithSpanKind(trace.SpanKindConsumer))
+	consumer.End()
+
+	pub := spanNamed(t, exporter, "publish call_ended")
+	sub := spanNamed(t, exporter, "consume call_ended")
+	traceID, _ := CallIDs(callUUID)
+	if sub.SpanContext.TraceID() != traceID {
+		t.Errorf("consumer trace = %s, want the call's %s", sub.SpanContext.TraceID(), traceID)
+	}
+	if sub.Parent.SpanID() != pub.SpanContext.SpanID() {
+		t.Errorf("consumer parent = %s, want the publisher %s", sub.Parent.SpanID(), pub.SpanContext.SpanID())
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/labstack/echo/v4"
+)
+
+const defaultUsageDays = 7
+
+// HandleGetCallUsage returns the provider usage and estimated cost of a call.
+func HandleGetCallUsage(c echo.Context) error {
+	id := c.Param("id")
+
+	call, err := redisClient.GetCall(id)
+	if err != nil {
+		logger.ErrorContext(c.Request().Context(), "failed to load call", "call_id", id, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load call")
+	}
+	if call == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Call not found")
+	}
+	if call.Usage == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "No usage recorded for call")
+	}
+
+	return c.JSON(http.StatusOK, call.Usage)
+}
+
+// HandleDailyUsage returns usage and estimated cost per UTC day and model.
+//
+// Query: since, until (YYYY-MM-DD, default the last 7 days).
+func HandleDailyUsage(c echo.Context) error {
+	until := time.Now().UTC()
+	since := until.AddDate(0, 0, -(defaultUsageDays - 1))
+	var err error
+
+	if v := c.QueryParam("since"); v != "" {
+		if since, err = time.Parse(time.DateOnly, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'since', expected YYYY-MM-DD")
+		}
+	}
+	if v := c.QueryParam("until"); v != "" {
+		if until, err = time.Parse(time.DateOnly, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'until', expected YYYY-MM-DD")
+		}
+	}
+	if until.Before(since) {
+		return echo.NewHTTPError(http.StatusBadRequest, "'until' is before 'since'")
+	}
+
+	days, err := redisClient.ListDailyUsage(since, until)
+	if err != nil {
+		logger.ErrorContext(c.Request().Context(), "failed to list daily usage", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list usage")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"count": len(days),
+		"days":  days,
+	})
+}
@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/metrics"
+	"github.com/AVVKavvk/openai-vobiz/models"
+)
+
+// muLawBytesPerSecond is the rate of Vobiz's 8kHz, 8-bit μ-law streams.
+const muLawBytesPerSecond = 8000
+
+// Tokens is one usage report from a provider. Input counts exclude
+// cached tokens.
+type Tokens struct {
+	InputText   int
+	InputAudio  int
+	CachedText  int
+	CachedAudio int
+	OutputText  int
+	OutputAudio int
+}
+
+// Meter accumulates the usage of one call. It is safe for concurrent use
+// by the bridge's reader and writer goroutines.
+type Meter struct {
+	mu    sync.Mutex
+	usage models.UsageModel
+	price Price
+}
+
+// NewMeter starts metering a call on provider's model.
+func NewMeter(provider, model string) *Meter {
+	model = strings.TrimPrefix(model, "models/")
+	price, priced := PriceFor(model)
+	return &Meter{
+		usage: models.UsageModel{Provider: provider, Model: model, Priced: priced},
+		price: price,
+	}
+}
+
+// Add records a provider usage report.
+func (m *Meter) Add(t Tokens) {
+	cost := m.price.Cost(t)
+
+	m.mu.Lock()
+	u := &m.usage
+	u.InputTextTokens += t.InputText
+	u.InputAudioTokens += t.InputAudio
+	u.CachedTextTokens += t.CachedText
+	u.CachedAudioTokens += t.CachedAudio
+	u.OutputTextTokens += t.OutputText
+	u.OutputAudioTokens += t.OutputAudio
+	u.EstimatedCostUSD += cost
+	provider, model := u.Provider, u.Model
+	m.mu.Unlock()
+
+	for kind, n := range map[string]int{
+		"input_text": t.InputText, "input_audio": t.InputAudio,
+		"cached_text": t.CachedText, "cached_audio": t.CachedAudio,
+		"output_text": t.OutputText, "output_audio": t.OutputAudio,
+	} {
+		if n > 0 {
+			metrics.UsageTokens.WithLabelValues(provider, model, kind).Add(float64(n))
+		}
+	}
+	metrics.UsageCost.WithLabelValues(provider, model).Add(cost)
+}
+
+// AddMuLaw records n bytes of 8kHz μ-law forwarded in direction
+// (metrics.Inbound or metrics.Outbound).
+func (m *Meter) AddMuLaw(direction string, n int) {
+	seconds := float64(n) / muLawBytesPerSecond
+
+	m.mu.Lock()
+	if direction == metrics.Inbound {
+		m.usage.InputAudioSeconds += seconds
+	} else {
+		m.usage.OutputAudioSeconds += seconds
+	}
+	provider, model := m.usage.Provider, m.usage.Model
+	m.mu.Unlock()
+
+	metrics.UsageAudioSeconds.WithLabelValues(provider, model, direction).Add(seconds)
+}
+
+// Snapshot returns the usage so far.
+func (m *Meter) Snapshot() models.UsageModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
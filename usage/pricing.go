@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+)
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	InputText   float64 `json:"input_text"`
+	InputAudio  float64 `json:"input_audio"`
+	CachedText  float64 `json:"cached_text"`
+	CachedAudio float64 `json:"cached_audio"`
+	OutputText  float64 `json:"output_text"`
+	OutputAudio float64 `json:"output_audio"`
+}
+
+// Config is the on-disk pricing table (see PRICING_CONFIG). Entries are
+// keyed by model name or model name prefix.
+type Config struct {
+	Models map[string]Price `json:"models"`
+}
+
+// DefaultPricing holds list prices at the time of writing; override them
+// with PRICING_CONFIG rather than editing code when they change.
+var DefaultPricing = Config{Models: map[string]Price{
+	"gpt-realtime-mini": {
+		InputText: 0.60, InputAudio: 10.00,
+		CachedText: 0.06, CachedAudio: 0.30,
+		OutputText: 2.40, OutputAudio: 20.00,
+	},
+	"gpt-realtime": {
+		InputText: 4.00, InputAudio: 32.00,
+		CachedText: 0.40, CachedAudio: 0.40,
+		OutputText: 16.00, OutputAudio: 64.00,
+	},
+	"gemini-2.5-flash-native-audio": {
+		InputText: 0.50, InputAudio: 3.00,
+		CachedText: 0.50, CachedAudio: 3.00,
+		OutputText: 2.00, OutputAudio: 12.00,
+	},
+}}
+
+var logger = logging.For("usage")
+
+var (
+	mu      sync.RWMutex
+	pricing = DefaultPricing.Models
+)
+
+// Load reads the pricing table from path. Its entries replace the
+// built-in ones with the same name; an empty path keeps the defaults.
+func Load(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read pricing config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse pricing config %s: %w", path, err)
+	}
+
+	merged := make(map[string]Price, len(DefaultPricing.Models)+len(cfg.Models))
+	for name, p := range DefaultPricing.Models {
+		merged[name] = p
+	}
+	for name, p := range cfg.Models {
+		if name == "" {
+			return fmt.Errorf("pricing config: model without a name")
+		}
+		merged[name] = p
+	}
+
+	mu.Lock()
+	pricing = merged
+	mu.Unlock()
+	logger.Info("loaded pricing", "models", len(cfg.Models), "path", path)
+	return nil
+}
+
+// PriceFor returns the price of model, matching the longest configured
+// prefix so dated variants such as "...-preview-12-2025" resolve.
+func PriceFor(model string) (Price, bool) {
+	model = strings.TrimPrefix(model, "models/")
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var best string
+	for name := range pricing {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return pricing[best], true
+}
+
+// Cost is the price of t in USD.
+func (p Price) Cost(t Tokens) float64 {
+	return (float64(t.InputText)*p.InputText +
+		float64(t.InputAudio)*p.InputAudio +
+		float64(t.CachedText)*p.CachedText +
+		float64(t.CachedAudio)*p.CachedAudio +
+		float64(t.OutputText)*p.OutputText +
+		float64(t.OutputAudio)*p.OutputAudio) / 1e6
+}
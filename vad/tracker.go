@@ -0,0 +1,68 @@
+package vad
+
+// Event is a change in whether the speaker is talking.
+type Event int
+
+const (
+	None  Event = iota
+	Start       // an utterance started
+	End         // the utterance ended
+)
+
+func (e Event) String() string {
+	switch e {
+	case Start:
+		return "start"
+	case End:
+		return "end"
+	}
+	return "none"
+}
+
+// Tracker smooths frame labels into utterances: StartFrames speech frames
+// in a row start one, EndFrames non-speech frames in a row end it.
+type Tracker struct {
+	StartFrames int
+	EndFrames   int
+
+	speaking bool
+	run      int // speech frames in a row
+	quiet    int // non-speech frames in a row
+}
+
+// NewTracker returns a tracker with the usual 60ms attack and 500ms
+// hangover for 20ms frames.
+func NewTracker() *Tracker {
+	return &Tracker{StartFrames: 3, EndFrames: 25}
+}
+
+// Update adds one frame label.
+func (t *Tracker) Update(speech bool) Event {
+	if speech {
+		t.run++
+		t.quiet = 0
+		if !t.speaking && t.run >= t.StartFrames {
+			t.speaking = true
+			return Start
+		}
+		return None
+	}
+
+	t.run = 0
+	t.quiet++
+	if t.speaking && t.quiet >= t.EndFrames {
+		t.speaking = false
+		return End
+	}
+	return None
+}
+
+// Speaking reports whether an utterance is in progress.
+func (t *Tracker) Speaking() bool {
+	return t.speaking
+}
+
+// Silent returns how many frames in a row had no speech.
+func (t *Tracker) Silent() int {
+	return t.quiet
+}
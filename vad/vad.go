@@ -0,0 +1,261 @@
+// Package vad detects speech in 8kHz telephone audio. A Detector labels
+// 20ms frames from their level over an adaptive noise floor and their
+// spectral shape, rejecting steady tones such as DTMF, and a Tracker turns
+// those labels into utterances with start and end events.
+package vad
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+// SampleRate of the audio the detector expects.
+const SampleRate = 8000
+
+// Mode is how aggressively non-speech is rejected, as in WebRTC's VAD:
+// higher modes miss more quiet speech but let through less noise.
+type Mode int
+
+const (
+	Quality Mode = iota
+	LowBitrate
+	Aggressive
+	VeryAggressive
+)
+
+// DefaultMode is used by detectors created without an explicit mode; see Load.
+var DefaultMode = Aggressive
+
+type thresholds struct {
+	snrDB    float64 // level over the noise floor
+	flatness float64 // at most; white noise measures 0.4-0.75 per frame
+	band     float64 // at least this share of energy in 100-3400Hz
+}
+
+var modeThresholds = [...]thresholds{
+	Quality:        {snrDB: 6, flatness: 0.35, band: 0.60},
+	LowBitrate:     {snrDB: 9, flatness: 0.30, band: 0.65},
+	Aggressive:     {snrDB: 12, flatness: 0.25, band: 0.70},
+	VeryAggressive: {snrDB: 15, flatness: 0.20, band: 0.75},
+}
+
+// minLevel is the RMS below which a frame is silence whatever its shape
+const minLevel = 100
+
+// A tone keeps nearly all its energy in its two strongest peaks; voiced
+// speech spreads at least a few percent over its other harmonics. The
+// share left over is averaged across toneFrames frames, so one peaky
+// speech frame is not taken for a tone.
+const (
+	toneFrames   = 3
+	toneResidual = 0.02
+)
+
+// ParseMode accepts 0-3 or a mode name such as "aggressive".
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "quality":
+		return Quality, nil
+	case "low_bitrate", "lowbitrate":
+		return LowBitrate, nil
+	case "aggressive":
+		return Aggressive, nil
+	case "very_aggressive", "veryaggressive":
+		return VeryAggressive, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < int(Quality) || n > int(VeryAggressive) {
+		return 0, fmt.Errorf("vad: unknown mode %q, want 0-3", s)
+	}
+	return Mode(n), nil
+}
+
+// Load sets DefaultMode from s (VAD_MODE); empty keeps the default.
+func Load(s string) error {
+	if s == "" {
+		return nil
+	}
+	m, err := ParseMode(s)
+	if err != nil {
+		return err
+	}
+	DefaultMode = m
+	return nil
+}
+
+// Result describes one frame.
+type Result struct {
+	Speech   bool
+	Level    float64 // RMS
+	SNR      float64 // dB over the noise floor
+	Flatness float64
+	Band     float64
+	// Peak is the share of energy in the two strongest spectral peaks
+	Peak float64
+	// Tone is set when recent frames were a steady tone, e.g. DTMF
+	Tone bool
+}
+
+// Detector labels frames of one audio stream. It is not safe for
+// concurrent use.
+type Detector struct {
+	th    thresholds
+	floor float64 // noise floor in dB
+
+	residual [toneFrames]float64 // 1 - Peak of the latest analysed frames
+	analysed int
+}
+
+// New returns a detector for mode.
+func New(mode Mode) *Detector {
+	if mode < Quality || mode > VeryAggressive {
+		mode = DefaultMode
+	}
+	return &Detector{th: modeThresholds[mode], floor: levelDB(minLevel)}
+}
+
+// Process labels one frame of up to 32ms of 8kHz PCM.
+func (d *Detector) Process(pcm []int16) Result {
+	r := Result{Level: audio.RMS(pcm)}
+	db := levelDB(r.Level)
+	r.SNR = db - d.floor
+
+	if r.Level >= minLevel && r.SNR >= d.th.snrDB {
+		r.Flatness, r.Band, r.Peak = spectralShape(pcm)
+		r.Tone = d.tone(r.Peak)
+		r.Speech = !r.Tone && r.Flatness <= d.th.flatness && r.Band >= d.th.band
+	} else {
+		d.analysed = 0
+	}
+
+	// The floor follows quieter frames quickly and creeps up slowly, so
+	// steady background noise is absorbed but speech is not
+	switch {
+	case db < d.floor:
+		d.floor = 0.7*d.floor + 0.3*db
+	case !r.Speech:
+		d.floor += min(db-d.floor, 0.5) * 0.1
+	default:
+		d.floor += 0.01
+	}
+	d.floor = max(d.floor, levelDB(1))
+	return r
+}
+
+// IsSpeech is Process(pcm).Speech.
+func (d *Detector) IsSpeech(pcm []int16) bool {
+	return d.Process(pcm).Speech
+}
+
+// tone adds one frame's peak share and reports whether the frames since
+// the last quiet one, up to toneFrames of them, look like a steady tone.
+func (d *Detector) tone(peak float64) bool {
+	d.residual[d.analysed%toneFrames] = 1 - peak
+	d.analysed++
+
+	n := min(d.analysed, toneFrames)
+	var sum float64
+	for _, r := range d.residual[:n] {
+		sum += r
+	}
+	return sum/float64(n) < toneResidual
+}
+
+func levelDB(rms float64) float64 {
+	return 20 * math.Log10(rms+1)
+}
+
+const fftSize = 256
+
+// spectralShape returns the spectral flatness over 250-3500Hz, the share
+// of energy in the 100-3400Hz telephone speech band and the share in the
+// two strongest peaks.
+func spectralShape(pcm []int16) (flatness, band, peak float64) {
+	re := make([]float64, fftSize)
+	im := make([]float64, fftSize)
+	n := min(len(pcm), fftSize)
+	for i := 0; i < n; i++ {
+		hann := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		re[i] = float64(pcm[i]) * hann
+	}
+	fft(re, im)
+
+	bin := func(hz float64) int { return int(hz * fftSize / SampleRate) }
+	power := make([]float64, fftSize/2+1)
+	var total, speech, logSum, sum float64
+	count := 0
+	for k := bin(62); k <= fftSize/2; k++ {
+		p := re[k]*re[k] + im[k]*im[k]
+		power[k] = p
+		total += p
+		if k >= bin(100) && k <= bin(3400) {
+			speech += p
+		}
+		if k >= bin(250) && k <= bin(3500) {
+			logSum += math.Log(p + 1e-10)
+			sum += p
+			count++
+		}
+	}
+	if total == 0 || sum == 0 {
+		return 1, 0, 0
+	}
+	mean := sum / float64(count)
+	return math.Exp(logSum/float64(count)) / mean, speech / total, peakShare(power, total)
+}
+
+// peakWidth is how many bins either side of a peak belong to it: the
+// main lobe of a Hann window over a 20ms frame zero-padded to fftSize
+const peakWidth = 4
+
+// peakShare returns the share of total in the two strongest peaks of
+// power. It clears the bins it counts.
+func peakShare(power []float64, total float64) float64 {
+	var inPeaks float64
+	for range 2 {
+		top := 0
+		for k := range power {
+			if power[k] > power[top] {
+				top = k
+			}
+		}
+		for k := max(0, top-peakWidth); k <= min(len(power)-1, top+peakWidth); k++ {
+			inPeaks += power[k]
+			power[k] = 0
+		}
+	}
+	return inPeaks / total
+}
+
+// fft is an in-place radix-2 FFT; len(re) must be a power of two.
+func fft(re, im []float64) {
+	n := len(re)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := -2 * math.Pi / float64(size)
+		for start := 0; start < n; start += size {
+			for k := 0; k < size/2; k++ {
+				wr, wi := math.Cos(step*float64(k)), math.Sin(step*float64(k))
+				a, b := start+k, start+k+size/2
+				tr := wr*re[b] - wi*im[b]
+				ti := wr*im[b] + wi*re[b]
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+			}
+		}
+	}
+}
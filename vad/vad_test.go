@@ -0,0 +1,252 @@
+package vad
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+const frameSamples = SampleRate / 50 // 20ms
+
+var modes = []Mode{Quality, LowBitrate, Aggressive, VeryAggressive}
+
+// vowels are formant frequencies and bandwidths in Hz.
+var vowels = map[string][][2]float64{
+	"a": {{700, 80}, {1200, 90}, {2500, 120}},
+	"i": {{300, 60}, {2300, 100}, {3000, 150}},
+	"u": {{300, 60}, {870, 80}, {2250, 120}},
+}
+
+// voiced synthesizes frames of a sustained vowel: harmonics of f0 with a
+// slow drift and jitter, shaped by the formants, over a little noise.
+func voiced(rng *rand.Rand, f0 float64, formants [][2]float64, rms float64, frames int) [][]int16 {
+	phase := map[int]float64{}
+	out := make([][]int16, frames)
+	for n := range out {
+		f := f0 * (1 + 0.03*math.Sin(float64(n)/8) + 0.005*rng.NormFloat64())
+		x := make([]float64, frameSamples)
+		for k := 1; float64(k)*f < 3800; k++ {
+			h := float64(k) * f
+			var env float64
+			for _, fm := range formants {
+				env += 1 / (1 + math.Pow((h-fm[0])/fm[1], 2))
+			}
+			a := env / float64(k)
+			for i := range x {
+				x[i] += a * math.Sin(phase[k]+2*math.Pi*h*float64(i)/SampleRate)
+			}
+			phase[k] += 2 * math.Pi * h * frameSamples / SampleRate
+		}
+		out[n] = scaled(x, rms, rng, 0.01)
+	}
+	return out
+}
+
+// bandNoise synthesizes frames of noise between lo and hi Hz, like a
+// fricative such as "sh" through a telephone line.
+func bandNoise(rng *rand.Rand, lo, hi, rms float64, frames int) [][]int16 {
+	out := make([][]int16, frames)
+	for n := range out {
+		x := make([]float64, frameSamples)
+		for f := lo; f <= hi; f += 10 {
+			a, ph := rng.NormFloat64(), rng.Float64()*2*math.Pi
+			for i := range x {
+				x[i] += a * math.Sin(ph+2*math.Pi*f*float64(i)/SampleRate)
+			}
+		}
+		out[n] = scaled(x, rms, rng, 0)
+	}
+	return out
+}
+
+func whiteNoise(rng *rand.Rand, rms float64, frames int) [][]int16 {
+	out := make([][]int16, frames)
+	for n := range out {
+		out[n] = make([]int16, frameSamples)
+		for i := range out[n] {
+			out[n][i] = int16(rng.NormFloat64() * rms)
+		}
+	}
+	return out
+}
+
+// tones synthesizes frames of the sum of sine waves at hz, each with peak
+// amplitude amp, passed through μ-law like audio from Vobiz.
+func tones(amp float64, frames int, hz ...float64) [][]int16 {
+	out := make([][]int16, frames)
+	for n := range out {
+		pcm := make([]int16, frameSamples)
+		for i := range pcm {
+			t := float64(n*frameSamples+i) / SampleRate
+			var v float64
+			for _, f := range hz {
+				v += amp * math.Sin(2*math.Pi*f*t)
+			}
+			pcm[i] = int16(v)
+		}
+		out[n] = audio.BytesToInt16(audio.MuLawToPCM(audio.PCMToMuLaw(audio.Int16ToBytes(pcm))))
+	}
+	return out
+}
+
+// scaled sets x to rms and adds white noise at noise times that level.
+func scaled(x []float64, rms float64, rng *rand.Rand, noise float64) []int16 {
+	var sum float64
+	for _, v := range x {
+		sum += v * v
+	}
+	g := rms / math.Sqrt(sum/float64(len(x)))
+	pcm := make([]int16, len(x))
+	for i, v := range x {
+		pcm[i] = int16(max(-32767, min(32767, v*g+rng.NormFloat64()*rms*noise)))
+	}
+	return pcm
+}
+
+// speechFrames runs a fresh detector over a short quiet lead-in and then
+// frames, and counts the frames labelled speech.
+func speechFrames(mode Mode, frames [][]int16) int {
+	d := New(mode)
+	for range 10 {
+		d.Process(make([]int16, frameSamples))
+	}
+	n := 0
+	for _, f := range frames {
+		if d.IsSpeech(f) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestVoicedSpeechIsSpeechInEveryMode(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, f0 := range []float64{100, 150, 220} {
+		for name, formants := range vowels {
+			frames := voiced(rng, f0, formants, 2000, 50)
+			for _, mode := range modes {
+				if n := speechFrames(mode, frames); n < 48 {
+					t.Errorf("/%s/ at %vHz, mode %d: %d of 50 frames speech", name, f0, mode, n)
+				}
+			}
+		}
+	}
+}
+
+func TestUnvoicedSpeechIsSpeech(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	frames := bandNoise(rng, 1800, 3400, 800, 25)
+	for _, mode := range modes {
+		if n := speechFrames(mode, frames); n < 23 {
+			t.Errorf("mode %d: %d of 25 fricative frames speech", mode, n)
+		}
+	}
+}
+
+func TestWhiteNoiseIsNotSpeech(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	frames := whiteNoise(rng, 4000, 500)
+	for _, mode := range modes {
+		if n := speechFrames(mode, frames); n != 0 {
+			t.Errorf("mode %d: %d of 500 white noise frames speech", mode, n)
+		}
+	}
+}
+
+func TestTonesAreNotSpeech(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		hz   []float64
+	}{
+		{"1kHz", []float64{1000}},
+		{"dial tone", []float64{350, 440}},
+		{"DTMF 1", []float64{697, 1209}},
+		{"DTMF 0", []float64{941, 1336}},
+		{"DTMF #", []float64{941, 1477}},
+	} {
+		frames := tones(6000, 25, tt.hz...)
+		for _, mode := range modes {
+			if n := speechFrames(mode, frames); n != 0 {
+				t.Errorf("%s, mode %d: %d of 25 frames speech", tt.name, mode, n)
+			}
+		}
+	}
+}
+
+func TestSpeechRightAfterToneIsSpeech(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	d := New(Quality)
+	for _, f := range tones(6000, 5, 697, 1209) {
+		d.Process(f)
+	}
+	n := 0
+	for _, f := range voiced(rng, 150, vowels["a"], 2000, 10) {
+		if d.IsSpeech(f) {
+			n++
+		}
+	}
+	if n < 8 {
+		t.Errorf("%d of 10 frames after a keypress speech", n)
+	}
+}
+
+func TestNoiseFloorAdapts(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	d := New(Quality)
+
+	// Steady background noise is absorbed into the floor
+	var r Result
+	for _, f := range whiteNoise(rng, 1500, 1000) {
+		r = d.Process(f)
+	}
+	if r.SNR >= modeThresholds[Quality].snrDB {
+		t.Errorf("after 20s of steady noise its SNR is %.1fdB", r.SNR)
+	}
+
+	// Speech over it is still heard, and does not drag the floor up
+	floor := d.floor
+	n := 0
+	for _, f := range voiced(rng, 120, vowels["a"], 8000, 100) {
+		if d.IsSpeech(f) {
+			n++
+		}
+	}
+	if n < 95 {
+		t.Errorf("%d of 100 frames of speech over noise detected", n)
+	}
+	if d.floor-floor > 1.5 {
+		t.Errorf("2s of speech raised the floor by %.1fdB", d.floor-floor)
+	}
+
+	// The floor drops back quickly once the noise stops
+	for range 25 {
+		d.Process(make([]int16, frameSamples))
+	}
+	quiet := voiced(rng, 200, vowels["i"], 400, 10)
+	if r := d.Process(quiet[0]); r.SNR < modeThresholds[Quality].snrDB {
+		t.Errorf("half a second after the noise stopped, quiet speech is %.1fdB over the floor", r.SNR)
+	}
+}
+
+func TestTrackerStartsAndEndsUtterances(t *testing.T) {
+	tr := NewTracker()
+	var events []Event
+	for _, speech := range []bool{true, true, false, true, true, true, true} {
+		if ev := tr.Update(speech); ev != None {
+			events = append(events, ev)
+		}
+	}
+	if len(events) != 1 || events[0] != Start || !tr.Speaking() {
+		t.Fatalf("events %v, speaking %v; want one start", events, tr.Speaking())
+	}
+	for i := 0; i < tr.EndFrames-1; i++ {
+		if ev := tr.Update(false); ev != None {
+			t.Fatalf("frame %d of hangover: %v", i+1, ev)
+		}
+	}
+	if ev := tr.Update(false); ev != End || tr.Speaking() {
+		t.Errorf("after %d quiet frames got %v, want end", tr.EndFrames, ev)
+	}
+}
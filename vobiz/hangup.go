@@ -0,0 +1,48 @@
+// Package vobiz calls the Vobiz REST API on behalf of a live call.
+package vobiz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+)
+
+// BaseURL is the account API root.
+var BaseURL = "https://api.vobiz.ai/api/v1/Account"
+
+var logger = logging.For("vobiz")
+
+// Hangup ends a live call by its Vobiz call ID.
+func Hangup(ctx context.Context, callID string) error {
+	if callID == "" {
+		return fmt.Errorf("hangup: no call id")
+	}
+	authID := os.Getenv("VOBIZ_AUTH_ID")
+	url := fmt.Sprintf("%s/%s/Call/%s/", BaseURL, authID, callID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Auth-ID", authID)
+	req.Header.Set("X-Auth-Token", os.Getenv("VOBIZ_AUTH_TOKEN"))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tracing.HTTPClient(10 * time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("vobiz api returned error status: %s", resp.Status)
+	}
+
+	logger.InfoContext(ctx, "call terminated", "call_id", callID)
+	return nil
+}
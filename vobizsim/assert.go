@@ -0,0 +1,57 @@
+package vobizsim
+
+import "time"
+
+// TB is the part of testing.TB the assertions need, so the simulator can
+// be linked into commands without pulling in the testing package.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertPlayedAudio fails t unless the bridge played at least min of audio.
+func AssertPlayedAudio(t TB, c *Call, min time.Duration) {
+	t.Helper()
+	if got := c.PlayedDuration(); got < min {
+		t.Errorf("played %v of audio, want at least %v", got, min)
+	}
+}
+
+// AssertFirstAudioWithin fails t unless the first playAudio arrived within
+// d of the start event, e.g. to check the greeting starts promptly.
+func AssertFirstAudioWithin(t TB, c *Call, d time.Duration) {
+	t.Helper()
+	at, ok := c.FirstAudioAt()
+	if !ok {
+		t.Errorf("no audio played, want first audio within %v", d)
+		return
+	}
+	if at > d {
+		t.Errorf("first audio after %v, want within %v", at, d)
+	}
+}
+
+// AssertCleared fails t unless the bridge sent at least one clearAudio,
+// i.e. it handled a barge-in.
+func AssertCleared(t TB, c *Call) {
+	t.Helper()
+	if c.Count("clearAudio") == 0 {
+		t.Errorf("no clearAudio received")
+	}
+}
+
+// AssertNotCleared fails t if the bridge sent any clearAudio.
+func AssertNotCleared(t TB, c *Call) {
+	t.Helper()
+	if n := c.Count("clearAudio"); n > 0 {
+		t.Errorf("received %d clearAudio event(s), want none", n)
+	}
+}
+
+// AssertNoStreamError fails t if the media stream ended abnormally.
+func AssertNoStreamError(t TB, c *Call) {
+	t.Helper()
+	if err := c.Err(); err != nil {
+		t.Errorf("media stream error: %v", err)
+	}
+}
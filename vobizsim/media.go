@@ -0,0 +1,149 @@
+package vobizsim
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/audio"
+)
+
+// Vobiz sends 20ms frames of 8kHz μ-law.
+const (
+	FrameDuration = 20 * time.Millisecond
+	FrameBytes    = 160
+)
+
+func muLawBytes(d time.Duration) int {
+	return int(d * 8000 / time.Second)
+}
+
+// SendFrame sends one media event without pacing.
+func (c *Call) SendFrame(mulaw []byte) error {
+	c.writeMu.Lock()
+	c.seq++
+	seq := c.seq
+	c.writeMu.Unlock()
+
+	return c.send(map[string]interface{}{
+		"event":          "media",
+		"streamId":       c.Config.StreamID,
+		"sequenceNumber": seq,
+		"media": map[string]interface{}{
+			"track":     "inbound",
+			"chunk":     seq,
+			"timestamp": time.Since(c.started).Milliseconds(),
+			"payload":   base64.StdEncoding.EncodeToString(mulaw),
+		},
+	})
+}
+
+// SendDigits sends a dtmf event per key, like Vobiz reporting keypad
+// presses, with gap between them.
+func (c *Call) SendDigits(ctx context.Context, digits string, gap time.Duration) error {
+	for i, d := range digits {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		err := c.send(map[string]interface{}{
+			"event":    "dtmf",
+			"streamId": c.Config.StreamID,
+			"dtmf":     map[string]interface{}{"digit": string(d)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stream sends μ-law audio as 20ms frames in real time. The last frame is
+// padded with silence.
+func (c *Call) Stream(ctx context.Context, mulaw []byte) error {
+	ticker := time.NewTicker(FrameDuration)
+	defer ticker.Stop()
+
+	for off := 0; off < len(mulaw); off += FrameBytes {
+		frame := make([]byte, FrameBytes)
+		n := copy(frame, mulaw[off:])
+		for i := n; i < FrameBytes; i++ {
+			frame[i] = audio.MuLawSilence
+		}
+
+		if err := c.SendFrame(frame); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// StreamWAV streams a WAV file (16-bit PCM at any rate, or 8kHz μ-law)
+// in real time.
+func (c *Call) StreamWAV(ctx context.Context, path string) error {
+	w, err := audio.ReadWAVFile(path)
+	if err != nil {
+		return err
+	}
+	mulaw, err := w.MuLaw8k()
+	if err != nil {
+		return err
+	}
+	return c.Stream(ctx, mulaw)
+}
+
+// StreamSilence streams d of silence in real time, as a caller who is
+// listening would.
+func (c *Call) StreamSilence(ctx context.Context, d time.Duration) error {
+	silence := make([]byte, muLawBytes(d))
+	for i := range silence {
+		silence[i] = audio.MuLawSilence
+	}
+	return c.Stream(ctx, silence)
+}
+
+// PlayedAudio returns the μ-law audio of every playAudio event so far.
+func (c *Call) PlayedAudio() []byte {
+	var out []byte
+	for _, ev := range c.Events() {
+		if ev.Event == "playAudio" {
+			out = append(out, ev.Audio...)
+		}
+	}
+	return out
+}
+
+// PlayedDuration is the duration of PlayedAudio.
+func (c *Call) PlayedDuration() time.Duration {
+	return time.Duration(len(c.PlayedAudio())) * time.Second / 8000
+}
+
+// Count returns the number of events of the given kind, e.g. "clearAudio".
+func (c *Call) Count(event string) int {
+	n := 0
+	for _, ev := range c.Events() {
+		if ev.Event == event {
+			n++
+		}
+	}
+	return n
+}
+
+// FirstAudioAt returns when the first playAudio event arrived.
+func (c *Call) FirstAudioAt() (time.Duration, bool) {
+	for _, ev := range c.Events() {
+		if ev.Event == "playAudio" {
+			return ev.At, true
+		}
+	}
+	return 0, false
+}
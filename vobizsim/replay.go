@@ -0,0 +1,70 @@
+package vobizsim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AVVKavvk/openai-vobiz/capture"
+	"github.com/gorilla/websocket"
+)
+
+// Replay sends the Vobiz frames of a capture with their original spacing.
+// The start and stop events are rewritten to the call's CallUUID and
+// StreamID so a replay never touches the records of the captured call.
+func (c *Call) Replay(ctx context.Context, frames []capture.Frame) error {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	begin := time.Now()
+	first := frames[0].AtMs
+	for _, f := range frames {
+		due := begin.Add(time.Duration(f.AtMs-first) * time.Millisecond)
+		select {
+		case <-time.After(time.Until(due)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		data, event, err := c.rewrite(f.Data)
+		if err != nil {
+			return fmt.Errorf("frame at %dms: %w", f.AtMs, err)
+		}
+		if event == "stop" {
+			c.setEnding()
+		}
+		c.writeMu.Lock()
+		err = c.conn.WriteMessage(websocket.TextMessage, data)
+		c.writeMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("send frame at %dms: %w", f.AtMs, err)
+		}
+	}
+	return nil
+}
+
+// rewrite points a captured frame at this call and returns its event name.
+func (c *Call) rewrite(data json.RawMessage) ([]byte, string, error) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, "", err
+	}
+	event, _ := msg["event"].(string)
+	if event != "start" && event != "stop" {
+		return data, event, nil
+	}
+
+	if _, ok := msg["streamId"]; ok {
+		msg["streamId"] = c.Config.StreamID
+	}
+	if body, ok := msg[event].(map[string]interface{}); ok {
+		body["callId"] = c.Config.CallUUID
+		if _, ok := body["streamId"]; ok {
+			body["streamId"] = c.Config.StreamID
+		}
+	}
+	out, err := json.Marshal(msg)
+	return out, event, err
+}
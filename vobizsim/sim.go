@@ -0,0 +1,378 @@
+// Package vobizsim plays the Vobiz side of a call against a running
+// bridge: it answers through /incoming-call, follows the returned
+// <Stream> to the media websocket and streams caller audio in real time,
+// recording everything the bridge sends back.
+package vobizsim
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Config describes the simulated call.
+type Config struct {
+	// BaseURL of the bridge, e.g. "http://localhost:8080". For plain
+	// http the wss:// stream URL in the answer is dialled as ws://.
+	BaseURL string
+
+	CallUUID  string
+	From      string
+	To        string
+	StreamID  string
+	AccountID string
+
+	// BodyData is forwarded as the body_data query parameter, like the
+	// answer URL built by /outbound-call.
+	BodyData string
+	// Agent picks the agent by name through the agent query parameter;
+	// empty uses the default agent.
+	Agent string
+
+	HTTPClient *http.Client
+}
+
+func (cfg *Config) defaults() {
+	if cfg.CallUUID == "" {
+		cfg.CallUUID = fmt.Sprintf("sim-%d", time.Now().UnixNano())
+	}
+	if cfg.StreamID == "" {
+		cfg.StreamID = "stream-" + cfg.CallUUID
+	}
+	if cfg.AccountID == "" {
+		cfg.AccountID = "sim-account"
+	}
+	if cfg.From == "" {
+		cfg.From = "919999900000"
+	}
+	if cfg.To == "" {
+		cfg.To = "918000000000"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+}
+
+// StreamInstruction is the <Stream> element of the answer XML.
+type StreamInstruction struct {
+	URL           string
+	Bidirectional bool
+	KeepCallAlive bool
+	ContentType   string
+}
+
+type answerXML struct {
+	XMLName xml.Name `xml:"Response"`
+	Streams []struct {
+		Bidirectional bool   `xml:"bidirectional,attr"`
+		KeepCallAlive bool   `xml:"keepCallAlive,attr"`
+		ContentType   string `xml:"contentType,attr"`
+		URL           string `xml:",chardata"`
+	} `xml:"Stream"`
+}
+
+// Answer POSTs the call to /incoming-call like Vobiz does and returns the
+// stream the bridge asked for.
+func Answer(ctx context.Context, cfg Config) (*StreamInstruction, error) {
+	cfg.defaults()
+
+	answerURL := strings.TrimRight(cfg.BaseURL, "/") + "/incoming-call"
+	query := url.Values{}
+	if cfg.BodyData != "" {
+		query.Set("body_data", cfg.BodyData)
+	}
+	if cfg.Agent != "" {
+		query.Set("agent", cfg.Agent)
+	}
+	if len(query) > 0 {
+		answerURL += "?" + query.Encode()
+	}
+
+	form := url.Values{}
+	form.Set("CallUUID", cfg.CallUUID)
+	form.Set("From", cfg.From)
+	form.Set("To", cfg.To)
+	form.Set("Direction", "inbound")
+	form.Set("CallStatus", "in-progress")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, answerURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("answer call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read answer: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("answer call: status %d: %s", resp.StatusCode, body)
+	}
+
+	var answer answerXML
+	if err := xml.Unmarshal(body, &answer); err != nil {
+		return nil, fmt.Errorf("parse answer xml: %w", err)
+	}
+	if len(answer.Streams) == 0 {
+		return nil, errors.New("answer xml has no <Stream>")
+	}
+
+	s := answer.Streams[0]
+	streamURL := strings.TrimSpace(s.URL)
+	if strings.HasPrefix(cfg.BaseURL, "http://") {
+		streamURL = strings.Replace(streamURL, "wss://", "ws://", 1)
+	}
+	return &StreamInstruction{
+		URL:           streamURL,
+		Bidirectional: s.Bidirectional,
+		KeepCallAlive: s.KeepCallAlive,
+		ContentType:   s.ContentType,
+	}, nil
+}
+
+// Event is a message the bridge sent on the media stream.
+type Event struct {
+	Event string
+	// At is the time since the start event was sent
+	At time.Duration
+	// Audio is the decoded μ-law payload of playAudio events
+	Audio []byte
+	Raw   json.RawMessage
+}
+
+// Call is a simulated call with an open media stream.
+type Call struct {
+	Config      Config
+	Instruction StreamInstruction
+
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	seq     int
+	started time.Time
+
+	mu      sync.Mutex
+	events  []Event
+	changed chan struct{} // closed and replaced whenever events or closed change
+	closed  bool
+	ending  bool // Stop or Close was called
+	readErr error
+}
+
+// Start answers the call, opens the media stream and sends the start event.
+func Start(ctx context.Context, cfg Config) (*Call, error) {
+	cfg.defaults()
+
+	stream, err := Answer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !stream.Bidirectional {
+		return nil, errors.New("stream is not bidirectional")
+	}
+
+	c, err := Dial(ctx, cfg, *stream)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.send(map[string]interface{}{
+		"event":    "start",
+		"streamId": cfg.StreamID,
+		"start": map[string]interface{}{
+			"callId":    cfg.CallUUID,
+			"streamId":  cfg.StreamID,
+			"accountId": cfg.AccountID,
+			"tracks":    []string{"inbound"},
+			"mediaFormat": map[string]interface{}{
+				"encoding":   "audio/x-mulaw",
+				"sampleRate": 8000,
+			},
+		},
+	}); err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("send start: %w", err)
+	}
+	return c, nil
+}
+
+// Dial opens the media stream without answering the call or sending the
+// start event, for callers that send their own frames such as a replay.
+func Dial(ctx context.Context, cfg Config, stream StreamInstruction) (*Call, error) {
+	cfg.defaults()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, stream.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", stream.URL, err)
+	}
+
+	c := &Call{Config: cfg, Instruction: stream, conn: conn, changed: make(chan struct{})}
+	c.started = time.Now()
+	go c.read()
+	return c, nil
+}
+
+func (c *Call) send(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *Call) read() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			c.closed = true
+			// The bridges drop the socket without a close frame once the
+			// call is over, so only errors before that count
+			if !c.ending && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				c.readErr = err
+			}
+			c.broadcast()
+			c.mu.Unlock()
+			return
+		}
+
+		var msg struct {
+			Event string `json:"event"`
+			Media *struct {
+				Payload string `json:"payload"`
+			} `json:"media"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		ev := Event{Event: msg.Event, At: time.Since(c.started), Raw: data}
+		if msg.Media != nil && msg.Media.Payload != "" {
+			ev.Audio, _ = base64.StdEncoding.DecodeString(msg.Media.Payload)
+		}
+
+		c.mu.Lock()
+		c.events = append(c.events, ev)
+		c.broadcast()
+		c.mu.Unlock()
+	}
+}
+
+// broadcast wakes every waiter; c.mu must be held.
+func (c *Call) broadcast() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Stop sends the stop event Vobiz sends on hangup.
+func (c *Call) Stop() error {
+	c.setEnding()
+	return c.send(map[string]interface{}{
+		"event":    "stop",
+		"streamId": c.Config.StreamID,
+		"stop": map[string]interface{}{
+			"callId": c.Config.CallUUID,
+		},
+	})
+}
+
+// Close closes the media stream without a stop event, like a dropped line.
+func (c *Call) Close() error {
+	c.setEnding()
+	return c.conn.Close()
+}
+
+func (c *Call) setEnding() {
+	c.mu.Lock()
+	c.ending = true
+	c.mu.Unlock()
+}
+
+// Elapsed is the time since the stream was opened, on the same clock as
+// Event.At.
+func (c *Call) Elapsed() time.Duration {
+	return time.Since(c.started)
+}
+
+// Events returns a copy of the events received so far.
+func (c *Call) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event(nil), c.events...)
+}
+
+// Err returns the error that ended the stream, if the bridge did not
+// close it cleanly.
+func (c *Call) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readErr
+}
+
+// WaitFor blocks until an event matching match arrives (including ones
+// already received) or ctx is done.
+func (c *Call) WaitFor(ctx context.Context, match func(Event) bool) (Event, error) {
+	seen := 0
+	for {
+		c.mu.Lock()
+		for ; seen < len(c.events); seen++ {
+			if match(c.events[seen]) {
+				ev := c.events[seen]
+				c.mu.Unlock()
+				return ev, nil
+			}
+		}
+		closed, changed := c.closed, c.changed
+		c.mu.Unlock()
+
+		if closed {
+			return Event{}, errors.New("stream closed")
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		}
+	}
+}
+
+// WaitForAudio blocks until at least d of audio has been played.
+func (c *Call) WaitForAudio(ctx context.Context, d time.Duration) error {
+	want := muLawBytes(d)
+	got := 0
+	_, err := c.WaitFor(ctx, func(ev Event) bool {
+		got += len(ev.Audio)
+		return got >= want
+	})
+	return err
+}
+
+// WaitForClose blocks until the bridge closes the media stream.
+func (c *Call) WaitForClose(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		closed, changed := c.closed, c.changed
+		c.mu.Unlock()
+		if closed {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
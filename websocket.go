@@ -1,18 +1,42 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/AVVKavvk/openai-vobiz/agent"
+	"github.com/AVVKavvk/openai-vobiz/amd"
+	"github.com/AVVKavvk/openai-vobiz/audio"
+	"github.com/AVVKavvk/openai-vobiz/bargein"
+	"github.com/AVVKavvk/openai-vobiz/capture"
+	"github.com/AVVKavvk/openai-vobiz/catalog"
+	"github.com/AVVKavvk/openai-vobiz/dtmf"
+	"github.com/AVVKavvk/openai-vobiz/filler"
+	"github.com/AVVKavvk/openai-vobiz/inactivity"
+	"github.com/AVVKavvk/openai-vobiz/langdetect"
+	"github.com/AVVKavvk/openai-vobiz/limits"
+	"github.com/AVVKavvk/openai-vobiz/logging"
+	"github.com/AVVKavvk/openai-vobiz/metrics"
 	"github.com/AVVKavvk/openai-vobiz/models"
+	"github.com/AVVKavvk/openai-vobiz/openai"
+	"github.com/AVVKavvk/openai-vobiz/playout"
 	"github.com/AVVKavvk/openai-vobiz/rabbitmq"
-	"github.com/AVVKavvk/openai-vobiz/redisClient"
+	"github.com/AVVKavvk/openai-vobiz/tools"
+	"github.com/AVVKavvk/openai-vobiz/tracing"
+	"github.com/AVVKavvk/openai-vobiz/usage"
+	"github.com/AVVKavvk/openai-vobiz/vad"
+	"github.com/AVVKavvk/openai-vobiz/vobiz"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // --- Structs for Vobiz Messages ---
@@ -28,17 +52,9 @@ type VobizInboundMessage struct {
 	Media struct {
 		Payload string `json:"payload"`
 	} `json:"media,omitempty"`
-}
-
-type VobizOutboundMessage struct {
-	Event string      `json:"event"`
-	Media *VobizMedia `json:"media,omitempty"`
-}
-
-type VobizMedia struct {
-	ContentType string `json:"contentType"`
-	SampleRate  int    `json:"sampleRate"`
-	Payload     string `json:"payload"`
+	DTMF struct {
+		Digit string `json:"digit"`
+	} `json:"dtmf,omitempty"`
 }
 
 // --- Updated Structs for OpenAI Messages ---
@@ -76,18 +92,101 @@ type APIError struct {
 	Param   string `json:"param,omitempty"`
 }
 
+// lockedConn serialises writes to a websocket shared by goroutines.
+type lockedConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *lockedConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
 // --- Updated WebSocket Handler ---
 
 func HandleWebSocketStream(c echo.Context) error {
 	var OpenAIKey = os.Getenv("OPENAI_API_KEY")
-	var callId string
+	// Vobiz's call ID arrives with the start event on the read loop and is
+	// read from the provider, tool and timer goroutines
+	var callIdValue atomic.Value
+	callId := func() string {
+		id, _ := callIdValue.Load().(string)
+		return id
+	}
 
 	// Get the parameters from the URL
 	from := c.QueryParam("from")
 	to := c.QueryParam("to")
 	uuid := c.QueryParam("calluuid")
 
-	log.Printf("WS Connection for Call %s: From %s to %s", uuid, from, to)
+	// Every line for this call carries call_id, stream_id and provider;
+	// media frames arrive every 20ms so their logs are sampled
+	streamID := &logging.Lazy{}
+	callLog := logging.ForCall(uuid, "openai", streamID)
+	audioLog := logging.Sampled(callLog, 50)
+
+	// Root span for the whole call; every other request for this CallUUID joins its trace
+	callCtx, callSpan := tracing.StartCall(c.Request().Context(), uuid, attribute.String("provider", "openai"))
+	defer callSpan.End()
+	callLog.InfoContext(callCtx, "stream connecting", "from", from, "to", to)
+
+	// Render the agent prompt for this call before touching any socket
+	persona := agent.Get(c.QueryParam("agent"))
+	callSpan.SetAttributes(attribute.String("agent", persona.Name))
+	vars := agent.NewCallVars(uuid, from, to, c.QueryParam("body_data"), getCustomerInfo())
+	instructions, err := persona.RenderInstructions(vars)
+	if err != nil {
+		callLog.ErrorContext(callCtx, "failed to render instructions", "agent", persona.Name, "error", err)
+		return err
+	}
+	greeting, err := persona.RenderGreeting(vars)
+	if err != nil {
+		callLog.ErrorContext(callCtx, "failed to render greeting", "agent", persona.Name, "error", err)
+		return err
+	}
+	// The call starts in the agent's first language (or the campaign's) and
+	// follows the caller's once it is detected
+	lang := persona.StartLanguage(vars)
+	speech := langdetect.NewSession(lang.Code, persona.LanguageCodes())
+	sessionInstructions := func(l agent.Language) string {
+		if len(persona.Languages) == 0 {
+			return instructions
+		}
+		return instructions + "\n\n" + l.Instruction()
+	}
+	// Whisper is left to recognise the language until the call has one
+	transcription := func(code string) map[string]interface{} {
+		t := map[string]interface{}{"model": "whisper-1"}
+		if code != "" {
+			t["language"] = code
+		}
+		return t
+	}
+
+	// Model, voice and tuning come from the catalogue, the agent, the
+	// campaign and the outbound request
+	requested, err := catalog.ParseSelection(c.QueryParam("session"))
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring requested session settings", "error", err)
+	}
+	settings, err := persona.SessionSettings(catalog.OpenAI, lang, vars, requested)
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring invalid session settings", "error", err)
+	}
+	callSpan.SetAttributes(attribute.String("model", settings.Model), attribute.String("voice", settings.Voice))
+
+	// Outbound calls may ask to be screened for answering machines first
+	policy, err := amd.ParsePolicy(c.QueryParam("amd"), vars)
+	if err != nil {
+		callLog.WarnContext(callCtx, "ignoring machine detection policy", "error", err)
+	}
+	var screen *amd.Detector
+	if policy != nil {
+		screen = amd.NewDetector(uuid, amd.Config{})
+		defer screen.Close()
+	}
 
 	// 1. Upgrade Vobiz Connection
 	vobizWs, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
@@ -95,328 +194,708 @@ func HandleWebSocketStream(c echo.Context) error {
 		return err
 	}
 	defer vobizWs.Close()
-	log.Println("✅ Vobiz Connected")
+	callLog.InfoContext(callCtx, "vobiz connected", "agent", persona.Name)
+
+	// With CAPTURE_DIR set, every received frame is written out for replay
+	rec := capture.Start(capture.Header{CallUUID: uuid, Provider: "openai", Query: c.Request().URL.RawQuery})
+	defer rec.Close()
+
+	// Model audio is paced out in 20ms frames; from here on every write
+	// to Vobiz goes through the player
+	player := playout.New(vobizWs, func(err error) {
+		audioLog.WarnContext(callCtx, "failed to write to vobiz", "error", err)
+	})
+	defer func() {
+		callLog.InfoContext(callCtx, "playout closed", "played_ms", player.Total().Milliseconds(), "underruns", player.Underruns())
+		player.Close()
+	}()
+
+	// Slow tool calls get the agent's filler audio instead of dead air
+	fillerClip, fillerAfter, fillerLoop := persona.FillerAudio()
+	fill := filler.New(player, filler.Config{Clip: fillerClip, After: fillerAfter, Loop: fillerLoop})
+	defer fill.Close()
+
+	// Announce the end of the call for post-call processing, however the stream ends
+	startedAt := time.Now().UTC()
+	outcome := "disconnected"
+	meter := usage.NewMeter("openai", settings.Model)
+	limitCfg := persona.LimitsConfig()
+	limit := limits.New(limits.Config{
+		MaxDuration:   limitCfg.MaxDuration(),
+		WrapUpAt:      limitCfg.WrapUpAt(),
+		MaxCostUSD:    limitCfg.MaxCostUSD,
+		WrapUpCostUSD: limitCfg.WrapUpCostUSD(),
+	}, func() float64 { return meter.Snapshot().EstimatedCostUSD })
+	metrics.ActiveCalls.WithLabelValues("openai").Inc()
+	defer func() {
+		limit.Stop()
+		disposition := limit.Reason()
+		if disposition == "" {
+			disposition = screen.Disposition()
+		}
+		if disposition != "" {
+			outcome = disposition
+		}
+		metrics.ActiveCalls.WithLabelValues("openai").Dec()
+		metrics.CallsEnded.WithLabelValues("openai", outcome).Inc()
+		metrics.CallDuration.WithLabelValues("openai").Observe(time.Since(startedAt).Seconds())
+
+		endedId := callId()
+		if endedId == "" {
+			endedId = uuid
+		}
+		callUsage := meter.Snapshot()
+		callLog.InfoContext(callCtx, "call usage", "model", callUsage.Model, "estimated_cost_usd", callUsage.EstimatedCostUSD,
+			"input_audio_seconds", callUsage.InputAudioSeconds, "output_audio_seconds", callUsage.OutputAudioSeconds)
+		rabbitmq.PublishCallEnded(callCtx, models.CallModel{
+			CallId:      endedId,
+			Agent:       persona.Name,
+			Provider:    "openai",
+			Channel:     models.ChannelVoice,
+			Language:    speech.Language(),
+			From:        from,
+			To:          to,
+			ClaimRef:    vars.ClaimRef,
+			AnsweredBy:  screen.Result(),
+			Disposition: disposition,
+			StartedAt:   startedAt,
+			EndedAt:     time.Now().UTC(),
+			Usage:       &callUsage,
+		})
+	}()
 
 	// 2. Connect to OpenAI
 	header := http.Header{}
 	header.Add("Authorization", "Bearer "+OpenAIKey)
 	header.Add("OpenAI-Beta", "realtime=v1")
 
-	openAIWs, _, err := websocket.DefaultDialer.Dial(OpenAIRealtimeURL, header)
+	realtimeURL, err := openai.Endpoint(settings.Model)
 	if err != nil {
-		log.Printf("❌ Failed to connect to OpenAI: %v", err)
+		callLog.ErrorContext(callCtx, "invalid provider url", "error", err)
+		outcome = "provider_failed"
 		return err
 	}
+	conn, _, err := websocket.DefaultDialer.Dial(realtimeURL, header)
+	if err != nil {
+		callLog.ErrorContext(callCtx, "failed to connect to provider", "error", err)
+		metrics.ProviderErrors.WithLabelValues("openai", "dial").Inc()
+		outcome = "provider_failed"
+		return err
+	}
+	// Both goroutines below write to OpenAI
+	openAIWs := &lockedConn{Conn: conn}
 	defer openAIWs.Close()
-	log.Println("✅ OpenAI Connected")
+	callLog.InfoContext(callCtx, "provider connected")
+
+	// Tools from the shared registry are declared after the built-in ones
+	toolCall := tools.Call{CallId: uuid, From: from, To: to, ClaimRef: vars.ClaimRef}
+	toolDefs := []map[string]interface{}{
+		{
+			"type":        "function",
+			"name":        "call_end",
+			"description": "Ends the current phone call immediately. Trigger this when the conversation is finished or the user wants to hang up.",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"type":        "function",
+			"name":        "get_customer_info",
+			"description": "Retrieves the user's name, age, and address from the database.",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+	}
+	for _, t := range tools.List() {
+		toolDefs = append(toolDefs, map[string]interface{}{
+			"type":        "function",
+			"name":        t.Name,
+			"description": t.Description,
+			"parameters":  t.Parameters,
+		})
+	}
+
+	// An agent with a single language has nothing to detect
+	fixedLanguage := ""
+	if len(persona.Languages) == 1 {
+		fixedLanguage = lang.Code
+	}
 
 	// 3. Configure Session - Enable input transcription to get user's speech as text
 	sessionUpdate := map[string]interface{}{
 		"type": "session.update",
 		"session": map[string]interface{}{
-			"modalities": []string{"audio", "text"},
-			"instructions": `You are Anika, a claims support agent at KIWI Insurance. You are empathetic, efficient, and reassuring. 
-
-### CORE POLICIES:
-1. ZERO-REPETITION: Never repeat customer details. Use "Recorded" or "I have that noted" and move on.
-2. ONE QUESTION AT A TIME: Keep responses short and focused.
-3. SAFETY FIRST: Always confirm safety before data collection.
-
-### FUNCTION CALLING PROTOCOLS:
-- **get_customer_info**: Call this immediately if the user asks "What information do you have on me?" or if you need to verify their identity/address to proceed with the claim. Do not guess their details; use the tool.
-- **call_end**: Trigger this tool ONLY when:
-    a) The customer says goodbye or indicates they want to hang up.
-    b) You have provided the Claim Reference Number (#123098) and confirmed the WhatsApp link was sent.
-    c) The user confirms they have no further questions.
-    Always say a brief, professional closing (e.g., "Take care, goodbye") before the tool executes.
-
-### FNOL STEPS:
-1. Confirm Safety. 2. Build Reassurance. 3. Vehicle Reg (MH/KA/DL etc.). 4. Relationship to Policy. 5. Incident Narration (What/Where/When). 6. Fill Gaps. 7. Police/FIR (if injuries). 8. Closing & Reference Number.`,
-			"voice":               "alloy",
-			"input_audio_format":  "g711_ulaw",
-			"output_audio_format": "g711_ulaw",
-			"input_audio_transcription": map[string]interface{}{
-				"model": "whisper-1",
-			},
-			"tools": []map[string]interface{}{
-				{
-					"type":        "function",
-					"name":        "call_end",
-					"description": "Ends the current phone call immediately. Trigger this when the conversation is finished or the user wants to hang up.",
-					"parameters": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"callId": map[string]interface{}{"type": "string", "description": "The unique identifier for the call session."},
-						},
-						"required": []string{"callId"},
-					},
-				},
-				{
-					"type":        "function",
-					"name":        "get_customer_info",
-					"description": "Retrieves the user's name, age, and address from the database.",
-					"parameters": map[string]interface{}{
-						"type":       "object",
-						"properties": map[string]interface{}{},
-					},
-				},
-			},
-			"tool_choice": "auto",
-			"turn_detection": map[string]interface{}{
-				"type": "server_vad",
-			},
+			"modalities":                []string{"audio", "text"},
+			"instructions":              sessionInstructions(lang),
+			"voice":                     settings.Voice,
+			"temperature":               *settings.Temperature,
+			"input_audio_format":        "g711_ulaw",
+			"output_audio_format":       "g711_ulaw",
+			"input_audio_transcription": transcription(fixedLanguage),
+			"tools":                     toolDefs,
+			"tool_choice":               "auto",
+			"turn_detection":            turnDetection(settings),
 		},
 	}
 
 	if err := openAIWs.WriteJSON(sessionUpdate); err != nil {
-		log.Println("❌ Error sending session update:", err)
+		callLog.ErrorContext(callCtx, "failed to send session update", "error", err)
 		return err
 	}
-	log.Println("📤 Session configuration sent")
+	callLog.DebugContext(callCtx, "session configuration sent", "tools", len(toolDefs))
+
+	// switchLanguage moves the session to the caller's language. The voice
+	// stays: OpenAI does not change it once the model has spoken.
+	switchLanguage := func(l agent.Language) {
+		callLog.InfoContext(callCtx, "caller language detected", "language", l.Code)
+		err := openAIWs.WriteJSON(map[string]interface{}{
+			"type": "session.update",
+			"session": map[string]interface{}{
+				"instructions":              sessionInstructions(l),
+				"input_audio_transcription": transcription(l.Code),
+			},
+		})
+		if err != nil {
+			callLog.WarnContext(callCtx, "failed to switch language", "language", l.Code, "error", err)
+			return
+		}
+		rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{
+			Role:    "Event",
+			Content: "Caller speaks " + l.Name + ", AI switched to " + l.Name,
+			CallId:  callId(),
+		})
+	}
+
+	// A supervisor or the adjust_session tool may retune the session
+	voice := settings.Voice
+	unregister := catalog.Register(uuid, catalog.OpenAI, settings, func(s catalog.Settings) error {
+		session := map[string]interface{}{
+			"temperature":    *s.Temperature,
+			"turn_detection": turnDetection(s),
+		}
+		if s.Voice != voice {
+			if player.Total() > 0 {
+				return fmt.Errorf("openai: the voice cannot change once the model has spoken")
+			}
+			session["voice"] = s.Voice
+		}
+		if err := openAIWs.WriteJSON(map[string]interface{}{"type": "session.update", "session": session}); err != nil {
+			return fmt.Errorf("openai: %w", err)
+		}
+		voice = s.Voice
+		callLog.InfoContext(callCtx, "session settings changed", "voice", s.Voice, "temperature", *s.Temperature, "vad_threshold", s.VADThreshold)
+		rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{
+			Role:    "Event",
+			Content: "Session settings changed",
+			CallId:  callId(),
+		})
+		return nil
+	})
+	defer unregister()
+
+	publishEvent := func(content string) {
+		rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{Role: "Event", Content: content, CallId: callId()})
+	}
+	// sayGoodbye has the model speak closing, waits until the caller has
+	// heard it and hangs up
+	sayGoodbye := func(closing string) {
+		err := openAIWs.WriteJSON(map[string]interface{}{
+			"type": "response.create",
+			"response": map[string]interface{}{
+				"instructions": fmt.Sprintf("End the call by saying: %q", closing),
+			},
+		})
+		if err != nil {
+			callLog.WarnContext(callCtx, "failed to send closing line", "error", err)
+		}
+		player.Drain(10 * time.Second)
+		if err := vobiz.Hangup(callCtx, callId()); err != nil {
+			callLog.WarnContext(callCtx, "failed to hang up", "error", err)
+		}
+	}
+
+	// A silent caller is asked if they are still there, then hung up on
+	inactive := persona.InactivityConfig()
+	repromptAfter, hangupAfter := inactive.Timers()
+	idle := inactivity.Start(inactivity.Config{RepromptAfter: repromptAfter, HangupAfter: hangupAfter}, inactivity.Actions{
+		Reprompt: func(silence time.Duration) {
+			callLog.InfoContext(callCtx, "caller silent, reprompting", "silence_ms", silence.Milliseconds())
+			publishEvent(fmt.Sprintf("Caller silent for %.0fs, AI reprompted", silence.Seconds()))
+			err := openAIWs.WriteJSON(map[string]interface{}{
+				"type":     "response.create",
+				"response": map[string]interface{}{"instructions": inactive.Reprompt},
+			})
+			if err != nil {
+				callLog.WarnContext(callCtx, "failed to send reprompt", "error", err)
+			}
+		},
+		Hangup: func(silence time.Duration) {
+			callLog.InfoContext(callCtx, "caller silent after reprompt, hanging up", "silence_ms", silence.Milliseconds())
+			publishEvent(fmt.Sprintf("Caller silent for %.0fs after the reprompt, call ended", silence.Seconds()))
+			sayGoodbye(inactive.Closing)
+		},
+	})
+	defer idle.Stop()
 
 	// Channels to handle graceful shutdown
 	done := make(chan struct{})
 
+	// End of the caller's last utterance (unix nanos, 0 = none); response
+	// latency runs from there to the first frame of the answer reaching Vobiz
+	var speechStoppedAt atomic.Int64
+	player.OnItemStart(func(item string) {
+		if item == filler.Item {
+			return
+		}
+		if t := speechStoppedAt.Swap(0); t != 0 {
+			metrics.ResponseLatency.WithLabelValues("openai").Observe(time.Since(time.Unix(0, t)).Seconds())
+		}
+	})
+
 	// --- Goroutine A: OpenAI -> Vobiz (Speaking) ---
 	go func() {
 		defer close(done)
+
+		// One span per model response; tool calls nest under it
+		turnCtx := callCtx
+		var turnSpan trace.Span
+		// Assistant items the caller cut off, to flag their transcripts
+		truncated := map[string]bool{}
+		defer func() {
+			if turnSpan != nil {
+				turnSpan.End()
+			}
+		}()
+
 		for {
 			// Read raw message first to see what we're getting
 			_, rawMsg, err := openAIWs.ReadMessage()
 			if err != nil {
-				log.Println("❌ Error reading from OpenAI:", err)
+				callLog.WarnContext(callCtx, "provider read failed", "error", err)
+				metrics.ProviderErrors.WithLabelValues("openai", "read").Inc()
 				return
 			}
-
-			// Log raw JSON for debugging
-			// log.Printf("🔍 RAW OpenAI Message: %s", string(rawMsg))
+			rec.Provider(rawMsg)
 
 			// Now parse it
 			var msg map[string]interface{}
 			if err := json.Unmarshal(rawMsg, &msg); err != nil {
-				log.Printf("❌ Error parsing JSON: %v", err)
+				callLog.WarnContext(callCtx, "failed to parse provider event", "error", err)
 				continue
 			}
 
-			// fmt.Println("Openai MGS: %v", msg)
-
 			eventType, _ := msg["type"].(string)
-			log.Printf("--->>>>📩 ---->>> OpenAI Event Type: %s", eventType)
+			if eventType == "response.audio.delta" {
+				audioLog.DebugContext(turnCtx, "provider event", "event", eventType)
+			} else {
+				callLog.DebugContext(turnCtx, "provider event", "event", eventType)
+			}
 
 			switch eventType {
 			case "response.audio.delta":
 				// This is the actual audio data!
 				if delta, ok := msg["delta"].(string); ok && delta != "" {
-					// log.Printf("🔊 Got audio delta! Length: %d bytes", len(delta))
-					payload := VobizOutboundMessage{
-						Event: "playAudio",
-						Media: &VobizMedia{
-							ContentType: "audio/x-mulaw",
-							SampleRate:  8000,
-							Payload:     delta,
-						},
+					mulaw, err := base64.StdEncoding.DecodeString(delta)
+					if err != nil {
+						audioLog.WarnContext(turnCtx, "undecodable audio delta", "error", err)
+						break
 					}
-					if err := vobizWs.WriteJSON(payload); err != nil {
-						log.Printf("❌ Error sending audio to Vobiz: %v", err)
+					itemID, _ := msg["item_id"].(string)
+					if truncated[itemID] {
+						// Already in flight when the caller barged in; the
+						// caller must not hear past the truncation point
+						break
 					}
+					fill.Stop()
+					player.Play(itemID, mulaw)
+					metrics.CountAudio("openai", metrics.Outbound, len(mulaw))
+					meter.AddMuLaw(metrics.Outbound, len(mulaw))
 				}
 
 			// case "response.audio_transcript.delta":
 			// 	// Assistant's transcript (what AI is saying)
-			// 	if delta, ok := msg["delta"].(string); ok && delta != "" {
-			// 		log.Printf("🤖 AI says: %s", delta)
-			// 	}
 			case "response.audio_transcript.done":
 				// Assistant's transcript (what AI is saying)
-				fmt.Println("Openai MGS: %v", msg)
 				if delta, ok := msg["transcript"].(string); ok && delta != "" {
-					log.Printf("🤖 AI whole transcript: %s", delta)
+					itemID, _ := msg["item_id"].(string)
+					callLog.DebugContext(turnCtx, "transcript", "role", "AI", "chars", len(delta), "interrupted", truncated[itemID])
 					trans := models.TranscriptModel{
-						Role:    "AI",
-						Content: delta,
-						CallId:  callId,
+						Role:        "AI",
+						Content:     delta,
+						CallId:      callId(),
+						Interrupted: truncated[itemID],
 					}
-					rabbitmq.RabbitMQProducer(trans)
+					rabbitmq.RabbitMQProducerWithContext(turnCtx, trans)
 
 				}
 
 			case "conversation.item.input_audio_transcription.completed":
 				// USER'S TRANSCRIPT - This is what the user said!
-				fmt.Println("Openai MGS: %v", msg)
 				if transcript, ok := msg["transcript"].(string); ok && transcript != "" {
-					log.Printf("👤 USER said: %s", transcript)
+					idle.CallerSpoke()
+					callLog.DebugContext(callCtx, "transcript", "role", "User", "chars", len(transcript))
 					trans := models.TranscriptModel{
 						Role:    "User",
 						Content: transcript,
-						CallId:  callId,
+						CallId:  callId(),
 					}
-					rabbitmq.RabbitMQProducer(trans)
+					rabbitmq.RabbitMQProducerWithContext(callCtx, trans)
 
+					// Transcription models that identify the language report it
+					hint, _ := msg["language"].(string)
+					if code := speech.Observe(transcript, hint); code != "" {
+						switchLanguage(persona.Language(code))
+					}
 				}
 
-			// case "conversation.item.input_audio_transcription.delta":
-			// 	// USER'S TRANSCRIPT (streaming) - Real-time transcription
-			// 	if delta, ok := msg["delta"].(string); ok && delta != "" {
-			// 		log.Printf("👤 User speaking: %s", delta)
-			// 	}
-
 			case "input_audio_buffer.speech_started":
-				fmt.Println("Openai MGS: %v", msg)
-				log.Println("🎤 User started talking - Clearing Vobiz buffer")
-				vobizWs.WriteJSON(VobizOutboundMessage{Event: "clearAudio"})
+				// OpenAI's turn detection hears speech the local VAD may miss
+				idle.CallerSpoke()
+				fill.Stop()
+				cut := player.Clear()
+				callLog.DebugContext(callCtx, "caller started talking, cleared playout", "item_id", cut.Item, "played_ms", cut.Played.Milliseconds())
 				openAIWs.WriteJSON(map[string]string{"type": "response.cancel"})
 
+				// The caller heard only part of the reply; cut the model's
+				// copy to match so it doesn't think it said the rest
+				if cut.Item != "" && cut.Item != filler.Item && cut.Played < cut.Total {
+					truncated[cut.Item] = true
+					metrics.Interruptions.WithLabelValues("openai").Inc()
+					err := openAIWs.WriteJSON(map[string]interface{}{
+						"type":          "conversation.item.truncate",
+						"item_id":       cut.Item,
+						"content_index": 0,
+						"audio_end_ms":  cut.Played.Milliseconds(),
+					})
+					if err != nil {
+						callLog.WarnContext(callCtx, "failed to truncate interrupted item", "item_id", cut.Item, "error", err)
+					}
+					rabbitmq.RabbitMQProducerWithContext(callCtx, models.TranscriptModel{
+						Role:    "Event",
+						Content: fmt.Sprintf("AI interrupted by the caller after %.1fs of %.1fs", cut.Played.Seconds(), cut.Total.Seconds()),
+						CallId:  callId(),
+					})
+				}
+
+			case "conversation.item.truncated":
+				itemID, _ := msg["item_id"].(string)
+				audioEnd, _ := msg["audio_end_ms"].(float64)
+				callLog.DebugContext(callCtx, "item truncated", "item_id", itemID, "audio_end_ms", int64(audioEnd))
+
+			case "input_audio_buffer.speech_stopped":
+				speechStoppedAt.Store(time.Now().UnixNano())
+
 			case "error":
-				fmt.Println("Openai MGS: %v", msg)
+				metrics.ProviderErrors.WithLabelValues("openai", "api").Inc()
 				if errDetails, ok := msg["error"].(map[string]interface{}); ok {
-					log.Printf("❌ OpenAI Error: %v", errDetails)
+					callLog.ErrorContext(turnCtx, "provider error", "error", errDetails)
 				}
 
 			case "session.updated":
-				fmt.Println("Openai MGS: %v", msg)
-				log.Println("✅ Session Configured Successfully")
+				callLog.InfoContext(callCtx, "session configured")
+
+			case "response.created":
+				var responseID string
+				if resp, ok := msg["response"].(map[string]interface{}); ok {
+					responseID, _ = resp["id"].(string)
+				}
+				turnCtx, turnSpan = tracing.Tracer().Start(callCtx, "model.turn", trace.WithAttributes(
+					attribute.String("response.id", responseID),
+				))
 
 			case "response.done":
-				fmt.Println("Openai MGS: %v", msg)
-				log.Println("✅ Response completed")
-				// Log the full response.done to see what's inside
-				log.Printf("📋 Response Done Details: %v", msg)
+				var status string
+				if resp, ok := msg["response"].(map[string]interface{}); ok {
+					status, _ = resp["status"].(string)
+					if u, ok := resp["usage"].(map[string]interface{}); ok {
+						meter.Add(openai.Tokens(u))
+					}
+				}
+				callLog.DebugContext(turnCtx, "response completed", "status", status)
+				if turnSpan != nil {
+					turnSpan.SetAttributes(attribute.String("response.status", status))
+					turnSpan.End()
+					turnCtx, turnSpan = callCtx, nil
+				}
 			case "response.function_call_arguments.done":
-				fmt.Println("Openai MGS: %v", msg)
 				// OpenAI has finished generating arguments for a function
 				fnName, _ := msg["name"].(string)
 				argsRaw, _ := msg["arguments"].(string)
 				callID, _ := msg["call_id"].(string) // OpenAI's internal tool call ID
 
-				log.Printf("🛠️ Tool Call: %s with args: %s", fnName, argsRaw)
-
-				var toolOutput interface{}
-
-				if fnName == "get_customer_info" {
-					toolOutput = getCustomerInfo()
-				} else if fnName == "call_end" {
-					// Parse the callId from the AI's arguments
-					var args struct {
-						CallId string `json:"callId"`
+				callLog.InfoContext(turnCtx, "tool call", "tool", fnName, "tool_call_id", callID)
+
+				// Tools run off the read loop so model audio keeps flowing; a
+				// slow one gets filler audio
+				parentCtx := turnCtx
+				go func() {
+					defer fill.Begin()()
+
+					var toolOutput interface{}
+					toolStart := time.Now()
+					toolCtx, toolSpan := tracing.Tracer().Start(parentCtx, "tool "+fnName, trace.WithAttributes(
+						attribute.String("tool.name", fnName),
+						attribute.String("tool.call_id", callID),
+					))
+
+					if fnName == "get_customer_info" {
+						toolOutput = getCustomerInfo()
+					} else if fnName == "call_end" {
+						// Only ever this stream's own call, never one the model names
+						err := vobiz.Hangup(toolCtx, callId())
+						if err != nil {
+							toolOutput = map[string]string{"error": err.Error()}
+						} else {
+							toolOutput = map[string]string{"status": "call_terminated"}
+						}
+					} else {
+						var args map[string]interface{}
+						json.Unmarshal([]byte(argsRaw), &args)
+						toolOutput = tools.Execute(toolCtx, toolCall, fnName, args)
 					}
-					json.Unmarshal([]byte(argsRaw), &args)
-
-					// Use the callId provided by the AI, or fallback to the one captured in 'start' event
-					targetID := args.CallId
-					if targetID == "" {
-						targetID = callId
+					metrics.ObserveTool(fnName, toolStart, toolFailed(toolOutput))
+					if toolFailed(toolOutput) {
+						callLog.WarnContext(toolCtx, "tool failed", "tool", fnName, "result", toolOutput)
+						toolSpan.SetStatus(codes.Error, "tool returned an error")
 					}
-
-					err := callEnd(callId)
-					if err != nil {
-						toolOutput = map[string]string{"error": err.Error()}
-					} else {
-						toolOutput = map[string]string{"status": "call_terminated"}
+					toolSpan.End()
+
+					// STEP 3: Send the result back to OpenAI
+					outputBytes, _ := json.Marshal(toolOutput)
+					responseEvent := map[string]interface{}{
+						"type": "conversation.item.create",
+						"item": map[string]interface{}{
+							"type":    "function_call_output",
+							"call_id": callID,
+							"output":  string(outputBytes),
+						},
 					}
-				}
+					openAIWs.WriteJSON(responseEvent)
 
-				// STEP 3: Send the result back to OpenAI
-				outputBytes, _ := json.Marshal(toolOutput)
-				responseEvent := map[string]interface{}{
-					"type": "conversation.item.create",
-					"item": map[string]interface{}{
-						"type":    "function_call_output",
-						"call_id": callID,
-						"output":  string(outputBytes),
-					},
-				}
-				openAIWs.WriteJSON(responseEvent)
-
-				// Trigger the AI to acknowledge the info and continue speaking
-				openAIWs.WriteJSON(map[string]interface{}{"type": "response.create"})
+					// Trigger the AI to acknowledge the info and continue speaking
+					openAIWs.WriteJSON(map[string]interface{}{"type": "response.create"})
+				}()
 			}
 
 		}
 	}()
 
 	// --- Goroutine B: Vobiz -> OpenAI (Listening) ---
-	for {
-
-		var msg VobizInboundMessage
-		err = vobizWs.ReadJSON(&msg)
+	// OpenAI does its own turn detection; the local VAD only measures the
+	// caller the same way as on other providers
+	guard := bargein.New(player, bargein.DefaultConfig)
+
+	// Keypad presses outside collect_digits reach the model as text
+	keypad := dtmf.NewKeypad(uuid, func(digits string) {
+		shown := digits
+		if !persona.RecordKeypadDigits {
+			shown = dtmf.Mask(digits)
+		}
+		publishEvent("Caller pressed " + shown + " on the keypad")
+		err := openAIWs.WriteJSON(map[string]interface{}{
+			"type": "conversation.item.create",
+			"item": map[string]interface{}{
+				"type":    "message",
+				"role":    "user",
+				"content": []map[string]interface{}{{"type": "input_text", "text": "[Caller pressed " + digits + " on the keypad]"}},
+			},
+		})
+		if err == nil {
+			err = openAIWs.WriteJSON(map[string]interface{}{"type": "response.create"})
+		}
 		if err != nil {
+			callLog.WarnContext(callCtx, "failed to forward keypad input", "error", err)
+		}
+	})
+	defer keypad.Close()
+	var tones dtmf.Detector
+
+	// Near a limit the model is told to wrap up; at it the call ends
+	limit.Start(limits.Actions{
+		WrapUp: func(reason string) {
+			callLog.InfoContext(callCtx, "call limit near, wrapping up", "limit", reason)
+			publishEvent("Call nearing its " + limits.Describe(reason) + ", AI asked to wrap up")
+			err := openAIWs.WriteJSON(map[string]interface{}{
+				"type": "conversation.item.create",
+				"item": map[string]interface{}{
+					"type":    "message",
+					"role":    "system",
+					"content": []map[string]interface{}{{"type": "input_text", "text": limitCfg.WrapUp}},
+				},
+			})
+			if err != nil {
+				callLog.WarnContext(callCtx, "failed to send wrap-up instruction", "error", err)
+			}
+		},
+		End: func(reason string) {
+			callLog.InfoContext(callCtx, "call limit reached, hanging up", "limit", reason, "estimated_cost_usd", meter.Snapshot().EstimatedCostUSD)
+			idle.Stop()
+			publishEvent("Call reached its " + limits.Describe(reason) + ", call ended")
+			sayGoodbye(limitCfg.Closing)
+		},
+	})
+
+	greet := func() {
+		// STEP 1: Add a conversation item first
+		conversationItem := map[string]interface{}{
+			"type": "conversation.item.create",
+			"item": map[string]interface{}{
+				"type": "message",
+				"role": "user",
+				"content": []map[string]interface{}{
+					{
+						"type": "input_text",
+						"text": "Hello",
+					},
+				},
+			},
+		}
+
+		if err := openAIWs.WriteJSON(conversationItem); err != nil {
+			callLog.ErrorContext(callCtx, "failed to create conversation item", "error", err)
+		}
+
+		// Small delay
+		time.Sleep(50 * time.Millisecond)
+
+		// STEP 2: Now create the response with explicit modalities
+		triggerMsg := map[string]interface{}{
+			"type": "response.create",
+			"response": map[string]interface{}{
+				"modalities":   []string{"audio", "text"},
+				"instructions": fmt.Sprintf("Greet the caller by saying: %q", greeting),
+			},
+		}
+
+		if err := openAIWs.WriteJSON(triggerMsg); err != nil {
+			callLog.ErrorContext(callCtx, "failed to trigger greeting", "error", err)
+		} else {
+			callLog.DebugContext(callCtx, "greeting triggered")
+		}
+	}
 
-			transcrips := redisClient.GetAllTranscript(callId)
-			for _, transcript := range transcrips {
-				// fmt.Printf("📞 Transcript: %v\n", transcript)
-				fmt.Printf("Role: %s \t", transcript.Role)
-				fmt.Printf("Content: %s \t", transcript.Content)
-				fmt.Printf("CallId: %s \n", transcript.CallId)
+	// While screening, caller audio goes to the detector instead of the
+	// model; a person gets the greeting, a machine the voicemail message
+	// or a hangup
+	hangUpOnMachine := func(disposition, message string) {
+		idle.Stop()
+		screen.SetDisposition(disposition)
+		if message != "" {
+			sayGoodbye(message)
+			return
+		}
+		if err := vobiz.Hangup(callCtx, callId()); err != nil {
+			callLog.WarnContext(callCtx, "failed to hang up", "error", err)
+		}
+	}
+	onScreen := func(ev amd.Event) {
+		switch ev {
+		case amd.Answered:
+			result := screen.Result()
+			callLog.InfoContext(callCtx, "call answered", "answered_by", result, "reason", screen.Reason())
+			publishEvent("Call answered by " + result)
+			switch {
+			case result != amd.Machine:
+				greet()
+			case policy.Action == amd.ActionHangup:
+				publishEvent("Answering machine, call ended")
+				go hangUpOnMachine(models.DispositionMachine, "")
+			}
+		case amd.MessageReady:
+			message, err := policy.RenderMessage(vars)
+			if err != nil {
+				callLog.ErrorContext(callCtx, "failed to render voicemail message", "error", err)
+				publishEvent("Answering machine, call ended")
+				go hangUpOnMachine(models.DispositionMachine, "")
+				return
 			}
+			callLog.InfoContext(callCtx, "leaving voicemail")
+			publishEvent("Answering machine, AI left a voicemail")
+			go hangUpOnMachine(models.DispositionVoicemail, message)
+		}
+	}
+
+	for {
 
-			log.Println("🛑 Vobiz connection closed:", err)
+		var msg VobizInboundMessage
+		_, rawMsg, err := vobizWs.ReadMessage()
+		if err != nil {
+			callLog.InfoContext(callCtx, "vobiz connection closed", "error", err)
 			break
 		}
+		rec.Vobiz(rawMsg)
+		if err := json.Unmarshal(rawMsg, &msg); err != nil {
+			callLog.WarnContext(callCtx, "failed to parse vobiz message", "error", err)
+			continue
+		}
 
 		switch msg.Event {
 		case "start":
-			callId = msg.Start.CallId
+			callIdValue.Store(msg.Start.CallId)
 
-			// %+v is your best friend for debugging structs!
-			log.Printf("Full Message Received: %+v", msg)
-			log.Printf("📞 Call Started (SID: %s) (CallID: %s)", msg.Start.StreamId, msg.Start.CallId)
-			log.Println(callId)
+			streamID.Set(msg.Start.StreamId)
+			callLog.InfoContext(callCtx, "call started", "vobiz_call_id", msg.Start.CallId)
 
 			// Wait a moment for session to be fully configured
 			time.Sleep(200 * time.Millisecond)
 
-			// STEP 1: Add a conversation item first
-			conversationItem := map[string]interface{}{
-				"type": "conversation.item.create",
-				"item": map[string]interface{}{
-					"type": "message",
-					"role": "user",
-					"content": []map[string]interface{}{
-						{
-							"type": "input_text",
-							"text": "Hello",
-						},
-					},
-				},
-			}
-
-			if err := openAIWs.WriteJSON(conversationItem); err != nil {
-				log.Printf("❌ Error creating conversation item: %v", err)
-			} else {
-				log.Println("📝 Conversation item created")
-			}
-
-			// Small delay
-			time.Sleep(50 * time.Millisecond)
-
-			// STEP 2: Now create the response with explicit modalities
-			triggerMsg := map[string]interface{}{
-				"type": "response.create",
-				"response": map[string]interface{}{
-					"modalities":   []string{"audio", "text"},
-					"instructions": "Introduce yourself as Hello, I'm Anika from KIWI Insurance and ask how you can help.",
-				},
-			}
-
-			if err := openAIWs.WriteJSON(triggerMsg); err != nil {
-				log.Printf("❌ Error triggering greeting: %v", err)
-			} else {
-				log.Println("🚀 Response creation triggered (with audio modality)")
+			// A screened call is greeted once a person is known to be there
+			if screen.Done() {
+				greet()
 			}
 
 		case "media":
 			if msg.Media.Payload != "" {
+				mulaw, _ := base64.StdEncoding.DecodeString(msg.Media.Payload)
+				pcm := audio.BytesToInt16(audio.MuLawToPCM(mulaw))
+				if key, ok := tones.Process(pcm); ok && keypad.Press(key, dtmf.SourceInBand) {
+					idle.CallerSpoke()
+				}
+				hasAudio, ev := guard.Frame(pcm, time.Now())
+				if !screen.Done() {
+					idle.Hold()
+					onScreen(screen.Frame(pcm, guard.Speaking()))
+					continue
+				}
+				if hasAudio {
+					idle.CallerSpoke()
+					metrics.CallerSpeechSeconds.WithLabelValues("openai").Add(playout.FrameDuration.Seconds())
+				} else if player.Busy() || keypad.Collecting() {
+					idle.Hold()
+				}
+				switch ev {
+				case vad.Start:
+					metrics.CallerUtterances.WithLabelValues("openai").Inc()
+					callLog.DebugContext(callCtx, "caller speech started", "model_audible", player.Busy())
+				case vad.End:
+					callLog.DebugContext(callCtx, "caller speech ended")
+				}
+
 				openAIEvent := OpenAIEvent{
 					Type:  "input_audio_buffer.append",
 					Audio: msg.Media.Payload,
 				}
 				if err := openAIWs.WriteJSON(openAIEvent); err != nil {
-					log.Printf("❌ Error sending audio to OpenAI: %v", err)
+					audioLog.WarnContext(callCtx, "failed to send audio to provider", "error", err)
+					metrics.ProviderErrors.WithLabelValues("openai", "write").Inc()
+				} else {
+					metrics.CountAudioBase64("openai", metrics.Inbound, msg.Media.Payload)
+					meter.AddMuLaw(metrics.Inbound, metrics.DecodedLen(msg.Media.Payload))
+					audioLog.DebugContext(callCtx, "caller audio forwarded", "payload", msg.Media.Payload)
 				}
 			}
 
+		case "dtmf":
+			if msg.DTMF.Digit != "" && keypad.Press(msg.DTMF.Digit[0], dtmf.SourceVobiz) {
+				idle.CallerSpoke()
+			}
+
 		case "stop":
-			log.Println("🛑 Stream Stopped by Vobiz")
+			callLog.InfoContext(callCtx, "stream stopped by vobiz")
+			outcome = "stopped"
 
 			return nil
 		}
@@ -425,39 +904,27 @@ func HandleWebSocketStream(c echo.Context) error {
 	return nil
 }
 
-func callEnd(callId string) error {
-	var VobizAuthID = os.Getenv("VOBIZ_AUTH_ID")
-	var VobizAuthToken = os.Getenv("VOBIZ_AUTH_TOKEN")
-
-	// Construct the URL using the account ID and call UUID
-	url := fmt.Sprintf("https://api.vobiz.ai/api/v1/Account/%s/Call/%s/", VobizAuthID, callId)
-
-	// Create a new DELETE request
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// turnDetection is the server VAD config for s; the threshold is left to
+// OpenAI unless set.
+func turnDetection(s catalog.Settings) map[string]interface{} {
+	td := map[string]interface{}{"type": "server_vad"}
+	if s.VADThreshold != nil {
+		td["threshold"] = *s.VADThreshold
 	}
+	return td
+}
 
-	// Add the required headers
-	req.Header.Set("X-Auth-ID", VobizAuthID)
-	req.Header.Set("X-Auth-Token", VobizAuthToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check if the response status is successful (usually 200 OK or 204 No Content)
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("vobiz api returned error status: %s", resp.Status)
+// toolFailed reports whether a tool result carries an "error" key
+func toolFailed(output interface{}) bool {
+	switch out := output.(type) {
+	case map[string]string:
+		_, failed := out["error"]
+		return failed
+	case map[string]interface{}:
+		_, failed := out["error"]
+		return failed
 	}
-
-	log.Printf("Successfully terminated call: %s", callId)
-	return nil
+	return false
 }
 
 func getCustomerInfo() map[string]interface{} {